@@ -13,7 +13,10 @@ import (
 
 	"app/internal/domain/model"
 	"app/internal/infrastructure/email"
+	"app/internal/infrastructure/notifier"
 	"app/internal/infrastructure/persistance"
+	"app/internal/infrastructure/realtime"
+	"app/internal/infrastructure/scraper"
 	"app/internal/interface/cron"
 	"app/internal/interface/web/router"
 	"app/internal/usecase"
@@ -73,43 +76,114 @@ func main() {
 		log.Fatalf("Error al migrar la base de datos: %v", err)
 	}
 
-	// Inicializar servicio de email
-	mailer := email.NewMailer()
-
 	// --------------------------------------
 	// Repositorios y casos de uso
 	// --------------------------------------
 	userRepo := persistance.NewUserRepository(db.DB)
 	productRepo := persistance.NewProductRepository(db.DB)
+	productIdentifierRepo := persistance.NewProductIdentifierRepository(db.DB)
 	categoryRepo := persistance.NewCategoryRepository(db.DB)
 	priceRepo := persistance.NewPriceRepository(db.DB)
+	priceHistoryRepo := persistance.NewPriceHistoryRepository(db.DB)
 	priceAlertRepo := persistance.NewPriceAlertRepository(db.DB)
+	alertTriggerRepo := persistance.NewAlertTriggerRepository(db.DB)
+	savedSearchRepo := persistance.NewSavedSearchRepository(db.DB)
+	notificationChannelRepo := persistance.NewNotificationChannelRepository(db.DB)
 	watchlistRepo := persistance.NewWatchlistRepository(db.DB)
 	watchlistItemRepo := persistance.NewWatchlistItemRepository(db.DB)
 	notificationRepo := persistance.NewNotificationRepository(db.DB)
+	scrapeRunRepo := persistance.NewScrapeRunRepository(db.DB)
+	exchangeRateRepo := persistance.NewExchangeRateRepository(db.DB)
+
+	// Inicializar servicio de email: los correos se guardan en la bandeja de salida y los envía su
+	// worker. Las preferencias de correo de los destinatarios se consultan en el repositorio de usuarios
+	emailOutboxRepo := persistance.NewEmailOutboxRepository(db.DB)
+	mailer := email.NewMailer(emailOutboxRepo, userRepo)
+
+	// Scrapers de las tiendas habilitadas en la configuración
+	storeDefinitions, err := scraper.LoadStoreDefinitions(config.Config.Scraper.StoresDir)
+	if err != nil {
+		log.Fatalf("Error al cargar las definiciones de tiendas: %v", err)
+	}
+	storeRegistry := scraper.NewRegistry(config.Config.Stores, storeDefinitions)
+	fetcher := scraper.NewFetcher(config.Config.Scraper, nil)
+	switch mode := scraper.FixtureMode(config.Config.Scraper.FixturesMode); mode {
+	case "":
+		storeRegistry.UseTransport(fetcher)
+	case scraper.FixtureRecord:
+		log.Printf("Scrapers en modo fixtures '%s' (directorio: %s)", mode, config.Config.Scraper.FixturesDir)
+		storeRegistry.UseTransport(scraper.NewFixtureTransport(config.Config.Scraper.FixturesDir, mode, fetcher))
+	case scraper.FixtureReplay:
+		log.Printf("Scrapers en modo fixtures '%s' (directorio: %s)", mode, config.Config.Scraper.FixturesDir)
+		storeRegistry.UseTransport(scraper.NewFixtureTransport(config.Config.Scraper.FixturesDir, mode, nil))
+	default:
+		log.Fatalf("Modo de fixtures no válido: %s (usa \"record\" o \"replay\")", mode)
+	}
 
 	// Crear casos de uso
-	productUseCase := usecase.NewProductUseCase(productRepo, categoryRepo, priceRepo)
-	userUseCase := usecase.NewUserUseCase(userRepo, mailer)
-	scraperUseCase := usecase.NewScraperUseCase(categoryRepo, productRepo, priceRepo)
+	ctx := context.Background()
+
+	// Tipos de cambio: importar el archivo, cargarlos y normalizar los precios guardados
+	currencyUseCase := usecase.NewCurrencyUseCase(exchangeRateRepo, priceRepo, config.Config.Currency)
+	if imported, err := currencyUseCase.ImportRatesFile(ctx, config.Config.Currency.RatesFile); err != nil {
+		log.Fatalf("Error al importar los tipos de cambio: %v", err)
+	} else if imported > 0 {
+		log.Printf("Importados %d tipos de cambio de %s", imported, config.Config.Currency.RatesFile)
+	}
+	if err := currencyUseCase.LoadRates(ctx); err != nil {
+		log.Fatalf("Error al cargar los tipos de cambio: %v", err)
+	}
+	if _, err := currencyUseCase.RenormalizePrices(ctx); err != nil {
+		log.Printf("Error al normalizar los precios: %v", err)
+	}
+
+	productUseCase := usecase.NewProductUseCase(productRepo, categoryRepo, priceRepo, priceHistoryRepo)
+	userUseCase := usecase.NewUserUseCase(userRepo, mailer, currencyUseCase)
+	ingestionUseCase := usecase.NewIngestionUseCase(categoryRepo, productRepo, productIdentifierRepo, priceRepo, priceHistoryRepo, currencyUseCase)
+	scraperUseCase := usecase.NewScraperUseCase(categoryRepo, scrapeRunRepo, ingestionUseCase, storeRegistry)
+	storeHealthUseCase := usecase.NewStoreHealthUseCase(scrapeRunRepo, storeRegistry.Names())
+	// Los avisos se guardan en la aplicación, se publican en las pestañas abiertas del usuario y se
+	// envían por correo y al webhook de cada usuario
+	notificationBroker := realtime.NewBroker(config.Config.Notify.StreamBufferSize, config.Config.Notify.StreamMaxPerUser)
+	notificationChannelUseCase := usecase.NewNotificationChannelUseCase(
+		notificationRepo,
+		notificationChannelRepo,
+		notificationBroker,
+		config.Config.Notify,
+		notifier.NewEmailChannel(mailer),
+		notifier.NewWebhookChannel(config.Config.Notify),
+	)
+	// Se cierra después de detener el scheduler, para enviar también los avisos de sus últimas tareas
+	defer notificationChannelUseCase.Close()
 	priceAlertUseCase := usecase.NewPriceAlertUseCase(
 		priceAlertRepo,
+		alertTriggerRepo,
 		notificationRepo,
 		productRepo,
 		priceRepo,
+		priceHistoryRepo,
 		userRepo,
-		mailer,
+		notificationChannelUseCase,
+		currencyUseCase,
 	)
-
-	ctx := context.Background()
+	// Las alertas de un producto se evalúan en cuanto la ingesta registra un cambio en su precio
+	ingestionUseCase.OnPriceChange(priceAlertUseCase.HandlePriceChange)
+	// Los productos nuevos o que cambian de precio se comparan con las búsquedas guardadas
+	savedSearchUseCase := usecase.NewSavedSearchUseCase(savedSearchRepo, categoryRepo, productRepo, priceRepo, userRepo, notificationChannelUseCase, currencyUseCase)
+	ingestionUseCase.OnPriceChange(savedSearchUseCase.HandlePriceChange)
+	trackingUseCase := usecase.NewTrackingUseCase(scraperUseCase, priceAlertUseCase, watchlistRepo, watchlistItemRepo)
+	// Los usuarios que lo prefieren reciben sus avisos por correo en un resumen diario o semanal
+	digestUseCase := usecase.NewDigestUseCase(userRepo, notificationRepo, watchlistItemRepo, priceRepo, mailer, currencyUseCase)
+	emailOutboxUseCase := usecase.NewEmailOutboxUseCase(emailOutboxRepo, mailer, config.Config.Email)
+	refreshUseCase := usecase.NewRefreshUseCase(priceAlertRepo, watchlistItemRepo, priceRepo, ingestionUseCase, storeRegistry)
 
 	// Modo de prueba para scraping
 	if *testMode {
 		if *productURL != "" {
 			// Si se proporciona una URL de producto específica, hacer scraping solo de ese producto
 			log.Printf("Iniciando scraping del producto individual: %s", *productURL)
-			// Usar la primera categoría (ID=1, portátiles) por defecto
-			product, err := scraperUseCase.ScrapeProductDetails(ctx, *productURL, 1)
+			// La categoría se asigna automáticamente (ID=0)
+			product, err := scraperUseCase.ScrapeProductDetails(ctx, *productURL, 0)
 			if err != nil {
 				log.Printf("Error al hacer scraping del producto: %v", err)
 			} else {
@@ -124,6 +198,8 @@ func main() {
 				log.Printf("Error al ejecutar scraping: %v", err)
 			}
 		}
+		// Terminar de registrar las coincidencias de las búsquedas guardadas antes de salir
+		savedSearchUseCase.Wait()
 		log.Println("Scraping finalizado. Saliendo...")
 		return
 	}
@@ -134,15 +210,32 @@ func main() {
 	// --------------------------------------
 	// Configurar router
 	// --------------------------------------
-	r := router.SetupRouter(productUseCase, userUseCase, priceAlertUseCase, watchlistRepo, watchlistItemRepo)
+	r := router.SetupRouter(productUseCase, userUseCase, priceAlertUseCase, savedSearchUseCase, notificationChannelUseCase, digestUseCase, storeHealthUseCase, currencyUseCase, emailOutboxUseCase, trackingUseCase, watchlistRepo, watchlistItemRepo, notificationBroker, config.Config.Notify.StreamHeartbeat)
 
 	// --------------------------------------
 	// Scheduler de scraping
 	// --------------------------------------
-	scheduler := cron.NewScraperScheduler(productRepo, priceRepo, categoryRepo, priceAlertUseCase)
+	scheduler := cron.NewScraperScheduler(priceRepo, categoryRepo, scraperUseCase, priceAlertUseCase, refreshUseCase, config.Config.Scraper.PriorityRefreshInterval, storeRegistry)
 	scheduler.Start()
 	defer scheduler.Stop()
 
+	// --------------------------------------
+	// Worker de la bandeja de salida de correos
+	// --------------------------------------
+	outboxWorker := cron.NewEmailOutboxWorker(emailOutboxUseCase, mailer.Queued(), config.Config.Email.OutboxPollInterval)
+	outboxWorker.Start()
+	defer outboxWorker.Stop()
+
+	// --------------------------------------
+	// Resúmenes de avisos por correo
+	// --------------------------------------
+	digestJob, err := cron.NewDigestJob(digestUseCase, config.Config.Notify.DigestDailySchedule, config.Config.Notify.DigestWeeklySchedule)
+	if err != nil {
+		log.Fatalf("Error al programar los resúmenes de avisos: %v", err)
+	}
+	digestJob.Start()
+	defer digestJob.Stop()
+
 	// Iniciar servidor HTTP
 	port := os.Getenv("APP_PORT")
 	if port == "" {
@@ -153,6 +246,8 @@ func main() {
 		Addr:    fmt.Sprintf(":%s", port),
 		Handler: r,
 	}
+	// Las conexiones de notificaciones en tiempo real no terminan solas: se cierran al apagar
+	srv.RegisterOnShutdown(notificationBroker.Close)
 
 	// Manejar graceful shutdown
 	go func() {
@@ -176,6 +271,8 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatalf("Error en el apagado del servidor: %v", err)
 	}
+	// Terminar de registrar las coincidencias de las búsquedas guardadas recién creadas
+	savedSearchUseCase.Wait()
 
 	log.Println("Servidor apagado correctamente")
 }
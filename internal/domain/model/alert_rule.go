@@ -0,0 +1,145 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tipos de regla de una alerta de precio
+const (
+	AlertRuleTargetPrice  = "target_price"  // El mejor precio baja del objetivo (TargetPrice)
+	AlertRulePercentDrop  = "percent_drop"  // El mejor precio baja un porcentaje (RuleValue) respecto al de creación
+	AlertRuleAnyDrop      = "any_drop"      // El mejor precio baja respecto al de creación
+	AlertRuleAllTimeLow   = "all_time_low"  // El mejor precio es el más bajo del histórico
+	AlertRuleBackInStock  = "back_in_stock" // Alguna oferta vuelve a estar disponible
+	AlertRuleStorePrice   = "store_price"   // El precio en una tienda concreta (RuleStore) baja del objetivo
+	AlertRuleBelowAverage = "below_average" // El mejor precio baja de la media de los últimos N días (RuleValue)
+)
+
+// DefaultAverageDays es el periodo por defecto de la regla below_average
+const DefaultAverageDays = 30
+
+// AlertRuleTypes enumera los tipos de regla en el orden en que se ofrecen al usuario
+var AlertRuleTypes = []string{
+	AlertRuleTargetPrice,
+	AlertRulePercentDrop,
+	AlertRuleAnyDrop,
+	AlertRuleAllTimeLow,
+	AlertRuleBackInStock,
+	AlertRuleStorePrice,
+	AlertRuleBelowAverage,
+}
+
+// AlertRule es la condición que dispara una alerta de precio, junto con el estado que necesita
+// para evaluarla (precio de referencia y disponibilidad vista por última vez)
+type AlertRule struct {
+	RuleType  string  `gorm:"size:20;default:'target_price'" json:"rule_type"`
+	RuleValue float64 `gorm:"default:0" json:"rule_value"` // Porcentaje (percent_drop) o días (below_average)
+	RuleStore string  `gorm:"size:50" json:"rule_store"`   // Tienda de la regla store_price
+
+	// Estado de la regla
+	BaselinePrice float64 `gorm:"default:0" json:"baseline_price"`     // Mejor precio al crear o editar la alerta (percent_drop, any_drop)
+	LastAvailable bool    `gorm:"default:false" json:"last_available"` // Había alguna oferta disponible en la última evaluación (back_in_stock)
+}
+
+// Normalize devuelve la regla con un tipo válido (target_price si no se reconoce) y sus valores por
+// defecto. La tienda solo se conserva en la regla store_price
+func (r AlertRule) Normalize() AlertRule {
+	r.RuleStore = strings.TrimSpace(r.RuleStore)
+	switch r.RuleType {
+	case AlertRuleBelowAverage:
+		if r.RuleValue <= 0 {
+			r.RuleValue = DefaultAverageDays
+		}
+	case AlertRulePercentDrop, AlertRuleStorePrice:
+	case AlertRuleAnyDrop, AlertRuleAllTimeLow, AlertRuleBackInStock:
+		r.RuleValue = 0
+	default:
+		r.RuleType = AlertRuleTargetPrice
+		r.RuleValue = 0
+	}
+	if r.RuleType != AlertRuleStorePrice {
+		r.RuleStore = ""
+	}
+	return r
+}
+
+// Validate comprueba que la regla tiene los datos que necesita. targetPrice es el precio objetivo
+// de la alerta, que solo usan las reglas target_price y store_price
+func (r AlertRule) Validate(targetPrice float64) error {
+	switch r.RuleType {
+	case AlertRuleTargetPrice:
+		if targetPrice <= 0 {
+			return fmt.Errorf("el precio objetivo debe ser mayor que 0")
+		}
+	case AlertRuleStorePrice:
+		if targetPrice <= 0 {
+			return fmt.Errorf("el precio objetivo debe ser mayor que 0")
+		}
+		if r.RuleStore == "" {
+			return fmt.Errorf("debes indicar la tienda")
+		}
+	case AlertRulePercentDrop:
+		if r.RuleValue <= 0 || r.RuleValue >= 100 {
+			return fmt.Errorf("el porcentaje de bajada debe estar entre 0 y 100")
+		}
+	}
+	return nil
+}
+
+// UsesTargetPrice indica si la regla compara con el precio objetivo de la alerta
+func (r AlertRule) UsesTargetPrice() bool {
+	return r.RuleType == AlertRuleTargetPrice || r.RuleType == AlertRuleStorePrice || r.RuleType == ""
+}
+
+// SameRule indica si dos reglas tienen la misma condición, sin tener en cuenta su estado
+func (r AlertRule) SameRule(other AlertRule) bool {
+	return r.RuleType == other.RuleType && r.RuleValue == other.RuleValue && r.RuleStore == other.RuleStore
+}
+
+// AlertRuleLabel devuelve el nombre del tipo de regla para mostrarlo al usuario
+func AlertRuleLabel(ruleType string) string {
+	switch ruleType {
+	case AlertRulePercentDrop:
+		return "Bajada de un porcentaje"
+	case AlertRuleAnyDrop:
+		return "Cualquier bajada"
+	case AlertRuleAllTimeLow:
+		return "Mínimo histórico"
+	case AlertRuleBackInStock:
+		return "Vuelve a estar disponible"
+	case AlertRuleStorePrice:
+		return "Precio en una tienda"
+	case AlertRuleBelowAverage:
+		return "Por debajo de la media"
+	default:
+		return "Precio objetivo"
+	}
+}
+
+// RuleDescription describe la condición de la alerta para mostrarla en la cesta y en los avisos.
+// format da formato a los importes en la moneda del usuario
+func (a *PriceAlert) RuleDescription(format PriceFormatter) string {
+	switch a.RuleType {
+	case AlertRulePercentDrop:
+		if a.BaselinePrice > 0 {
+			return fmt.Sprintf("Baja un %.0f%% desde %s", a.RuleValue, format(a.BaselinePrice))
+		}
+		return fmt.Sprintf("Baja un %.0f%%", a.RuleValue)
+	case AlertRuleAnyDrop:
+		if a.BaselinePrice > 0 {
+			return "Baja de " + format(a.BaselinePrice)
+		}
+		return "Cualquier bajada de precio"
+	case AlertRuleAllTimeLow:
+		return "Nuevo mínimo histórico"
+	case AlertRuleBackInStock:
+		return "Vuelve a estar disponible"
+	case AlertRuleStorePrice:
+		return fmt.Sprintf("Baja de %s en %s", format(a.TargetPrice), a.RuleStore)
+	case AlertRuleBelowAverage:
+		return fmt.Sprintf("Por debajo de la media de %.0f días", a.RuleValue)
+	default:
+		return "Baja de " + format(a.TargetPrice)
+	}
+}
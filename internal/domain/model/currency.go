@@ -0,0 +1,33 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PriceFormatter da formato a un importe en la moneda de un usuario
+type PriceFormatter func(amount float64) string
+
+// currencySymbols son los símbolos de las monedas más habituales. El resto se muestran con su código ISO
+var currencySymbols = map[string]string{
+	"EUR": "€",
+	"USD": "$",
+	"GBP": "£",
+	"JPY": "¥",
+	"CHF": "CHF",
+}
+
+// CurrencySymbol devuelve el símbolo de una moneda ("€") o su código ISO si no tiene uno conocido
+func CurrencySymbol(currency string) string {
+	currency = strings.ToUpper(currency)
+	if symbol, ok := currencySymbols[currency]; ok {
+		return symbol
+	}
+	return currency
+}
+
+// FormatPrice da formato a un importe en una moneda ("99.99 €"). Todos los precios que se muestran
+// al usuario (web, correos, notificaciones y webhooks) pasan por aquí
+func FormatPrice(amount float64, currency string) string {
+	return fmt.Sprintf("%.2f %s", amount, CurrencySymbol(currency))
+}
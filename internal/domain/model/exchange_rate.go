@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+)
+
+// Orígenes de un tipo de cambio
+const (
+	ExchangeRateSourceFile  = "file"  // Importado del archivo de tipos de cambio
+	ExchangeRateSourceAdmin = "admin" // Introducido por un administrador
+)
+
+// ExchangeRate guarda el tipo de cambio de una moneda respecto a la moneda base de la
+// configuración (currency.base) a partir de una fecha. Se conservan los tipos anteriores
+type ExchangeRate struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Currency  string    `gorm:"not null;size:3;uniqueIndex:idx_exchange_rate_currency_date,priority:1" json:"currency"`
+	Rate      float64   `gorm:"not null" json:"rate"`                                                                  // Unidades de la moneda base que vale 1 unidad de Currency
+	Date      time.Time `gorm:"type:date;not null;uniqueIndex:idx_exchange_rate_currency_date,priority:2" json:"date"` // Fecha desde la que aplica
+	Source    string    `gorm:"size:20" json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -5,35 +5,150 @@ import (
 )
 
 // PriceAlert representa una alerta configurada por un usuario para recibir notificaciones
-// cuando un producto alcance un precio igual o menor al establecido
+// cuando se cumpla su regla (por defecto, que el producto alcance un precio igual o menor al
+// establecido)
 type PriceAlert struct {
-	ID            uint      `gorm:"primaryKey" json:"id"`
-	UserID        uint      `gorm:"not null;index:idx_alert_user" json:"user_id"`
-	ProductID     uint      `gorm:"not null;index:idx_alert_product" json:"product_id"`
-	TargetPrice   float64   `gorm:"not null" json:"target_price"`
-	NotifyByEmail bool      `gorm:"default:true" json:"notify_by_email"`
-	IsActive      bool      `gorm:"default:true" json:"is_active"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            uint              `gorm:"primaryKey" json:"id"`
+	UserID        uint              `gorm:"not null;index:idx_alert_user" json:"user_id"`
+	ProductID     uint              `gorm:"not null;index:idx_alert_product" json:"product_id"`
+	TargetPrice   float64           `gorm:"not null" json:"target_price"` // Solo en las reglas target_price y store_price
+	AlertRule     `gorm:"embedded"` // Condición que dispara la alerta
+	NotifyByEmail bool              `gorm:"default:true" json:"notify_by_email"`
+	IsActive      bool              `gorm:"default:true" json:"is_active"`
+	OfferFilter   `gorm:"embedded"` // Ofertas que cuentan para la alerta (solo nuevos, sin subastas)
+	NotifyPolicy  `gorm:"embedded"` // Cuándo se vuelve a notificar una alerta que ya se ha disparado
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+
+	// Estado del último disparo (vacío si la alerta no se ha disparado desde que se creó o se editó)
+	LastNotifiedPrice float64    `gorm:"default:0" json:"last_notified_price"`
+	LastNotifiedAt    *time.Time `json:"last_notified_at"`
 
 	// Relaciones
 	User    User    `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
 	Product Product `gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
 }
 
+// Modos de notificación de una alerta de precio
+const (
+	NotifyModeOneShot  = "one_shot" // Se notifica una vez y la alerta se desactiva
+	NotifyModeOnDrop   = "on_drop"  // Solo se vuelve a notificar si el precio baja del último notificado
+	NotifyModeCooldown = "cooldown" // Se vuelve a notificar, como mucho, una vez por periodo de espera
+)
+
+// DefaultCooldownHours es el periodo de espera por defecto del modo NotifyModeCooldown
+const DefaultCooldownHours = 24
+
+// NotifyPolicy indica cuándo se vuelve a notificar una alerta mientras el precio sigue por debajo
+// del objetivo
+type NotifyPolicy struct {
+	NotifyMode    string `gorm:"size:20;default:'on_drop'" json:"notify_mode"`
+	CooldownHours int    `gorm:"default:24" json:"cooldown_hours"` // Solo en modo cooldown
+}
+
+// Normalize devuelve la política con un modo válido (on_drop si no se reconoce) y un periodo de
+// espera positivo
+func (p NotifyPolicy) Normalize() NotifyPolicy {
+	switch p.NotifyMode {
+	case NotifyModeOneShot, NotifyModeOnDrop, NotifyModeCooldown:
+	default:
+		p.NotifyMode = NotifyModeOnDrop
+	}
+	if p.CooldownHours <= 0 {
+		p.CooldownHours = DefaultCooldownHours
+	}
+	return p
+}
+
+// ShouldNotify indica si la alerta debe notificarse con el precio dado, según su modo y el estado
+// del último disparo. No evalúa la regla de la alerta: eso lo hace el motor de alertas
+func (a *PriceAlert) ShouldNotify(price float64, now time.Time) bool {
+	if !a.IsActive {
+		return false
+	}
+	if a.RuleType == AlertRuleBackInStock && a.LastAvailable {
+		// Ya se vio disponible: solo se avisa cuando vuelve a estarlo tras agotarse
+		return false
+	}
+	if a.LastNotifiedAt == nil {
+		return true
+	}
+
+	switch a.NotifyPolicy.Normalize().NotifyMode {
+	case NotifyModeOneShot:
+		return false
+	case NotifyModeCooldown:
+		cooldown := time.Duration(a.NotifyPolicy.Normalize().CooldownHours) * time.Hour
+		return now.Sub(*a.LastNotifiedAt) >= cooldown
+	default:
+		// Cada reposición es un aviso nuevo, aunque el precio no haya bajado
+		return a.RuleType == AlertRuleBackInStock || price < a.LastNotifiedPrice
+	}
+}
+
+// RecordTrigger guarda el estado del disparo de la alerta. En modo one_shot la alerta se desactiva
+func (a *PriceAlert) RecordTrigger(price float64, now time.Time) {
+	a.LastNotifiedPrice = price
+	a.LastNotifiedAt = &now
+	if a.RuleType == AlertRuleBackInStock {
+		a.LastAvailable = true
+	}
+	if a.NotifyPolicy.Normalize().NotifyMode == NotifyModeOneShot {
+		a.IsActive = false
+	}
+}
+
+// Rearm olvida el último disparo de una alerta en modo on_drop cuando su regla deja de cumplirse
+// (el precio ha vuelto a subir), para que la próxima bajada avise aunque no sea inferior al último
+// precio notificado. Devuelve false si no había ningún disparo que olvidar
+func (a *PriceAlert) Rearm() bool {
+	if a.LastNotifiedAt == nil || a.NotifyPolicy.Normalize().NotifyMode != NotifyModeOnDrop {
+		return false
+	}
+	a.ResetTrigger()
+	return true
+}
+
+// ResetTrigger olvida el último disparo para que la alerta vuelva a notificar desde cero. Se usa
+// al editar el objetivo o la política de la alerta
+func (a *PriceAlert) ResetTrigger() {
+	a.LastNotifiedPrice = 0
+	a.LastNotifiedAt = nil
+}
+
+// AlertTrigger registra cada vez que una alerta de precio se ha disparado: cuándo, con qué precio
+// y en qué tienda
+type AlertTrigger struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	AlertID     uint      `gorm:"not null;index:idx_trigger_alert" json:"alert_id"`
+	ProductID   uint      `gorm:"not null" json:"product_id"`
+	Price       float64   `gorm:"not null" json:"price"` // Precio comparable que disparó la alerta
+	Store       string    `gorm:"size:100" json:"store"`
+	URL         string    `gorm:"size:512" json:"url"`
+	TriggeredAt time.Time `gorm:"not null;index:idx_trigger_alert" json:"triggered_at"`
+
+	// Relaciones
+	PriceAlert PriceAlert `gorm:"foreignKey:AlertID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
 // Notification representa una notificación enviada a un usuario sobre un cambio de precio
 type Notification struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
 	UserID    uint      `gorm:"not null;index:idx_notification_user" json:"user_id"`
 	ProductID uint      `gorm:"not null;index:idx_notification_product" json:"product_id"`
 	AlertID   *uint     `gorm:"index:idx_notification_alert" json:"alert_id"`
+	SearchID  *uint     `gorm:"index:idx_notification_search" json:"search_id"` // Búsqueda guardada que originó el aviso
 	Title     string    `gorm:"size:255;not null" json:"title"`
 	Message   string    `gorm:"size:1000;not null" json:"message"`
 	IsRead    bool      `gorm:"default:false;index:idx_notification_read" json:"is_read"`
 	CreatedAt time.Time `json:"created_at"`
 
+	// Pendiente de enviarse en el próximo resumen por correo (usuarios con resumen diario o semanal)
+	DigestPending bool `gorm:"default:false;index:idx_notification_digest" json:"-"`
+
 	// Relaciones
-	User       User        `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
-	Product    Product     `gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
-	PriceAlert *PriceAlert `gorm:"foreignKey:AlertID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
+	User        User         `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Product     Product      `gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	PriceAlert  *PriceAlert  `gorm:"foreignKey:AlertID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
+	SavedSearch *SavedSearch `gorm:"foreignKey:SearchID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
 }
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// Canales externos por los que se envían las notificaciones, además de la propia aplicación
+const (
+	ChannelEmail   = "email"   // Correo electrónico a la dirección del usuario
+	ChannelWebhook = "webhook" // Petición HTTP POST firmada a una URL del usuario
+)
+
+// NotificationChannel guarda la preferencia de un usuario para un canal de notificación. Los
+// usuarios sin preferencia guardada reciben los avisos por los canales activos por defecto. El
+// correo no se guarda aquí: se controla con User.EmailNotifications
+type NotificationChannel struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	UserID        uint      `gorm:"not null;uniqueIndex:idx_channel_user" json:"user_id"`
+	Channel       string    `gorm:"size:20;not null;uniqueIndex:idx_channel_user" json:"channel"`
+	Enabled       bool      `gorm:"not null" json:"enabled"`
+	WebhookURL    string    `gorm:"size:512" json:"webhook_url"` // Solo en el canal webhook
+	WebhookSecret string    `gorm:"size:128" json:"-"`           // Clave con la que se firman los envíos al webhook
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	// Relaciones
+	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// ChannelEnabledByDefault indica si un canal está activo para los usuarios que no lo han
+// configurado. Solo el correo, que es como se avisaba antes de existir los canales
+func ChannelEnabledByDefault(channel string) bool {
+	return channel == ChannelEmail
+}
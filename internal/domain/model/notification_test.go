@@ -0,0 +1,83 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPriceAlertShouldNotify(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+
+	tests := []struct {
+		name         string
+		mode         string
+		cooldown     int
+		rule         string
+		inactive     bool
+		lastAvail    bool
+		lastPrice    float64
+		lastNotified *time.Time
+		rearm        bool // Se llama a Rearm antes de evaluar, como cuando el precio vuelve a subir
+		price        float64
+		want         bool
+	}{
+		{name: "primer aviso", mode: NotifyModeOnDrop, price: 90, want: true},
+		{name: "alerta desactivada", mode: NotifyModeOnDrop, inactive: true, price: 90, want: false},
+		{name: "one_shot sin disparar", mode: NotifyModeOneShot, price: 90, want: true},
+		{name: "one_shot ya disparada", mode: NotifyModeOneShot, lastPrice: 90, lastNotified: ago(time.Hour), price: 80, want: false},
+		{name: "on_drop con precio más bajo", mode: NotifyModeOnDrop, lastPrice: 90, lastNotified: ago(time.Hour), price: 89.99, want: true},
+		{name: "on_drop con el mismo precio", mode: NotifyModeOnDrop, lastPrice: 90, lastNotified: ago(time.Hour), price: 90, want: false},
+		{name: "on_drop con precio más alto", mode: NotifyModeOnDrop, lastPrice: 90, lastNotified: ago(time.Hour), price: 95, want: false},
+		{name: "modo desconocido se trata como on_drop", mode: "weekly", lastPrice: 90, lastNotified: ago(time.Hour), price: 95, want: false},
+		{name: "on_drop rearmada tras una subida", mode: NotifyModeOnDrop, lastPrice: 90, lastNotified: ago(time.Hour), rearm: true, price: 95, want: true},
+		{name: "cooldown no se rearma tras una subida", mode: NotifyModeCooldown, cooldown: 24, lastPrice: 90, lastNotified: ago(time.Hour), rearm: true, price: 95, want: false},
+		{name: "cooldown antes del límite", mode: NotifyModeCooldown, cooldown: 24, lastPrice: 90, lastNotified: ago(24*time.Hour - time.Second), price: 80, want: false},
+		{name: "cooldown justo en el límite", mode: NotifyModeCooldown, cooldown: 24, lastPrice: 90, lastNotified: ago(24 * time.Hour), price: 95, want: true},
+		{name: "cooldown sin periodo usa el de por defecto", mode: NotifyModeCooldown, lastPrice: 90, lastNotified: ago(DefaultCooldownHours*time.Hour - time.Second), price: 80, want: false},
+		{name: "cooldown de 2 horas", mode: NotifyModeCooldown, cooldown: 2, lastPrice: 90, lastNotified: ago(2 * time.Hour), price: 95, want: true},
+		{name: "reposición ya vista", mode: NotifyModeOnDrop, rule: AlertRuleBackInStock, lastAvail: true, price: 90, want: false},
+		{name: "nueva reposición sin bajada", mode: NotifyModeOnDrop, rule: AlertRuleBackInStock, lastPrice: 90, lastNotified: ago(time.Hour), price: 95, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := &PriceAlert{
+				IsActive:          !tt.inactive,
+				AlertRule:         AlertRule{RuleType: tt.rule, LastAvailable: tt.lastAvail},
+				NotifyPolicy:      NotifyPolicy{NotifyMode: tt.mode, CooldownHours: tt.cooldown},
+				LastNotifiedPrice: tt.lastPrice,
+				LastNotifiedAt:    tt.lastNotified,
+			}
+			if tt.rearm {
+				alert.Rearm()
+			}
+			if got := alert.ShouldNotify(tt.price, now); got != tt.want {
+				t.Errorf("ShouldNotify(%.2f) = %t, se esperaba %t", tt.price, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriceAlertRecordTrigger(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	oneShot := &PriceAlert{IsActive: true, NotifyPolicy: NotifyPolicy{NotifyMode: NotifyModeOneShot}}
+	oneShot.RecordTrigger(90, now)
+	if oneShot.IsActive {
+		t.Error("una alerta one_shot debe desactivarse al dispararse")
+	}
+
+	onDrop := &PriceAlert{IsActive: true, NotifyPolicy: NotifyPolicy{NotifyMode: NotifyModeOnDrop}}
+	onDrop.RecordTrigger(90, now)
+	if !onDrop.IsActive || onDrop.LastNotifiedPrice != 90 || onDrop.LastNotifiedAt == nil || !onDrop.LastNotifiedAt.Equal(now) {
+		t.Errorf("estado tras el disparo = (%t, %.2f, %v), se esperaba (true, 90.00, %v)",
+			onDrop.IsActive, onDrop.LastNotifiedPrice, onDrop.LastNotifiedAt, now)
+	}
+	if onDrop.ShouldNotify(90, now) {
+		t.Error("on_drop no debe repetir el aviso con el mismo precio")
+	}
+}
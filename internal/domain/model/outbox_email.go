@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+// Estados de un correo de la bandeja de salida
+const (
+	OutboxPending = "pending" // Pendiente de enviar o de reintentar
+	OutboxSent    = "sent"    // Enviado
+	OutboxFailed  = "failed"  // Descartado tras agotar los reintentos (solo se reenvía a mano)
+)
+
+// OutboxEmail es un correo de la bandeja de salida. Todos los correos se guardan aquí antes de
+// enviarse, de modo que una caída del servidor SMTP no los pierde: el worker de la bandeja los
+// reintenta con espera exponencial hasta agotar los intentos
+type OutboxEmail struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Recipient     string     `gorm:"size:255;not null" json:"recipient"`
+	Subject       string     `gorm:"size:255;not null" json:"subject"`
+	MessageID     string     `gorm:"size:255" json:"message_id"`        // Cabecera Message-ID, la misma en todos los intentos
+	Body          string     `gorm:"type:mediumtext;not null" json:"-"` // Parte HTML
+	TextBody      string     `gorm:"type:mediumtext" json:"-"`          // Parte de texto plano
+	Unsubscribe   string     `gorm:"size:512" json:"-"`                 // Enlace para darse de baja (cabecera List-Unsubscribe); vacío en los correos que no admiten baja
+	Status        string     `gorm:"size:20;not null;index:idx_outbox_due" json:"status"`
+	Attempts      int        `gorm:"not null" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_outbox_due" json:"next_attempt_at"`
+	LastError     string     `gorm:"type:text" json:"last_error"`
+	SentAt        *time.Time `json:"sent_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// RecordFailure anota un intento fallido y programa el siguiente con espera exponencial
+// (retryDelay * 2^(intentos-1), como mucho maxDelay). Al llegar a maxAttempts el correo se descarta
+func (e *OutboxEmail) RecordFailure(err error, now time.Time, maxAttempts int, retryDelay, maxDelay time.Duration) {
+	e.Attempts++
+	e.LastError = err.Error()
+	if e.Attempts >= maxAttempts {
+		e.Status = OutboxFailed
+		return
+	}
+
+	delay := retryDelay << (e.Attempts - 1)
+	if delay <= 0 || delay > maxDelay {
+		delay = maxDelay
+	}
+	e.Status = OutboxPending
+	e.NextAttemptAt = now.Add(delay)
+}
+
+// RecordSent marca el correo como enviado
+func (e *OutboxEmail) RecordSent(now time.Time) {
+	e.Attempts++
+	e.Status = OutboxSent
+	e.LastError = ""
+	e.SentAt = &now
+}
@@ -8,16 +8,116 @@ import (
 
 // Price representa una oferta de precio para un producto específico
 type Price struct {
-	ID          uint      `gorm:"primaryKey"`
-	ProductID   uint      `gorm:"index;not null"`
-	Product     Product   `gorm:"foreignKey:ProductID"`
-	Store       string    `gorm:"not null;size:50"` // Tienda: PCComponentes, MercadoLibre, eBay
-	Price       float64   `gorm:"not null"`
-	Currency    string    `gorm:"size:3;default:'EUR'"` // EUR, USD, MXN, etc.
-	URL         string    `gorm:"not null;size:1024"`   // URL para comprar el producto
-	IsAvailable bool      `gorm:"default:true"`
-	RetrievedAt time.Time `gorm:"not null"` // Cuándo se obtuvo este precio
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	ID              uint      `gorm:"primaryKey"`
+	ProductID       uint      `gorm:"index;not null"`
+	Product         Product   `gorm:"foreignKey:ProductID"`
+	Store           string    `gorm:"not null;size:50"` // Tienda: PCComponentes, MercadoLibre, eBay
+	Price           float64   `gorm:"not null"`
+	Currency        string    `gorm:"size:3;default:'EUR'"` // EUR, USD, MXN, etc.
+	NormalizedPrice float64   `gorm:"index;default:0"`      // Precio en la moneda de visualización
+	ShippingCost    *float64  // Gastos de envío en la moneda del precio (nil si la tienda no los muestra, 0 si es gratis)
+	ImportCharges   *float64  // Cargos de importación o impuestos estimados por la tienda, en la moneda del precio
+	TaxNote         string    `gorm:"size:100"`        // Indicación de impuestos tal como la muestra la tienda (ej: "IVA incluido")
+	SellerLocation  string    `gorm:"size:100"`        // Desde dónde se envía el producto
+	NormalizedTotal float64   `gorm:"index;default:0"` // Coste total (precio + envío + importación) en la moneda de visualización
+	Condition       string    `gorm:"size:20;index"`   // Estado del artículo: new, used, refurbished, for_parts (vacío si la tienda no lo indica)
+	ListingType     string    `gorm:"size:20;index"`   // Tipo de anuncio: auction o buy_it_now (vacío si no es un marketplace)
+	SellerName      string    `gorm:"size:100"`        // Vendedor en los marketplaces
+	SellerFeedback  *int      // Número de valoraciones del vendedor (nil si no se conoce)
+	URL             string    `gorm:"not null;size:1024"` // URL para comprar el producto
+	IsAvailable     bool      `gorm:"default:true"`
+	RetrievedAt     time.Time `gorm:"not null"` // Cuándo se obtuvo este precio
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       gorm.DeletedAt `gorm:"index"`
+}
+
+// Estados del artículo de una oferta
+const (
+	ConditionNew         = "new"
+	ConditionUsed        = "used"
+	ConditionRefurbished = "refurbished"
+	ConditionForParts    = "for_parts"
+)
+
+// Tipos de anuncio de una oferta en un marketplace
+const (
+	ListingTypeAuction  = "auction"
+	ListingTypeBuyItNow = "buy_it_now"
+)
+
+// OfferFilter restringe las ofertas que se tienen en cuenta al buscar el mejor precio de un
+// producto e indica con qué valor se comparan. Se usa en los listados, en las alertas de precio
+// y en las búsquedas guardadas
+type OfferFilter struct {
+	NewOnly         bool `gorm:"default:false" json:"new_only"`         // Solo artículos nuevos (o de tiendas que no indican el estado)
+	ExcludeAuctions bool `gorm:"default:false" json:"exclude_auctions"` // Descartar las subastas
+	LandedCost      bool `gorm:"default:false" json:"landed_cost"`      // Comparar por coste total (precio, envío e importación)
+}
+
+// IsEmpty indica si el filtro no restringe ninguna oferta (LandedCost no descarta ofertas, solo
+// cambia cómo se comparan)
+func (f OfferFilter) IsEmpty() bool {
+	return !f.NewOnly && !f.ExcludeAuctions
+}
+
+// Matches indica si una oferta pasa el filtro. Las ofertas sin estado se consideran nuevas,
+// porque las tiendas que no lo indican solo venden artículos nuevos
+func (f OfferFilter) Matches(p *Price) bool {
+	if f.NewOnly && p.Condition != "" && p.Condition != ConditionNew {
+		return false
+	}
+	if f.ExcludeAuctions && p.ListingType == ListingTypeAuction {
+		return false
+	}
+	return true
+}
+
+// ComparisonColumn devuelve la columna de la tabla prices con la que se comparan los precios
+// según el filtro
+func (f OfferFilter) ComparisonColumn() string {
+	if f.LandedCost {
+		return "normalized_total"
+	}
+	return "normalized_price"
+}
+
+// PriceOf devuelve el valor con el que se compara la oferta según el filtro, en la moneda de
+// comparación: el coste total si LandedCost está activo o el precio del producto si no
+func (f OfferFilter) PriceOf(p *Price) float64 {
+	if f.LandedCost {
+		return p.ComparableTotal()
+	}
+	return p.ComparablePrice()
+}
+
+// LandedCost devuelve el coste total de la oferta en la moneda del precio: el precio más los
+// gastos de envío y de importación que conozcamos
+func (p *Price) LandedCost() float64 {
+	total := p.Price
+	if p.ShippingCost != nil {
+		total += *p.ShippingCost
+	}
+	if p.ImportCharges != nil {
+		total += *p.ImportCharges
+	}
+	return total
+}
+
+// ComparablePrice devuelve el precio del producto en la moneda de comparación. Los precios que
+// aún no se han normalizado se comparan por su valor original
+func (p *Price) ComparablePrice() float64 {
+	if p.NormalizedPrice > 0 {
+		return p.NormalizedPrice
+	}
+	return p.Price
+}
+
+// ComparableTotal devuelve el coste total de la oferta en la moneda de comparación, o su valor
+// original si aún no se ha normalizado
+func (p *Price) ComparableTotal() float64 {
+	if p.NormalizedTotal > 0 {
+		return p.NormalizedTotal
+	}
+	return p.LandedCost()
 }
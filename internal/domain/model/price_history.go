@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+)
+
+// PriceObservation representa una lectura histórica del precio de un producto en una tienda.
+// A diferencia de Price, que guarda la oferta vigente, estas filas nunca se sobrescriben:
+// se añade una nueva cada vez que un scraper obtiene el precio
+type PriceObservation struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	ProductID       uint      `gorm:"not null;index:idx_observation_product_store,priority:1" json:"product_id"`
+	Store           string    `gorm:"not null;size:50;index:idx_observation_product_store,priority:2" json:"store"`
+	Price           float64   `gorm:"not null" json:"price"`
+	Currency        string    `gorm:"size:3;default:'EUR'" json:"currency"`
+	NormalizedPrice float64   `json:"normalized_price"` // Precio en la moneda de visualización en el momento de la lectura
+	IsAvailable     bool      `gorm:"default:true" json:"is_available"`
+	ObservedAt      time.Time `gorm:"not null;index:idx_observation_product_store,priority:3" json:"observed_at"` // Cuándo se obtuvo el precio
+	CreatedAt       time.Time `json:"created_at"`
+
+	// Relaciones
+	Product Product `gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// PriceStats resume las observaciones disponibles del histórico de un producto en un periodo
+type PriceStats struct {
+	Lowest  float64 // Precio normalizado más bajo
+	Average float64 // Media de los precios normalizados
+	Count   int64   // Número de observaciones
+}
+
+// NewPriceObservation crea una observación histórica a partir de la oferta scrapeada
+func NewPriceObservation(price Price) *PriceObservation {
+	observedAt := price.RetrievedAt
+	if observedAt.IsZero() {
+		observedAt = time.Now()
+	}
+
+	return &PriceObservation{
+		ProductID:       price.ProductID,
+		Store:           price.Store,
+		Price:           price.Price,
+		Currency:        price.Currency,
+		NormalizedPrice: price.NormalizedPrice,
+		IsAvailable:     price.IsAvailable,
+		ObservedAt:      observedAt,
+	}
+}
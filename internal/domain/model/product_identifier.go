@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+)
+
+// Tipos de identificador de producto
+const (
+	IdentifierGTIN = "gtin" // EAN/UPC/GTIN, normalizado a 14 dígitos
+	IdentifierMPN  = "mpn"  // Referencia del fabricante (part number), en mayúsculas y sin separadores
+)
+
+// ProductIdentifier es un código que identifica un producto de forma exacta (GTIN o MPN). Es la
+// regla más fuerte para reconocer el mismo producto en tiendas distintas: cada código pertenece
+// a un único producto del catálogo
+type ProductIdentifier struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ProductID uint      `gorm:"index;not null" json:"product_id"`
+	Type      string    `gorm:"size:10;not null;uniqueIndex:idx_product_identifier,priority:1" json:"type"`
+	Value     string    `gorm:"size:64;not null;uniqueIndex:idx_product_identifier,priority:2" json:"value"`
+	Source    string    `gorm:"size:50" json:"source"` // Tienda de la que se obtuvo
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,131 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SavedSearch es una búsqueda guardada por un usuario ("SSD NVMe 2TB por menos de 100 €") con los
+// mismos criterios que el listado de una categoría. El usuario recibe un aviso la primera vez que
+// un producto nuevo o que cambia de precio cumple la búsqueda
+type SavedSearch struct {
+	ID            uint              `gorm:"primaryKey" json:"id"`
+	UserID        uint              `gorm:"not null;index:idx_saved_search_user" json:"user_id"`
+	Name          string            `gorm:"size:100;not null" json:"name"`
+	CategoryID    *uint             `gorm:"index:idx_saved_search_category" json:"category_id"` // nil en cualquier categoría
+	Keywords      string            `gorm:"size:200" json:"keywords"`                           // Palabras que deben aparecer en el nombre del producto
+	Store         string            `gorm:"size:50" json:"store"`                               // Vacío en cualquier tienda
+	MinPrice      float64           `gorm:"default:0" json:"min_price"`                         // 0 sin mínimo
+	MaxPrice      float64           `gorm:"default:0" json:"max_price"`                         // 0 sin máximo
+	OfferFilter   `gorm:"embedded"` // Ofertas que cuentan para la búsqueda (solo nuevos, sin subastas)
+	NotifyByEmail bool              `gorm:"default:true" json:"notify_by_email"`
+	IsActive      bool              `gorm:"default:true" json:"is_active"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+
+	// Relaciones
+	User     User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Category *Category `gorm:"foreignKey:CategoryID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"category,omitempty"`
+}
+
+// Normalize limpia los textos de la búsqueda y le pone un nombre si no lo tiene. format da formato a
+// los importes del nombre en la moneda del usuario
+func (s *SavedSearch) Normalize(format PriceFormatter) {
+	s.Keywords = strings.Join(strings.Fields(s.Keywords), " ")
+	s.Store = strings.TrimSpace(s.Store)
+	s.Name = strings.TrimSpace(s.Name)
+	if s.Name == "" {
+		s.Name = s.Describe(format)
+	}
+	// Se recorta por caracteres y no por bytes para no partir un carácter UTF-8
+	if name := []rune(s.Name); len(name) > 100 {
+		s.Name = strings.TrimSpace(string(name[:97])) + "..."
+	}
+}
+
+// Validate comprueba que la búsqueda restringe algo y que el rango de precios es coherente
+func (s *SavedSearch) Validate() error {
+	if s.MinPrice < 0 || s.MaxPrice < 0 {
+		return fmt.Errorf("los precios no pueden ser negativos")
+	}
+	if s.MaxPrice > 0 && s.MinPrice > s.MaxPrice {
+		return fmt.Errorf("el precio mínimo no puede ser mayor que el máximo")
+	}
+	if s.CategoryID == nil && s.Keywords == "" && s.Store == "" {
+		return fmt.Errorf("indica al menos una categoría, unas palabras clave o una tienda")
+	}
+	return nil
+}
+
+// MatchesOffer indica si una oferta cuenta para la búsqueda: disponible, de la tienda de la
+// búsqueda (si tiene) y que pasa su filtro de ofertas
+func (s *SavedSearch) MatchesOffer(p *Price) bool {
+	if !p.IsAvailable {
+		return false
+	}
+	if s.Store != "" && !strings.EqualFold(p.Store, s.Store) {
+		return false
+	}
+	return s.OfferFilter.Matches(p)
+}
+
+// InPriceRange indica si un precio está dentro del rango de la búsqueda
+func (s *SavedSearch) InPriceRange(price float64) bool {
+	if s.MinPrice > 0 && price < s.MinPrice {
+		return false
+	}
+	if s.MaxPrice > 0 && price > s.MaxPrice {
+		return false
+	}
+	return true
+}
+
+// Describe resume los criterios de la búsqueda para mostrarlos al usuario, con los importes en su moneda
+func (s *SavedSearch) Describe(format PriceFormatter) string {
+	var parts []string
+	if s.Keywords != "" {
+		parts = append(parts, fmt.Sprintf("«%s»", s.Keywords))
+	}
+	if s.Category != nil && s.Category.Name != "" {
+		parts = append(parts, "en "+s.Category.Name)
+	}
+	if s.Store != "" {
+		parts = append(parts, "de "+s.Store)
+	}
+	switch {
+	case s.MinPrice > 0 && s.MaxPrice > 0:
+		parts = append(parts, fmt.Sprintf("entre %s y %s", format(s.MinPrice), format(s.MaxPrice)))
+	case s.MaxPrice > 0:
+		parts = append(parts, "hasta "+format(s.MaxPrice))
+	case s.MinPrice > 0:
+		parts = append(parts, "desde "+format(s.MinPrice))
+	}
+	if s.NewOnly {
+		parts = append(parts, "solo nuevos")
+	}
+	if s.ExcludeAuctions {
+		parts = append(parts, "sin subastas")
+	}
+	if len(parts) == 0 {
+		return "Cualquier producto"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// SavedSearchMatch registra que un producto ha cumplido una búsqueda guardada. Solo hay una fila por
+// búsqueda y producto, de modo que cada producto se avisa una sola vez
+type SavedSearchMatch struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	SearchID  uint      `gorm:"not null;uniqueIndex:idx_search_match_product" json:"search_id"`
+	ProductID uint      `gorm:"not null;uniqueIndex:idx_search_match_product" json:"product_id"`
+	Price     float64   `gorm:"not null" json:"price"` // Precio comparable con el que cumplió la búsqueda
+	Store     string    `gorm:"size:100" json:"store"`
+	URL       string    `gorm:"size:512" json:"url"`
+	Notified  bool      `gorm:"default:false" json:"notified"` // false en los productos que ya cumplían la búsqueda al crearla
+	MatchedAt time.Time `gorm:"not null" json:"matched_at"`
+
+	// Relaciones
+	SavedSearch SavedSearch `gorm:"foreignKey:SearchID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Product     Product     `gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
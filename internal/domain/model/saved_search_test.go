@@ -0,0 +1,30 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSavedSearchNormalizeTruncatesName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"  Portátiles  ", "Portátiles"},
+		{strings.Repeat("ñ", 100), strings.Repeat("ñ", 100)},
+		{strings.Repeat("ñ", 101), strings.Repeat("ñ", 97) + "..."},
+		{strings.Repeat("a", 96) + "€€€€€", strings.Repeat("a", 96) + "€..."},
+	}
+
+	for _, tt := range tests {
+		search := &SavedSearch{Name: tt.name}
+		search.Normalize(nil)
+		if search.Name != tt.want {
+			t.Errorf("Normalize(%q).Name = %q, se esperaba %q", tt.name, search.Name, tt.want)
+		}
+		if !utf8.ValidString(search.Name) {
+			t.Errorf("Normalize(%q).Name no es UTF-8 válido", tt.name)
+		}
+	}
+}
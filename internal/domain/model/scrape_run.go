@@ -0,0 +1,77 @@
+package model
+
+import (
+	"time"
+)
+
+// Estados de la salud de una tienda
+const (
+	StoreHealthOK      = "ok"      // La última ejecución devolvió productos con normalidad
+	StoreHealthWarning = "warning" // Alguna categoría ha pasado de tener productos a no tener ninguno
+	StoreHealthError   = "error"   // Ninguna categoría devolvió productos (probable cambio de selectores)
+	StoreHealthUnknown = "unknown" // No hay ejecuciones recientes
+)
+
+// ScrapeRun registra una ejecución del scraper de una tienda para una categoría
+type ScrapeRun struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	Store             string     `gorm:"not null;size:50;index:idx_scrape_run_store_started,priority:1" json:"store"`
+	CategoryID        uint       `gorm:"index" json:"category_id"`
+	CategoryName      string     `gorm:"size:100" json:"category_name"`
+	StartedAt         time.Time  `gorm:"not null;index:idx_scrape_run_store_started,priority:2" json:"started_at"`
+	FinishedAt        *time.Time `json:"finished_at"`
+	HTTPErrors        int        `gorm:"default:0" json:"http_errors"`        // Peticiones fallidas (errores de red o respuestas 4xx/5xx)
+	ItemsFound        int        `gorm:"default:0" json:"items_found"`        // Productos devueltos por el scraper
+	ItemsSaved        int        `gorm:"default:0" json:"items_saved"`        // Productos guardados o actualizados
+	ItemsReclassified int        `gorm:"default:0" json:"items_reclassified"` // Productos movidos a otra categoría
+	ItemsDiscarded    int        `gorm:"default:0" json:"items_discarded"`    // Productos descartados por no encajar en ninguna categoría
+	Error             string     `gorm:"size:500" json:"error,omitempty"`     // Error que interrumpió el scraping, si lo hubo
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// NewScrapeRun crea el registro de una ejecución que empieza ahora
+func NewScrapeRun(store string, category *Category) *ScrapeRun {
+	return &ScrapeRun{
+		Store:        store,
+		CategoryID:   category.ID,
+		CategoryName: category.Name,
+		StartedAt:    time.Now(),
+	}
+}
+
+// Finish marca la ejecución como terminada, guardando el error si lo hubo
+func (r *ScrapeRun) Finish(err error) {
+	now := time.Now()
+	r.FinishedAt = &now
+	if err != nil {
+		// Se recorta por caracteres y no por bytes para no partir un carácter UTF-8
+		if message := []rune(err.Error()); len(message) > 500 {
+			r.Error = string(message[:500])
+		} else {
+			r.Error = string(message)
+		}
+	}
+}
+
+// Duration devuelve cuánto tardó la ejecución (0 si no ha terminado)
+func (r *ScrapeRun) Duration() time.Duration {
+	if r.FinishedAt == nil {
+		return 0
+	}
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// StoreHealth resume el estado reciente del scraping de una tienda. No se persiste:
+// se calcula a partir de las últimas ScrapeRun
+type StoreHealth struct {
+	Store             string       `json:"store"`
+	Status            string       `json:"status"`
+	LastRunAt         *time.Time   `json:"last_run_at"`
+	Runs              int          `json:"runs"`                // Ejecuciones en el periodo analizado
+	LastItemsFound    int          `json:"last_items_found"`    // Productos en la última ejecución de cada categoría
+	AverageItemsFound float64      `json:"average_items_found"` // Media de productos por ejecución en el periodo
+	LastHTTPErrors    int          `json:"last_http_errors"`    // Errores HTTP en la última ejecución de cada categoría
+	ZeroCategories    []string     `json:"zero_categories"`     // Categorías que han pasado de tener productos a ninguno
+	LatestRuns        []*ScrapeRun `json:"latest_runs"`         // Última ejecución de cada categoría
+}
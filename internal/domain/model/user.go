@@ -8,15 +8,87 @@ import (
 
 // User representa el modelo de usuario para autenticación y gestión de sesiones
 type User struct {
-	ID                 uint   `gorm:"primaryKey"`
-	Username           string `gorm:"uniqueIndex;not null;size:100"`
-	Email              string `gorm:"uniqueIndex;not null;size:100"`
-	PasswordHash       string `gorm:"column:password_hash;not null;type:varchar(255)"`
-	Verified           bool   `gorm:"default:false"`
-	VerifyToken        string `gorm:"size:100"`
-	EmailNotifications bool   `gorm:"default:true"`
-	IsAdmin            bool   `gorm:"default:false"`
+	ID                 uint       `gorm:"primaryKey"`
+	Username           string     `gorm:"uniqueIndex;not null;size:100"`
+	Email              string     `gorm:"uniqueIndex;not null;size:100"`
+	PasswordHash       string     `gorm:"column:password_hash;not null;type:varchar(255)"`
+	Verified           bool       `gorm:"default:false"`
+	VerifyToken        string     `gorm:"size:100"`
+	EmailNotifications bool       `gorm:"default:true"`                // Acepta los correos de alertas y búsquedas guardadas (EmailCategoryAlerts)
+	EmailDigest        bool       `gorm:"default:true"`                // Acepta los resúmenes de avisos (EmailCategoryDigest)
+	EmailAccount       bool       `gorm:"default:true"`                // Acepta los avisos de cuenta y seguridad (EmailCategoryAccount)
+	DigestFrequency    string     `gorm:"size:20;default:'immediate'"` // Cuándo se envían por correo los avisos (DigestImmediate, DigestDaily o DigestWeekly)
+	LastDigestAt       *time.Time // Último resumen enviado
+	Currency           string     `gorm:"size:3"` // Moneda en la que ve los precios (código ISO); vacía usa currency.display
+	CompareLandedCost  *bool      // Compara los precios por coste total (envío e importación incluidos); nil usa currency.compare_landed_cost
+	IsAdmin            bool       `gorm:"default:false"`
 	CreatedAt          time.Time
 	UpdatedAt          time.Time
 	DeletedAt          gorm.DeletedAt `gorm:"index"`
 }
+
+// Frecuencias de envío por correo de los avisos de un usuario
+const (
+	DigestImmediate = "immediate" // Un correo por aviso, en el momento
+	DigestDaily     = "daily"     // Un resumen al día
+	DigestWeekly    = "weekly"    // Un resumen a la semana
+)
+
+// IsValidDigestFrequency indica si la frecuencia de envío es una de las admitidas
+func IsValidDigestFrequency(frequency string) bool {
+	switch frequency {
+	case DigestImmediate, DigestDaily, DigestWeekly:
+		return true
+	}
+	return false
+}
+
+// WantsDigest indica si el usuario recibe sus avisos por correo agrupados en un resumen
+func (u *User) WantsDigest() bool {
+	return u.DigestFrequency == DigestDaily || u.DigestFrequency == DigestWeekly
+}
+
+// Tipos de correo que el usuario puede dejar de recibir. Los correos que pide el propio usuario
+// (verificación de la cuenta y restablecimiento de contraseña) se envían siempre
+const (
+	EmailCategoryAlerts  = "alerts"  // Alertas de precio y búsquedas guardadas
+	EmailCategoryDigest  = "digest"  // Resúmenes diarios o semanales
+	EmailCategoryAccount = "account" // Avisos de cuenta y seguridad
+)
+
+// IsValidEmailCategory indica si el tipo de correo es uno de los admitidos
+func IsValidEmailCategory(category string) bool {
+	switch category {
+	case EmailCategoryAlerts, EmailCategoryDigest, EmailCategoryAccount:
+		return true
+	}
+	return false
+}
+
+// AcceptsEmail indica si el usuario acepta recibir correos del tipo indicado
+func (u *User) AcceptsEmail(category string) bool {
+	switch category {
+	case EmailCategoryAlerts:
+		return u.EmailNotifications
+	case EmailCategoryDigest:
+		return u.EmailDigest
+	case EmailCategoryAccount:
+		return u.EmailAccount
+	}
+	return false
+}
+
+// SetEmailCategory activa o desactiva un tipo de correo. Devuelve false si el tipo no existe
+func (u *User) SetEmailCategory(category string, enabled bool) bool {
+	switch category {
+	case EmailCategoryAlerts:
+		u.EmailNotifications = enabled
+	case EmailCategoryDigest:
+		u.EmailDigest = enabled
+	case EmailCategoryAccount:
+		u.EmailAccount = enabled
+	default:
+		return false
+	}
+	return true
+}
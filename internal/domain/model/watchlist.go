@@ -11,6 +11,7 @@ type WatchlistItem struct {
 	ProductID   uint      `gorm:"not null;uniqueIndex:idx_watchlist_item_user_product" json:"product_id"`
 	TargetPrice float64   `gorm:"type:decimal(10,2)" json:"target_price"`
 	Notes       string    `gorm:"type:text" json:"notes"`
+	DigestPrice float64   `gorm:"default:0" json:"-"` // Mejor precio en el último resumen por correo (0 si aún no ha salido en ninguno)
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 
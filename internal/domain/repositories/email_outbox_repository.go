@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"app/internal/domain/model"
+)
+
+// EmailOutboxRepository define las operaciones para la bandeja de salida de correos
+type EmailOutboxRepository interface {
+	// Create guarda un correo pendiente de enviar
+	Create(ctx context.Context, email *model.OutboxEmail) error
+
+	// Update guarda el resultado de un intento de envío
+	Update(ctx context.Context, email *model.OutboxEmail) error
+
+	// FindByID busca un correo por su ID
+	FindByID(ctx context.Context, id uint) (*model.OutboxEmail, error)
+
+	// FindDue devuelve los correos pendientes cuyo siguiente intento ya ha llegado, los más antiguos primero
+	FindDue(ctx context.Context, now time.Time, limit int) ([]*model.OutboxEmail, error)
+
+	// FindByStatus devuelve los últimos correos con un estado, los más recientes primero
+	FindByStatus(ctx context.Context, status string, limit int) ([]*model.OutboxEmail, error)
+
+	// CountByStatus cuenta los correos de cada estado
+	CountByStatus(ctx context.Context) (map[string]int64, error)
+
+	// DeleteSentBefore elimina los correos enviados antes de la fecha y devuelve cuántos se han eliminado
+	DeleteSentBefore(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+
+	"app/internal/domain/model"
+)
+
+// ExchangeRateRepository define las operaciones de persistencia para los tipos de cambio
+type ExchangeRateRepository interface {
+	// Save crea el tipo de cambio de una moneda para una fecha o lo sustituye si ya existía
+	Save(ctx context.Context, rate *model.ExchangeRate) error
+
+	// FindLatest devuelve el tipo de cambio más reciente de cada moneda, sin los de fecha futura
+	FindLatest(ctx context.Context) ([]*model.ExchangeRate, error)
+
+	// FindByCurrency devuelve los últimos tipos de cambio de una moneda, del más reciente al más antiguo
+	FindByCurrency(ctx context.Context, currency string, limit int) ([]*model.ExchangeRate, error)
+}
@@ -21,7 +21,7 @@ type PriceAlertRepository interface {
 	// Buscar una alerta por su ID
 	FindByID(ctx context.Context, alertID uint) (*model.PriceAlert, error)
 
-	// Buscar alertas por ID de usuario
+	// Buscar las alertas de un usuario, incluidas las desactivadas (p. ej. las de un solo aviso ya disparadas)
 	FindByUserID(ctx context.Context, userID uint) ([]*model.PriceAlert, error)
 
 	// Buscar alertas por ID de producto
@@ -30,6 +30,21 @@ type PriceAlertRepository interface {
 	// Buscar alertas activas para un precio específico
 	// Devuelve alertas donde targetPrice >= nuevoPrice
 	FindActiveAlertsForPrice(ctx context.Context, productID uint, newPrice float64) ([]*model.PriceAlert, error)
+
+	// Guardar solo el estado de la regla (precio de referencia y disponibilidad vista por última vez)
+	UpdateRuleState(ctx context.Context, alertID uint, baselinePrice float64, lastAvailable bool) error
+
+	// Buscar los IDs de los productos que tienen al menos una alerta activa
+	FindActiveProductIDs(ctx context.Context, afterID uint, limit int) ([]uint, error)
+}
+
+// AlertTriggerRepository define las operaciones para el historial de disparos de las alertas
+type AlertTriggerRepository interface {
+	// Create registra un disparo de una alerta
+	Create(ctx context.Context, trigger *model.AlertTrigger) error
+
+	// FindByAlertID devuelve los últimos disparos de una alerta, del más reciente al más antiguo
+	FindByAlertID(ctx context.Context, alertID uint, limit int) ([]*model.AlertTrigger, error)
 }
 
 // NotificationRepository define las operaciones para gestionar notificaciones
@@ -69,4 +84,26 @@ type NotificationRepository interface {
 
 	// Eliminar notificaciones antiguas (más de cierto tiempo)
 	DeleteOldNotifications(ctx context.Context, olderThan time.Time) error
+
+	// FindDigestPendingByUserID busca las notificaciones de un usuario pendientes de su próximo
+	// resumen por correo, de la más antigua a la más reciente
+	FindDigestPendingByUserID(ctx context.Context, userID uint) ([]*model.Notification, error)
+
+	// ClearDigestPending marca las notificaciones indicadas como ya incluidas en un resumen
+	ClearDigestPending(ctx context.Context, ids []uint) error
+
+	// ClearDigestPendingByUserID descarta del resumen todas las notificaciones pendientes de un usuario
+	ClearDigestPendingByUserID(ctx context.Context, userID uint) error
+}
+
+// NotificationChannelRepository define las operaciones para las preferencias de canales de notificación
+type NotificationChannelRepository interface {
+	// FindByUserID devuelve las preferencias de canal guardadas por un usuario
+	FindByUserID(ctx context.Context, userID uint) ([]*model.NotificationChannel, error)
+
+	// FindByUserAndChannel devuelve la preferencia de un usuario para un canal, o nil si no la ha guardado
+	FindByUserAndChannel(ctx context.Context, userID uint, channel string) (*model.NotificationChannel, error)
+
+	// Save crea o actualiza la preferencia de un usuario para un canal
+	Save(ctx context.Context, channel *model.NotificationChannel) error
 }
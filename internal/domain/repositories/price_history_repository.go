@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"app/internal/domain/model"
+)
+
+// PriceHistoryRepository define las operaciones de persistencia para el histórico de precios
+type PriceHistoryRepository interface {
+	// Create registra una nueva observación de precio
+	Create(ctx context.Context, observation *model.PriceObservation) error
+
+	// FindByProductID busca las observaciones de un producto entre dos fechas (ambas incluidas),
+	// ordenadas de la más antigua a la más reciente
+	FindByProductID(ctx context.Context, productID uint, from, to time.Time) ([]*model.PriceObservation, error)
+
+	// FindByProductAndStore busca las observaciones de un producto en una tienda entre dos fechas
+	FindByProductAndStore(ctx context.Context, productID uint, store string, from, to time.Time) ([]*model.PriceObservation, error)
+
+	// FindPriceStats calcula el mínimo y la media de los precios normalizados de las ofertas disponibles
+	// de un producto observadas desde from (incluida) hasta to (excluida)
+	FindPriceStats(ctx context.Context, productID uint, from, to time.Time) (*model.PriceStats, error)
+
+	// FindLatestByProductAndStore devuelve la última observación de un producto en una tienda
+	// Devuelve nil si todavía no hay ninguna
+	FindLatestByProductAndStore(ctx context.Context, productID uint, store string) (*model.PriceObservation, error)
+}
@@ -18,11 +18,11 @@ type PriceRepository interface {
 	// FindByProductID busca precios por ID de producto
 	FindByProductID(ctx context.Context, productID uint) ([]*model.Price, error)
 
-	// FindBestPriceByProductID busca el mejor precio para un producto
-	FindBestPriceByProductID(ctx context.Context, productID uint) (*model.Price, error)
+	// FindBestPriceByProductID busca el mejor precio para un producto entre las ofertas que pasan el filtro
+	FindBestPriceByProductID(ctx context.Context, productID uint, filter model.OfferFilter) (*model.Price, error)
 
-	// FindTopOffersByProductID busca las mejores ofertas para un producto
-	FindTopOffersByProductID(ctx context.Context, productID uint, limit int) ([]*model.Price, error)
+	// FindTopOffersByProductID busca las mejores ofertas para un producto entre las que pasan el filtro
+	FindTopOffersByProductID(ctx context.Context, productID uint, limit int, filter model.OfferFilter) ([]*model.Price, error)
 
 	// Update actualiza un precio existente
 	Update(ctx context.Context, price *model.Price) error
@@ -33,4 +33,9 @@ type PriceRepository interface {
 	// DeleteOldPrices elimina precios más antiguos que una fecha dada
 	// Devuelve el número de precios eliminados y un error si hubo problemas
 	DeleteOldPrices(ctx context.Context, olderThan time.Time) (int, error)
+
+	// NormalizePrices recalcula el precio y el coste total normalizados de todos los precios
+	// multiplicando los importes originales por el factor de su moneda. Los precios en monedas sin factor se copian tal cual.
+	// Devuelve el número de precios actualizados
+	NormalizePrices(ctx context.Context, factors map[string]float64) (int, error)
 }
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+
+	"app/internal/domain/model"
+)
+
+// ProductIdentifierRepository define las operaciones de persistencia para los identificadores de producto
+type ProductIdentifierRepository interface {
+	// Save asocia los identificadores al producto. Los que ya pertenecen a otro producto se ignoran
+	Save(ctx context.Context, productID uint, identifiers []model.ProductIdentifier) error
+
+	// FindMatching devuelve los identificadores guardados que coinciden en tipo y valor con alguno de los dados
+	FindMatching(ctx context.Context, identifiers []model.ProductIdentifier) ([]*model.ProductIdentifier, error)
+
+	// FindByProductID devuelve los identificadores de un producto
+	FindByProductID(ctx context.Context, productID uint) ([]*model.ProductIdentifier, error)
+}
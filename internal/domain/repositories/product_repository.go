@@ -2,19 +2,23 @@ package repositories
 
 import (
 	"context"
+	"errors"
 
 	"app/internal/domain/model"
 )
 
+// ErrProductNotFound indica que no existe ningún producto con el ID o slug buscado
+var ErrProductNotFound = errors.New("producto no encontrado")
+
 // ProductRepository define las operaciones de persistencia para los productos
 type ProductRepository interface {
 	// Create crea un nuevo producto en la base de datos
 	Create(ctx context.Context, product *model.Product) error
 
-	// FindByID busca un producto por su ID
+	// FindByID busca un producto por su ID. Devuelve ErrProductNotFound si no existe
 	FindByID(ctx context.Context, id uint) (*model.Product, error)
 
-	// FindBySlug busca un producto por su slug
+	// FindBySlug busca un producto por su slug. Devuelve ErrProductNotFound si no existe
 	FindBySlug(ctx context.Context, slug string) (*model.Product, error)
 
 	// FindByCategory busca productos por categoría
@@ -23,8 +27,8 @@ type ProductRepository interface {
 	// FindFilteredProductsByCategory busca productos por categoría con filtros avanzados
 	FindFilteredProductsByCategory(ctx context.Context, options model.ProductFilterOptions) ([]*model.Product, error)
 
-	// FindBestDeals obtiene los productos con mejores ofertas (precio más bajo)
-	FindBestDeals(ctx context.Context, limit int) ([]*model.Product, error)
+	// FindBestDeals obtiene los productos con mejores ofertas (precio más bajo) entre las que pasan el filtro
+	FindBestDeals(ctx context.Context, limit int, filter model.OfferFilter) ([]*model.Product, error)
 
 	// Update actualiza un producto existente
 	Update(ctx context.Context, product *model.Product) error
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"context"
+
+	"app/internal/domain/model"
+)
+
+// SavedSearchRepository define las operaciones para las búsquedas guardadas y sus coincidencias
+type SavedSearchRepository interface {
+	// Create crea una búsqueda guardada
+	Create(ctx context.Context, search *model.SavedSearch) error
+
+	// Update actualiza una búsqueda guardada
+	Update(ctx context.Context, search *model.SavedSearch) error
+
+	// Delete elimina una búsqueda guardada y sus coincidencias
+	Delete(ctx context.Context, searchID uint) error
+
+	// FindByID busca una búsqueda guardada por su ID, con su categoría
+	FindByID(ctx context.Context, searchID uint) (*model.SavedSearch, error)
+
+	// FindByUserID devuelve las búsquedas guardadas de un usuario, activas o no, de la más reciente a la más antigua
+	FindByUserID(ctx context.Context, userID uint) ([]*model.SavedSearch, error)
+
+	// FindActiveForCategory devuelve las búsquedas activas de la categoría y las que no tienen categoría
+	FindActiveForCategory(ctx context.Context, categoryID uint) ([]*model.SavedSearch, error)
+
+	// FindCandidateProducts devuelve, ordenados por ID, los productos de la categoría (de cualquiera
+	// si categoryID es nil) posteriores a afterID, como máximo limit
+	FindCandidateProducts(ctx context.Context, categoryID *uint, afterID uint, limit int) ([]*model.Product, error)
+
+	// CreateMatch registra que un producto cumple una búsqueda. Devuelve false si ya estaba registrado
+	CreateMatch(ctx context.Context, match *model.SavedSearchMatch) (bool, error)
+
+	// FindMatchesBySearchID devuelve las últimas coincidencias de una búsqueda, con su producto
+	FindMatchesBySearchID(ctx context.Context, searchID uint, limit int) ([]*model.SavedSearchMatch, error)
+}
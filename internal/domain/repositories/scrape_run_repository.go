@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"app/internal/domain/model"
+)
+
+// ScrapeRunRepository define las operaciones de persistencia para las ejecuciones del scraper
+type ScrapeRunRepository interface {
+	// Create registra una nueva ejecución
+	Create(ctx context.Context, run *model.ScrapeRun) error
+
+	// Update actualiza una ejecución (contadores y fecha de fin)
+	Update(ctx context.Context, run *model.ScrapeRun) error
+
+	// FindSince busca las ejecuciones iniciadas desde una fecha, de la más reciente a la más antigua
+	FindSince(ctx context.Context, since time.Time) ([]*model.ScrapeRun, error)
+
+	// FindByStore busca las últimas ejecuciones de una tienda, de la más reciente a la más antigua
+	FindByStore(ctx context.Context, store string, limit int) ([]*model.ScrapeRun, error)
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"app/internal/domain/model"
 )
@@ -28,4 +29,10 @@ type UserRepository interface {
 	
 	// Delete elimina un usuario de la base de datos
 	Delete(ctx context.Context, id uint) error
+	
+	// FindByDigestFrequency busca los usuarios que reciben sus avisos con la frecuencia indicada
+	FindByDigestFrequency(ctx context.Context, frequency string) ([]*model.User, error)
+	
+	// UpdateLastDigestAt guarda solo la fecha del último resumen enviado al usuario
+	UpdateLastDigestAt(ctx context.Context, id uint, at time.Time) error
 } 
\ No newline at end of file
@@ -46,4 +46,10 @@ type WatchlistItemRepository interface {
 
 	// Buscar elementos por ID de producto
 	FindByProductID(ctx context.Context, productID uint) ([]*model.WatchlistItem, error)
+
+	// Buscar los IDs de los productos que están en la lista de seguimiento de algún usuario
+	FindProductIDs(ctx context.Context) ([]uint, error)
+
+	// Guardar solo el mejor precio incluido en el último resumen por correo
+	UpdateDigestPrice(ctx context.Context, itemID uint, price float64) error
 }
@@ -1,211 +1,471 @@
 package email
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
-	"io/ioutil"
 	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
 	"net/smtp"
-	"path/filepath"
-	"runtime"
+	"net/textproto"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
+	"app/internal/domain/model"
+	"app/internal/domain/repositories"
 	"app/pkg/config"
 )
 
-// Mailer es un servicio para enviar correos electrónicos
+// Mailer es un servicio para enviar correos electrónicos. Los correos no se envían al momento: se
+// guardan en la bandeja de salida y los envía el worker de la bandeja con Deliver, reintentándolos
+// si el servidor SMTP falla. Antes de guardar un correo de avisos, resúmenes o cuenta comprueba que
+// el destinatario lo acepta y le añade el enlace firmado para darse de baja
 type Mailer struct {
 	smtpHost string
 	smtpPort string
 	smtpUser string
 	smtpPass string
 	from     string
-	cssStyle string
+
+	templates         *emailTemplates
+	users             repositories.UserRepository // Preferencias de correo de los destinatarios
+	unsubscribeKey    []byte                      // Clave de los enlaces para darse de baja
+	unsubscribeMaxAge time.Duration               // Tiempo durante el que vale un enlace para darse de baja
+
+	outbox repositories.EmailOutboxRepository
+	queued chan struct{} // Avisa al worker de que hay correos nuevos en la bandeja
 }
 
-// NewMailer crea una nueva instancia del servicio de correo
-func NewMailer() *Mailer {
+// NewMailer crea una nueva instancia del servicio de correo que guarda los correos en la bandeja
+// de salida indicada y consulta las preferencias de correo en el repositorio de usuarios
+func NewMailer(outbox repositories.EmailOutboxRepository, users repositories.UserRepository) *Mailer {
 	// Usar la configuración del archivo config.yaml
 	emailConfig := config.Config.Email
 
-	// Cargar el archivo CSS
-	_, currentFile, _, _ := runtime.Caller(0)
-	cssPath := filepath.Join(filepath.Dir(currentFile), "email.css")
-	cssContent, err := ioutil.ReadFile(cssPath)
+	// Las plantillas van embebidas en el binario, así que un error aquí es un fallo de compilación
+	templates, err := loadTemplates()
+	if err != nil {
+		log.Fatalf("Error al cargar las plantillas de correo: %v", err)
+	}
+	key, err := unsubscribeKey(emailConfig.UnsubscribeSecret)
 	if err != nil {
-		log.Printf("Error al cargar el archivo CSS para emails: %v", err)
-		cssContent = []byte{} // Si hay error, usar un string vacío
+		log.Fatalf("Error en la clave de los enlaces para darse de baja: %v", err)
 	}
 
 	return &Mailer{
-		smtpHost: emailConfig.SMTPHost,
-		smtpPort: strconv.Itoa(emailConfig.SMTPPort),
-		smtpUser: emailConfig.SMTPUser,
-		smtpPass: emailConfig.SMTPPass,
-		from:     emailConfig.SMTPFrom,
-		cssStyle: string(cssContent),
+		smtpHost:  emailConfig.SMTPHost,
+		smtpPort:  strconv.Itoa(emailConfig.SMTPPort),
+		smtpUser:  emailConfig.SMTPUser,
+		smtpPass:  emailConfig.SMTPPass,
+		from:      emailConfig.SMTPFrom,
+		templates: templates,
+		users:     users,
+		outbox:    outbox,
+		queued:    make(chan struct{}, 1),
+
+		unsubscribeKey:    key,
+		unsubscribeMaxAge: emailConfig.UnsubscribeMaxAge,
 	}
 }
 
+// linkEmail son los datos de los correos con un enlace para el usuario (verificación y contraseña)
+type linkEmail struct {
+	Username string
+	URL      string
+}
+
+// passwordChangedEmail son los datos del aviso de cambio de contraseña
+type passwordChangedEmail struct {
+	Username  string
+	ChangedAt time.Time
+	ResetURL  string // Para recuperar la cuenta si el cambio no lo hizo el usuario
+}
+
+// priceAlertEmail son los datos del correo de alerta de precio
+type priceAlertEmail struct {
+	Username       string
+	ProductName    string
+	TargetPrice    float64
+	CurrentPrice   float64
+	Currency       string // Moneda de los importes (la del usuario)
+	Store          string
+	OfferURL       string // Oferta en la tienda
+	ProductURL     string // Producto en el comparador
+	HasSavings     bool
+	Savings        float64
+	SavingsPercent float64
+}
+
+// savedSearchEmail son los datos del correo de búsqueda guardada
+type savedSearchEmail struct {
+	Username    string
+	SearchName  string
+	ProductName string
+	Price       float64
+	Currency    string // Moneda de Price (la del usuario)
+	Store       string
+	OfferURL    string // Oferta en la tienda
+	ProductURL  string // Producto en el comparador
+}
+
+// Queued devuelve un canal que recibe un valor cuando se guarda un correo en la bandeja, para que
+// el worker lo envíe sin esperar a su siguiente pasada
+func (m *Mailer) Queued() <-chan struct{} {
+	return m.queued
+}
+
 // SendVerificationEmail envía un correo de verificación
 func (m *Mailer) SendVerificationEmail(to string, token string, username string) error {
-	// Construir el asunto y el cuerpo del correo
 	subject := "Verificación de cuenta - Comparador de Precios"
-
-	// URL de verificación
-	verificationURL := fmt.Sprintf("%s/verificar?token=%s", config.Config.App.URL, token)
-
-	// Construir el cuerpo del correo en formato HTML
-	body := fmt.Sprintf(`
-		<html>
-		<head>
-			<style>
-				%s
-			</style>
-		</head>
-		<body>
-			<div class="container">
-				<div class="header header-primary">
-					<h2>Verificación de Cuenta</h2>
-				</div>
-				<div class="content">
-					<div class="icon">✉️</div>
-					<h3>¡Hola <span class="highlight highlight-primary">%s</span>!</h3>
-					<p>Gracias por registrarte en nuestro <b>Comparador de Precios</b>.</p>
-					<p>Para activar tu cuenta y comenzar a ahorrar, haz clic en el siguiente botón:</p>
-					
-					<a href="%s" class="button button-primary">Verificar mi cuenta</a>
-					
-					<p><small>¿El botón no funciona? Copia y pega este enlace en tu navegador:</small></p>
-					<p class="link">%s</p>
-					<p><small>Este enlace expirará en 24 horas por seguridad.</small></p>
-					<p><small>Si no te has registrado en nuestra plataforma, puedes ignorar este correo.</small></p>
-				</div>
-				<div class="footer">
-					<p>© Comparador de Precios - Ahorra en tus compras online</p>
-					<p>Este correo es automático, por favor no lo respondas.</p>
-				</div>
-			</div>
-		</body>
-		</html>
-	`, m.cssStyle, username, verificationURL, verificationURL)
-
-	// Enviar el correo
-	return m.sendMail(to, subject, body)
+	data := linkEmail{
+		Username: username,
+		URL:      fmt.Sprintf("%s/verificar?token=%s", config.Config.App.URL, url.QueryEscape(token)),
+	}
+	return m.sendTemplate(to, subject, templateVerification, "", "primary", "Verificación de Cuenta", data)
 }
 
 // SendPasswordResetEmail envía un correo con un enlace para restablecer la contraseña
 func (m *Mailer) SendPasswordResetEmail(to, token, username string) error {
-	// Usar la URL de la aplicación de la configuración
-	appURL := config.Config.App.URL
-
-	resetURL := fmt.Sprintf("%s/restablecer-password?token=%s", appURL, token)
 	subject := "Restablecimiento de contraseña - Comparador de Precios"
+	data := linkEmail{
+		Username: username,
+		URL:      fmt.Sprintf("%s/restablecer-password?token=%s", config.Config.App.URL, url.QueryEscape(token)),
+	}
+	return m.sendTemplate(to, subject, templatePasswordReset, "", "purple", "Restablecimiento de Contraseña", data)
+}
 
-	htmlBody := fmt.Sprintf(`
-		<html>
-		<head>
-			<style>
-				%s
-			</style>
-		</head>
-		<body>
-			<div class="container">
-				<div class="header header-purple">
-					<h2>Restablecimiento de Contraseña</h2>
-				</div>
-				<div class="content">
-					<div class="icon">🔐</div>
-					<h3>¡Hola <span class="highlight highlight-purple">%s</span>!</h3>
-					<p>Hemos recibido una solicitud para restablecer tu contraseña.</p>
-					<p>Si fuiste tú, haz clic en el siguiente botón para crear una nueva contraseña:</p>
-					
-					<a href="%s" class="button button-purple">Restablecer mi contraseña</a>
-					
-					<p><small>¿El botón no funciona? Copia y pega este enlace en tu navegador:</small></p>
-					<p class="link">%s</p>
-					<p><small>Este enlace expirará en 24 horas por seguridad.</small></p>
-					<p><small>Si no has solicitado el restablecimiento de contraseña, puedes ignorar este mensaje.</small></p>
-				</div>
-				<div class="footer">
-					<p>© Comparador de Precios - Ahorra en tus compras online</p>
-					<p>Este correo es automático, por favor no lo respondas.</p>
-				</div>
-			</div>
-		</body>
-		</html>
-	`, m.cssStyle, username, resetURL, resetURL)
-
-	return m.sendMail(to, subject, htmlBody)
+// SendPasswordChangedEmail avisa al usuario de que se ha cambiado la contraseña de su cuenta
+func (m *Mailer) SendPasswordChangedEmail(to, username string) error {
+	subject := "Tu contraseña ha cambiado - Comparador de Precios"
+	data := passwordChangedEmail{
+		Username:  username,
+		ChangedAt: time.Now(),
+		ResetURL:  config.Config.App.URL + "/forgot-password",
+	}
+	return m.sendTemplate(to, subject, templatePasswordChanged, model.EmailCategoryAccount, "purple", "Contraseña cambiada", data)
 }
 
 // SendPriceAlertEmail envía un correo cuando un producto alcanza el precio objetivo
 func (m *Mailer) SendPriceAlertEmail(to string, username string, productName string, productID uint,
-	targetPrice float64, currentPrice float64, store string, productURL string) error {
+	targetPrice float64, currentPrice float64, currency string, store string, productURL string) error {
 
-	// Construir el asunto del correo
 	subject := fmt.Sprintf("¡Alerta de precio para %s! - Comparador de Precios", productName)
+	data := priceAlertEmail{
+		Username:     username,
+		ProductName:  productName,
+		TargetPrice:  targetPrice,
+		CurrentPrice: currentPrice,
+		Currency:     currency,
+		Store:        store,
+		OfferURL:     productURL,
+		ProductURL:   fmt.Sprintf("%s/producto/%d", config.Config.App.URL, productID),
+	}
+	// El ahorro solo se muestra si el precio de referencia es mayor que el actual
+	if targetPrice > currentPrice && currentPrice > 0 {
+		data.HasSavings = true
+		data.Savings = targetPrice - currentPrice
+		data.SavingsPercent = data.Savings / targetPrice * 100
+	}
+	return m.sendTemplate(to, subject, templatePriceAlert, model.EmailCategoryAlerts, "success", "¡Alerta de Precio!", data)
+}
 
-	// URL del producto en nuestra plataforma
-	ourProductURL := fmt.Sprintf("%s/producto/%d", config.Config.App.URL, productID)
-
-	// Calcular el porcentaje de ahorro
-	savingsPercent := ((targetPrice - currentPrice) / targetPrice) * 100
-
-	// Construir el cuerpo del correo en formato HTML
-	body := fmt.Sprintf(`
-		<html>
-		<head>
-			<style>
-				%s
-			</style>
-		</head>
-		<body>
-			<div class="container">
-				<div class="header header-success">
-					<h2>¡Alerta de Precio!</h2>
-				</div>
-				<div class="content">
-					<div class="icon">🎉</div>
-					<h3>¡Buenas noticias, <span class="highlight highlight-success">%s</span>!</h3>
-					<p>El producto que estabas siguiendo ha alcanzado tu precio objetivo.</p>
-					
-					<div class="product-card">
-						<h3>%s</h3>
-						<p>
-							<span class="price-tag">%.2f€</span>
-							<span class="old-price">%.2f€</span>
-							<span class="store-badge">%s</span>
-						</p>
-						<div class="savings">¡Ahorras un %.1f%% (%.2f€)!</div>
-					</div>
-					
-					<div class="button-container">
-						<a href="%s" class="button button-success" style="width: 45%%;">
-							Ver oferta en %s
-						</a>
-						<a href="%s" class="button button-primary" style="width: 45%%;">
-							Ver en Comparador
-						</a>
-					</div>
-					
-					<p><small>Esta alerta de precio se ha activado porque el precio actual del producto es igual o inferior al precio objetivo que configuraste.</small></p>
-					<p><small>Puedes gestionar tus alertas de precio en tu perfil dentro de nuestra plataforma.</small></p>
-				</div>
-				<div class="footer">
-					<p>© Comparador de Precios - Ahorra en tus compras online</p>
-					<p>Este correo es automático, por favor no lo respondas.</p>
-				</div>
-			</div>
-		</body>
-		</html>
-	`, m.cssStyle, username, productName, currentPrice, targetPrice, store, savingsPercent, targetPrice-currentPrice, productURL, store, ourProductURL)
+// SendSavedSearchEmail envía un correo cuando un producto cumple por primera vez una búsqueda guardada
+func (m *Mailer) SendSavedSearchEmail(to string, username string, searchName string, productName string, productID uint,
+	price float64, currency string, store string, productURL string) error {
 
-	// Enviar el correo
-	return m.sendMail(to, subject, body)
+	subject := fmt.Sprintf("Nuevo resultado para «%s» - Comparador de Precios", searchName)
+	data := savedSearchEmail{
+		Username:    username,
+		SearchName:  searchName,
+		ProductName: productName,
+		Price:       price,
+		Currency:    currency,
+		Store:       store,
+		OfferURL:    productURL,
+		ProductURL:  fmt.Sprintf("%s/producto/%d", config.Config.App.URL, productID),
+	}
+	return m.sendTemplate(to, subject, templateSavedSearch, model.EmailCategoryAlerts, "success", "Nuevo resultado en tu búsqueda", data)
 }
 
-// sendMail envía un correo electrónico
-func (m *Mailer) sendMail(to string, subject string, body string) error {
+// Digest son los avisos que se envían juntos en un resumen por correo
+type Digest struct {
+	Frequency     string     // model.DigestDaily o model.DigestWeekly
+	Currency      string     // Moneda de los importes de los movimientos (la del usuario)
+	Since         *time.Time // Fecha del resumen anterior (nil si es el primero)
+	Notifications []DigestNotification
+	Movements     []DigestMovement
+}
+
+// DigestNotification es un aviso de una alerta o búsqueda guardada incluido en un resumen
+type DigestNotification struct {
+	Title     string
+	Message   string
+	ProductID uint
+	CreatedAt time.Time
+}
+
+// DigestMovement es un cambio de precio de un producto de la lista de seguimiento desde el resumen anterior
+type DigestMovement struct {
+	ProductID   uint
+	ProductName string
+	OldPrice    float64
+	NewPrice    float64
+}
+
+// IsDrop indica si el precio ha bajado
+func (m DigestMovement) IsDrop() bool {
+	return m.NewPrice < m.OldPrice
+}
+
+// ChangePercent devuelve la variación del precio en porcentaje (negativa si ha bajado)
+func (m DigestMovement) ChangePercent() float64 {
+	if m.OldPrice == 0 {
+		return 0
+	}
+	return (m.NewPrice - m.OldPrice) / m.OldPrice * 100
+}
+
+// digestMaxNotifications es el número máximo de avisos que se detallan en un resumen; del resto
+// solo se indica cuántos son
+const digestMaxNotifications = 50
+
+// digestNotificationView es un aviso del resumen con el enlace al producto ya construido
+type digestNotificationView struct {
+	DigestNotification
+	ProductURL string
+}
+
+// digestMovementView es un cambio de precio del resumen con el enlace al producto ya construido
+type digestMovementView struct {
+	DigestMovement
+	ProductURL string
+}
+
+// digestEmail son los datos del correo de resumen
+type digestEmail struct {
+	Username           string
+	Period             string // "diario" o "semanal"
+	Currency           string // Moneda de los importes de los movimientos
+	Since              *time.Time
+	Notifications      []digestNotificationView
+	TotalNotifications int
+	MoreNotifications  int // Avisos que no se detallan por superar digestMaxNotifications
+	Movements          []digestMovementView
+	NotificationsURL   string
+}
+
+// SendDigestEmail envía en un solo correo los avisos pendientes del usuario y los cambios de precio
+// de su lista de seguimiento
+func (m *Mailer) SendDigestEmail(to string, username string, digest *Digest) error {
+	period, heading := "diario", "Tu resumen diario"
+	if digest.Frequency == model.DigestWeekly {
+		period, heading = "semanal", "Tu resumen semanal"
+	}
+	subject := fmt.Sprintf("%s de avisos - Comparador de Precios", heading)
+
+	appURL := config.Config.App.URL
+	data := digestEmail{
+		Username:           username,
+		Period:             period,
+		Currency:           digest.Currency,
+		Since:              digest.Since,
+		TotalNotifications: len(digest.Notifications),
+		NotificationsURL:   appURL + "/notificaciones",
+	}
+	for i, notification := range digest.Notifications {
+		if i == digestMaxNotifications {
+			data.MoreNotifications = len(digest.Notifications) - digestMaxNotifications
+			break
+		}
+		data.Notifications = append(data.Notifications, digestNotificationView{
+			DigestNotification: notification,
+			ProductURL:         fmt.Sprintf("%s/producto/%d", appURL, notification.ProductID),
+		})
+	}
+	for _, movement := range digest.Movements {
+		data.Movements = append(data.Movements, digestMovementView{
+			DigestMovement: movement,
+			ProductURL:     fmt.Sprintf("%s/producto/%d", appURL, movement.ProductID),
+		})
+	}
+
+	return m.sendTemplate(to, subject, templateDigest, model.EmailCategoryDigest, "primary", heading, data)
+}
+
+// sendTemplate pinta un correo con sus plantillas y lo guarda en la bandeja de salida. Los correos
+// de un tipo (model.EmailCategory*) solo se guardan si el destinatario lo acepta, y llevan el enlace
+// para darse de baja; si no lo acepta se devuelve ErrOptedOut. Los correos sin tipo son los que pide
+// el propio usuario y se envían siempre
+func (m *Mailer) sendTemplate(to, subject, name, category, accent, heading string, data any) error {
+	var unsubscribeURL string
+	if category != "" {
+		user, err := m.users.FindByEmail(context.Background(), to)
+		if err != nil {
+			log.Printf("[ERROR] Error al comprobar las preferencias de correo de %s: %v", to, err)
+			return fmt.Errorf("error al comprobar las preferencias de correo del destinatario: %w", err)
+		}
+		if !user.AcceptsEmail(category) {
+			log.Printf("[INFO] Correo '%s' a %s descartado: el usuario no acepta correos de tipo %s", subject, to, category)
+			return ErrOptedOut
+		}
+		unsubscribeURL = m.UnsubscribeURL(user.ID, category)
+	}
+
+	htmlBody, textBody, err := m.templates.render(name, accent, heading, unsubscribeURL, data)
+	if err != nil {
+		log.Printf("[ERROR] Error al preparar el correo '%s' a %s: %v", subject, to, err)
+		return err
+	}
+	return m.sendMail(to, subject, htmlBody, textBody, unsubscribeURL)
+}
+
+// sendMail guarda el correo en la bandeja de salida y avisa al worker para que lo envíe. El
+// Message-ID se genera aquí para que todos los reintentos lleven el mismo
+func (m *Mailer) sendMail(to, subject, htmlBody, textBody, unsubscribeURL string) error {
+	now := time.Now()
+	email := &model.OutboxEmail{
+		Recipient:     to,
+		Subject:       subject,
+		MessageID:     m.newMessageID(),
+		Body:          htmlBody,
+		TextBody:      textBody,
+		Unsubscribe:   unsubscribeURL,
+		Status:        model.OutboxPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	if err := m.outbox.Create(context.Background(), email); err != nil {
+		log.Printf("[ERROR] Error al guardar en la bandeja de salida el correo a %s con asunto '%s': %v", to, subject, err)
+		return fmt.Errorf("error al guardar el correo en la bandeja de salida: %w", err)
+	}
+
+	select {
+	case m.queued <- struct{}{}:
+	default:
+		// Ya hay un aviso pendiente; el worker enviará también este correo
+	}
+	return nil
+}
+
+// Deliver envía por SMTP un correo de la bandeja de salida
+func (m *Mailer) Deliver(email *model.OutboxEmail) error {
+	// Los correos guardados antes de existir el Message-ID reciben uno en su primer intento
+	if email.MessageID == "" {
+		email.MessageID = m.newMessageID()
+	}
+
+	message, err := m.buildMessage(email)
+	if err != nil {
+		return err
+	}
+	return m.deliver(email.Recipient, email.Subject, message)
+}
+
+// sender devuelve el remitente de los correos: smtp.from o, si no se ha configurado, el usuario SMTP
+func (m *Mailer) sender() string {
+	if m.from != "" {
+		return m.from
+	}
+	return m.smtpUser
+}
+
+// newMessageID genera un identificador único para la cabecera Message-ID con el dominio del remitente
+func (m *Mailer) newMessageID() string {
+	domain := "localhost"
+	if address, err := mail.ParseAddress(m.sender()); err == nil {
+		if _, host, found := strings.Cut(address.Address, "@"); found && host != "" {
+			domain = host
+		}
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("<%d@%s>", time.Now().UnixNano(), domain)
+	}
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(buf), domain)
+}
+
+// buildMessage construye el mensaje MIME del correo: multipart/alternative con la parte de texto
+// plano y la HTML (los clientes muestran la última que entienden). Los correos antiguos sin texto
+// plano se envían solo con la parte HTML
+func (m *Mailer) buildMessage(email *model.OutboxEmail) ([]byte, error) {
+	from := m.sender()
+	if address, err := mail.ParseAddress(from); err == nil {
+		from = address.String()
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", email.TextBody},
+		{"text/html; charset=UTF-8", email.Body},
+	}
+	for _, part := range parts {
+		if part.content == "" {
+			continue
+		}
+		partWriter, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error al construir el correo: %w", err)
+		}
+		qp := quotedprintable.NewWriter(partWriter)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("error al construir el correo: %w", err)
+		}
+		if err := qp.Close(); err != nil {
+			return nil, fmt.Errorf("error al construir el correo: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("error al construir el correo: %w", err)
+	}
+
+	date := email.CreatedAt
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	// Las cabeceras van en un orden fijo; el asunto se codifica según RFC 2047 si no es ASCII
+	var message bytes.Buffer
+	headers := [][2]string{
+		{"From", from},
+		{"To", email.Recipient},
+		{"Subject", mime.QEncoding.Encode("utf-8", email.Subject)},
+		{"Date", date.Format(time.RFC1123Z)},
+		{"Message-ID", email.MessageID},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": writer.Boundary()})},
+	}
+	if email.Unsubscribe != "" {
+		// Baja en un clic (RFC 8058): el cliente de correo hace un POST al enlace sin abrir la web
+		headers = append(headers,
+			[2]string{"List-Unsubscribe", "<" + email.Unsubscribe + ">"},
+			[2]string{"List-Unsubscribe-Post", "List-Unsubscribe=One-Click"},
+		)
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&message, "%s: %s\r\n", header[0], header[1])
+	}
+	message.WriteString("\r\n")
+	message.Write(body.Bytes())
+	return message.Bytes(), nil
+}
+
+// deliver envía un mensaje ya construido por SMTP
+func (m *Mailer) deliver(to string, subject string, message []byte) error {
 	// Verificar que la configuración SMTP está completa
 	if m.smtpHost == "" || m.smtpPort == "" || m.smtpUser == "" || m.smtpPass == "" {
 		log.Printf("[ERROR] Configuración SMTP incompleta - Host: %s, Puerto: %s, Usuario: %s",
@@ -214,26 +474,11 @@ func (m *Mailer) sendMail(to string, subject string, body string) error {
 			m.smtpHost, m.smtpPort, m.smtpUser)
 	}
 
-	// Si no se especificó el remitente, usar el usuario SMTP
-	from := m.from
-	if from == "" {
-		from = m.smtpUser
-	}
-
-	// Construir los encabezados del correo
-	headers := make(map[string]string)
-	headers["From"] = from
-	headers["To"] = to
-	headers["Subject"] = subject
-	headers["MIME-Version"] = "1.0"
-	headers["Content-Type"] = "text/html; charset=UTF-8"
-
-	// Construir el mensaje completo
-	message := ""
-	for k, v := range headers {
-		message += fmt.Sprintf("%s: %s\r\n", k, v)
+	// El sobre SMTP solo lleva la dirección, sin el nombre del remitente
+	from := m.sender()
+	if address, err := mail.ParseAddress(from); err == nil {
+		from = address.Address
 	}
-	message += "\r\n" + body
 
 	log.Printf("[INFO] Preparando envío de correo a %s con asunto '%s' a través de SMTP %s:%s",
 		to, subject, m.smtpHost, m.smtpPort)
@@ -247,7 +492,7 @@ func (m *Mailer) sendMail(to string, subject string, body string) error {
 		auth,
 		from,
 		[]string{to},
-		[]byte(message),
+		message,
 	)
 
 	if err != nil {
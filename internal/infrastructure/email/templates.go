@@ -0,0 +1,194 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"regexp"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"app/internal/domain/model"
+	"app/pkg/config"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Plantillas de los correos. Cada correo tiene una plantilla HTML y otra de texto plano que
+// definen el bloque "content" y se pintan dentro de layout.html y layout.txt
+//
+//go:embed templates/*.html templates/*.txt email.css
+var templateFS embed.FS
+
+// Nombres de las plantillas de correo (templates/<nombre>.html y templates/<nombre>.txt)
+const (
+	templateVerification    = "verification"
+	templatePasswordReset   = "password_reset"
+	templatePriceAlert      = "price_alert"
+	templateSavedSearch     = "saved_search"
+	templateDigest          = "digest"
+	templatePasswordChanged = "password_changed"
+)
+
+var emailTemplateNames = []string{templateVerification, templatePasswordReset, templatePriceAlert, templateSavedSearch,
+	templateDigest, templatePasswordChanged}
+
+// templateFuncs son las funciones disponibles en las plantillas de correo
+var templateFuncs = map[string]any{
+	"price": model.FormatPrice, // price importe moneda
+	"date":  func(t time.Time) string { return t.Format("02/01/2006 15:04") },
+}
+
+// emailData son los datos comunes a todas las plantillas de correo
+type emailData struct {
+	Accent  string           // Color de la cabecera: primary, success o purple
+	Heading string           // Título de la cabecera
+	CSS     htmltemplate.CSS // Contenido de email.css
+	Data    any              // Datos propios de cada correo
+
+	UnsubscribeURL string // Enlace para darse de baja (vacío si el correo no lo admite)
+	PreferencesURL string // Página de preferencias de correo
+}
+
+// emailTemplates contiene las plantillas HTML y de texto ya cargadas
+type emailTemplates struct {
+	html map[string]*htmltemplate.Template
+	text map[string]*texttemplate.Template
+	css  string
+}
+
+// loadTemplates carga las plantillas de correo embebidas en el binario
+func loadTemplates() (*emailTemplates, error) {
+	css, err := templateFS.ReadFile("email.css")
+	if err != nil {
+		return nil, fmt.Errorf("error al cargar email.css: %w", err)
+	}
+
+	templates := &emailTemplates{
+		html: make(map[string]*htmltemplate.Template, len(emailTemplateNames)),
+		text: make(map[string]*texttemplate.Template, len(emailTemplateNames)),
+		css:  string(css),
+	}
+	for _, name := range emailTemplateNames {
+		htmlTmpl, err := htmltemplate.New("layout.html").Funcs(templateFuncs).
+			ParseFS(templateFS, "templates/layout.html", "templates/"+name+".html")
+		if err != nil {
+			return nil, fmt.Errorf("error al cargar la plantilla de correo %s.html: %w", name, err)
+		}
+		textTmpl, err := texttemplate.New("layout.txt").Funcs(templateFuncs).
+			ParseFS(templateFS, "templates/layout.txt", "templates/"+name+".txt")
+		if err != nil {
+			return nil, fmt.Errorf("error al cargar la plantilla de correo %s.txt: %w", name, err)
+		}
+		templates.html[name] = htmlTmpl
+		templates.text[name] = textTmpl
+	}
+	return templates, nil
+}
+
+// render pinta un correo y devuelve su parte HTML, con los estilos ya copiados a cada elemento,
+// y su parte de texto plano
+func (t *emailTemplates) render(name, accent, heading, unsubscribeURL string, data any) (string, string, error) {
+	payload := emailData{
+		Accent:  accent,
+		Heading: heading,
+		CSS:     htmltemplate.CSS(t.css),
+		Data:    data,
+
+		UnsubscribeURL: unsubscribeURL,
+		PreferencesURL: config.Config.App.URL + "/perfil/correo",
+	}
+
+	var htmlBuf bytes.Buffer
+	if err := t.html[name].ExecuteTemplate(&htmlBuf, "layout", payload); err != nil {
+		return "", "", fmt.Errorf("error al pintar la plantilla de correo %s.html: %w", name, err)
+	}
+	var textBuf bytes.Buffer
+	if err := t.text[name].ExecuteTemplate(&textBuf, "layout", payload); err != nil {
+		return "", "", fmt.Errorf("error al pintar la plantilla de correo %s.txt: %w", name, err)
+	}
+
+	htmlBody, err := inlineCSS(htmlBuf.String(), t.css)
+	if err != nil {
+		return "", "", err
+	}
+	return htmlBody, textBuf.String(), nil
+}
+
+// cssRule es una regla de email.css: sus selectores y sus declaraciones
+type cssRule struct {
+	selectors    []string
+	declarations string
+}
+
+var cssComments = regexp.MustCompile(`(?s)/\*.*?\*/`)
+
+// parseCSS separa una hoja de estilos sencilla (sin @media ni reglas anidadas) en reglas
+func parseCSS(css string) []cssRule {
+	css = cssComments.ReplaceAllString(css, "")
+
+	var rules []cssRule
+	for _, block := range strings.Split(css, "}") {
+		selectors, declarations, found := strings.Cut(block, "{")
+		if !found {
+			continue
+		}
+		declarations = strings.Join(strings.Fields(declarations), " ")
+		if declarations == "" {
+			continue
+		}
+		rule := cssRule{declarations: strings.TrimSuffix(declarations, ";") + ";"}
+		for _, selector := range strings.Split(selectors, ",") {
+			if selector = strings.TrimSpace(selector); selector != "" {
+				rule.selectors = append(rule.selectors, selector)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// inlineCSS copia las reglas de css al atributo style de cada elemento al que se aplican, porque
+// muchos clientes de correo ignoran el bloque <style>. Las reglas se aplican en el orden de la hoja
+// y el estilo que ya tuviera el elemento va al final para que prevalezca. Las pseudoclases
+// (:hover...) no se pueden copiar y se quedan solo en el bloque <style>
+func inlineCSS(body, css string) (string, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("error al leer el HTML del correo: %w", err)
+	}
+
+	const originalStyle = "data-original-style"
+	doc.Find("[style]").Each(func(_ int, s *goquery.Selection) {
+		style, _ := s.Attr("style")
+		s.SetAttr(originalStyle, style)
+		s.RemoveAttr("style")
+	})
+
+	for _, rule := range parseCSS(css) {
+		for _, selector := range rule.selectors {
+			if strings.Contains(selector, ":") {
+				continue
+			}
+			doc.Find(selector).Each(func(_ int, s *goquery.Selection) {
+				style, _ := s.Attr("style")
+				s.SetAttr("style", strings.TrimSpace(style+" "+rule.declarations))
+			})
+		}
+	}
+
+	doc.Find("[" + originalStyle + "]").Each(func(_ int, s *goquery.Selection) {
+		original, _ := s.Attr(originalStyle)
+		style, _ := s.Attr("style")
+		s.SetAttr("style", strings.TrimSpace(style+" "+original))
+		s.RemoveAttr(originalStyle)
+	})
+
+	html, err := doc.Html()
+	if err != nil {
+		return "", fmt.Errorf("error al generar el HTML del correo: %w", err)
+	}
+	return html, nil
+}
@@ -0,0 +1,100 @@
+package email
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"app/internal/domain/model"
+	"app/pkg/config"
+)
+
+// ErrOptedOut indica que el destinatario ha pedido no recibir correos de ese tipo
+var ErrOptedOut = errors.New("el usuario no acepta este tipo de correo")
+
+// ErrInvalidUnsubscribeToken indica que el enlace para darse de baja no es válido o está manipulado
+var ErrInvalidUnsubscribeToken = errors.New("enlace para darse de baja no válido")
+
+// ErrExpiredUnsubscribeToken indica que el enlace para darse de baja es auténtico pero ha caducado.
+// También es un ErrInvalidUnsubscribeToken
+var ErrExpiredUnsubscribeToken = fmt.Errorf("%w: ha caducado", ErrInvalidUnsubscribeToken)
+
+// minUnsubscribeSecretLength es la longitud mínima de la clave de los enlaces para darse de baja
+const minUnsubscribeSecretLength = 32
+
+// unsubscribeSecretPlaceholder es el valor de ejemplo de configs/config.example.yaml
+const unsubscribeSecretPlaceholder = "CAMBIAR_POR_UNA_CLAVE_ALEATORIA"
+
+// unsubscribeKey comprueba la clave con la que se firman los enlaces para darse de baja. Es
+// obligatoria: una clave generada al arrancar invalidaría los enlaces de los correos ya enviados
+func unsubscribeKey(secret string) ([]byte, error) {
+	switch {
+	case secret == "" || secret == unsubscribeSecretPlaceholder:
+		return nil, errors.New("configura email.unsubscribe_secret (o UNSUBSCRIBE_SECRET) con una clave aleatoria")
+	case len(secret) < minUnsubscribeSecretLength:
+		return nil, fmt.Errorf("email.unsubscribe_secret debe tener al menos %d caracteres", minUnsubscribeSecretLength)
+	}
+	return []byte(secret), nil
+}
+
+// UnsubscribeToken genera el token firmado que da de baja al usuario de un tipo de correo. El token
+// lleva el instante en que se genera y vale durante email.unsubscribe_max_age
+func (m *Mailer) UnsubscribeToken(userID uint, category string) string {
+	return m.unsubscribeToken(userID, category, time.Now())
+}
+
+func (m *Mailer) unsubscribeToken(userID uint, category string, issuedAt time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%s:%d", userID, category, issuedAt.Unix())))
+	return payload + "." + m.signUnsubscribe(payload)
+}
+
+// ParseUnsubscribeToken comprueba la firma y la antigüedad de un token para darse de baja y devuelve
+// el usuario y el tipo de correo
+func (m *Mailer) ParseUnsubscribeToken(token string) (uint, string, error) {
+	return m.parseUnsubscribeToken(token, time.Now())
+}
+
+func (m *Mailer) parseUnsubscribeToken(token string, now time.Time) (uint, string, error) {
+	payload, signature, found := strings.Cut(token, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(m.signUnsubscribe(payload))) {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+	fields := strings.Split(string(decoded), ":")
+	if len(fields) != 3 || !model.IsValidEmailCategory(fields[1]) {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+	userID, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil || userID == 0 {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+	issuedAt, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+	if age := now.Sub(time.Unix(issuedAt, 0)); age > m.unsubscribeMaxAge {
+		return 0, "", ErrExpiredUnsubscribeToken
+	}
+	return uint(userID), fields[1], nil
+}
+
+// UnsubscribeURL devuelve el enlace para darse de baja de un tipo de correo
+func (m *Mailer) UnsubscribeURL(userID uint, category string) string {
+	return fmt.Sprintf("%s/correo/baja?token=%s", config.Config.App.URL, m.UnsubscribeToken(userID, category))
+}
+
+// signUnsubscribe firma el contenido de un token para darse de baja
+func (m *Mailer) signUnsubscribe(payload string) string {
+	mac := hmac.New(sha256.New, m.unsubscribeKey)
+	mac.Write([]byte("unsubscribe:" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
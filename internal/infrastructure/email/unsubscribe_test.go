@@ -0,0 +1,103 @@
+package email
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"app/internal/domain/model"
+)
+
+const testUnsubscribeSecret = "0123456789abcdef0123456789abcdef"
+
+func TestUnsubscribeKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{"sin clave", "", true},
+		{"clave de ejemplo", unsubscribeSecretPlaceholder, true},
+		{"demasiado corta", strings.Repeat("x", minUnsubscribeSecretLength-1), true},
+		{"longitud mínima", strings.Repeat("x", minUnsubscribeSecretLength), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := unsubscribeKey(tt.secret); (err != nil) != tt.wantErr {
+				t.Errorf("unsubscribeKey() error = %v, se esperaba error: %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseUnsubscribeToken(t *testing.T) {
+	const maxAge = 30 * 24 * time.Hour
+	issuedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	m := &Mailer{unsubscribeKey: []byte(testUnsubscribeSecret), unsubscribeMaxAge: maxAge}
+	other := &Mailer{unsubscribeKey: []byte(strings.Repeat("z", minUnsubscribeSecretLength)), unsubscribeMaxAge: maxAge}
+	token := m.unsubscribeToken(42, model.EmailCategoryAlerts, issuedAt)
+	payload, signature, _ := strings.Cut(token, ".")
+
+	// sign firma un contenido arbitrario con la clave buena, como si se hubiera filtrado la clave
+	sign := func(raw string) string {
+		encoded := base64.RawURLEncoding.EncodeToString([]byte(raw))
+		return encoded + "." + m.signUnsubscribe(encoded)
+	}
+	// tamper cambia el usuario del contenido y conserva la firma original
+	tamper := base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(mustDecode(t, payload), "42:", "43:", 1))) + "." + signature
+
+	tests := []struct {
+		name    string
+		token   string
+		now     time.Time
+		wantErr error
+	}{
+		{"recién generado", token, issuedAt, nil},
+		{"justo antes de caducar", token, issuedAt.Add(maxAge), nil},
+		{"caducado", token, issuedAt.Add(maxAge + time.Second), ErrExpiredUnsubscribeToken},
+		{"usuario cambiado", tamper, issuedAt, ErrInvalidUnsubscribeToken},
+		{"firma cambiada", payload + "." + strings.Repeat("A", len(signature)), issuedAt, ErrInvalidUnsubscribeToken},
+		{"firmado con otra clave", other.unsubscribeToken(42, model.EmailCategoryAlerts, issuedAt), issuedAt, ErrInvalidUnsubscribeToken},
+		{"sin firma", payload, issuedAt, ErrInvalidUnsubscribeToken},
+		{"vacío", "", issuedAt, ErrInvalidUnsubscribeToken},
+		{"formato antiguo sin fecha", sign("42:" + model.EmailCategoryAlerts), issuedAt, ErrInvalidUnsubscribeToken},
+		{"categoría desconocida", sign("42:spam:1772366400"), issuedAt, ErrInvalidUnsubscribeToken},
+		{"fecha no numérica", sign("42:" + model.EmailCategoryAlerts + ":ayer"), issuedAt, ErrInvalidUnsubscribeToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID, category, err := m.parseUnsubscribeToken(tt.token, tt.now)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("parseUnsubscribeToken() error = %v, se esperaba %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseUnsubscribeToken() error = %v", err)
+			}
+			if userID != 42 || category != model.EmailCategoryAlerts {
+				t.Errorf("parseUnsubscribeToken() = (%d, %q), se esperaba (42, %q)", userID, category, model.EmailCategoryAlerts)
+			}
+		})
+	}
+
+	// Un enlace caducado sigue siendo un enlace no válido para quien solo comprueba ese error
+	if !errors.Is(ErrExpiredUnsubscribeToken, ErrInvalidUnsubscribeToken) {
+		t.Error("ErrExpiredUnsubscribeToken debe ser también ErrInvalidUnsubscribeToken")
+	}
+}
+
+func mustDecode(t *testing.T, payload string) string {
+	t.Helper()
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		t.Fatalf("contenido del token no válido: %v", err)
+	}
+	return string(decoded)
+}
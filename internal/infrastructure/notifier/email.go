@@ -0,0 +1,43 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+
+	"app/internal/domain/model"
+	"app/internal/infrastructure/email"
+)
+
+// EmailChannel envía los avisos por correo con las plantillas del Mailer
+type EmailChannel struct {
+	mailer *email.Mailer
+}
+
+// NewEmailChannel crea el canal de correo
+func NewEmailChannel(mailer *email.Mailer) *EmailChannel {
+	return &EmailChannel{mailer: mailer}
+}
+
+// Name implementa Channel
+func (c *EmailChannel) Name() string {
+	return model.ChannelEmail
+}
+
+// Send implementa Channel. Si el usuario se ha dado de baja de ese tipo de correo devuelve
+// email.ErrOptedOut sin envolver, para que quien reparte los avisos lo distinga de un fallo
+func (c *EmailChannel) Send(ctx context.Context, user *model.User, settings *model.NotificationChannel, msg *Message) error {
+	if user.Email == "" {
+		return fmt.Errorf("el usuario %d no tiene correo", user.ID)
+	}
+
+	switch msg.Event {
+	case EventPriceAlert:
+		return c.mailer.SendPriceAlertEmail(user.Email, user.Username, msg.ProductName, msg.ProductID,
+			msg.ReferencePrice, msg.Price, msg.Currency, msg.Store, msg.OfferURL)
+	case EventSavedSearch:
+		return c.mailer.SendSavedSearchEmail(user.Email, user.Username, msg.SearchName, msg.ProductName, msg.ProductID,
+			msg.Price, msg.Currency, msg.Store, msg.OfferURL)
+	default:
+		return fmt.Errorf("el canal de correo no admite avisos de tipo %q", msg.Event)
+	}
+}
@@ -0,0 +1,49 @@
+// Package notifier contiene los canales externos por los que se envían las notificaciones de los
+// usuarios (correo, webhook). Las notificaciones de la propia aplicación se guardan siempre en la
+// base de datos; estos canales son copias del aviso hacia fuera
+package notifier
+
+import (
+	"context"
+	"time"
+
+	"app/internal/domain/model"
+)
+
+// Tipos de aviso que se envían por los canales
+const (
+	EventPriceAlert  = "price_alert"  // Se ha cumplido una alerta de precio
+	EventSavedSearch = "saved_search" // Un producto cumple por primera vez una búsqueda guardada
+	EventTest        = "test"         // Envío de prueba pedido por el usuario
+)
+
+// Message es un aviso para un usuario, con los datos que necesita cualquier canal
+type Message struct {
+	Event string
+	Title string
+	Body  string
+
+	ProductID      uint
+	ProductName    string
+	Price          float64 // Precio comparable de la oferta que origina el aviso, en la moneda del usuario
+	ReferencePrice float64 // Precio con el que se ha comparado (objetivo, mínimo, media...), si lo hay
+	Currency       string  // Moneda de Price y ReferencePrice
+	Store          string
+	OfferURL       string
+
+	AlertID    *uint  // Alerta de precio que origina el aviso
+	SearchID   *uint  // Búsqueda guardada que origina el aviso
+	SearchName string // Nombre de la búsqueda guardada
+
+	CreatedAt time.Time
+}
+
+// Channel es un canal externo de notificación. settings es la preferencia del usuario para el
+// canal (URL del webhook, clave...), o nil si no la ha guardado
+type Channel interface {
+	// Name devuelve el nombre del canal (model.ChannelEmail, model.ChannelWebhook)
+	Name() string
+
+	// Send envía el aviso al usuario por el canal
+	Send(ctx context.Context, user *model.User, settings *model.NotificationChannel, msg *Message) error
+}
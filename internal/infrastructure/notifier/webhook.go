@@ -0,0 +1,242 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"app/internal/domain/model"
+	"app/pkg/config"
+	"app/pkg/utils"
+)
+
+// Cabeceras de cada envío al webhook
+const (
+	HeaderEvent     = "X-PriceHunter-Event"     // Tipo de aviso (price_alert, saved_search, test)
+	HeaderDelivery  = "X-PriceHunter-Delivery"  // Identificador del envío, el mismo en todos sus reintentos
+	HeaderTimestamp = "X-PriceHunter-Timestamp" // Instante del envío en segundos Unix
+	HeaderSignature = "X-PriceHunter-Signature" // "sha256=" + HMAC-SHA256 en hexadecimal de "<timestamp>.<cuerpo>"
+)
+
+// ErrPrivateWebhook indica que el webhook apunta a la propia máquina o a una red privada y la
+// configuración no lo permite (notify.webhook_allow_private_networks). Es también un
+// utils.ErrPrivateAddress, el error de las conexiones rechazadas, y no se reintenta
+var ErrPrivateWebhook = fmt.Errorf("el webhook apunta a una dirección local o privada: %w", utils.ErrPrivateAddress)
+
+// WebhookChannel envía los avisos como JSON por HTTP POST a la URL configurada por el usuario. El
+// cuerpo se firma con HMAC-SHA256 usando la clave del usuario, y los errores de red, 429 y 5xx se
+// reintentan con espera exponencial
+type WebhookChannel struct {
+	client       *http.Client
+	maxRetries   int
+	retryDelay   time.Duration
+	allowPrivate bool
+}
+
+// NewWebhookChannel crea el canal de webhook a partir de la configuración de notificaciones
+func NewWebhookChannel(cfg config.NotifyConfig) *WebhookChannel {
+	allowPrivate := cfg.WebhookAllowPrivateNetworks
+	dialer := &net.Dialer{Timeout: cfg.WebhookTimeout}
+	if !allowPrivate {
+		// Se comprueba la IP a la que se conecta realmente, no solo el nombre de la URL, para que
+		// un dominio que resuelve a una IP privada no sirva para saltarse la restricción
+		dialer.Control = utils.PublicDialControl
+	}
+
+	return &WebhookChannel{
+		client: &http.Client{
+			Timeout: cfg.WebhookTimeout,
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: cfg.WebhookTimeout,
+			},
+			// Las redirecciones no se siguen: el webhook debe responder en la URL configurada
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		maxRetries:   cfg.WebhookMaxRetries,
+		retryDelay:   cfg.WebhookRetryDelay,
+		allowPrivate: allowPrivate,
+	}
+}
+
+// Name implementa Channel
+func (c *WebhookChannel) Name() string {
+	return model.ChannelWebhook
+}
+
+// webhookPayload es el cuerpo JSON de cada envío
+type webhookPayload struct {
+	Event          string          `json:"event"`
+	Delivery       string          `json:"delivery"`
+	Title          string          `json:"title"`
+	Message        string          `json:"message"`
+	Product        *webhookProduct `json:"product,omitempty"`
+	Offer          *webhookOffer   `json:"offer,omitempty"`
+	ReferencePrice float64         `json:"reference_price,omitempty"`
+	Currency       string          `json:"currency,omitempty"` // Moneda de offer.price y reference_price
+	AlertID        *uint           `json:"alert_id,omitempty"`
+	SearchID       *uint           `json:"search_id,omitempty"`
+	SearchName     string          `json:"search_name,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+type webhookProduct struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url"` // Página del producto en el comparador
+}
+
+type webhookOffer struct {
+	Price float64 `json:"price"`
+	Store string  `json:"store"`
+	URL   string  `json:"url"`
+}
+
+// Send implementa Channel
+func (c *WebhookChannel) Send(ctx context.Context, user *model.User, settings *model.NotificationChannel, msg *Message) error {
+	if settings == nil || settings.WebhookURL == "" {
+		return fmt.Errorf("el usuario %d no tiene webhook configurado", user.ID)
+	}
+	if err := ValidateWebhookURL(settings.WebhookURL, c.allowPrivate); err != nil {
+		return err
+	}
+
+	delivery, err := randomHex(16)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(newWebhookPayload(msg, delivery))
+	if err != nil {
+		return fmt.Errorf("error al generar el JSON del webhook: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.post(ctx, settings, msg.Event, delivery, body)
+		if err == nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				return nil
+			}
+		}
+
+		if !utils.ShouldRetry(resp, err) || attempt >= c.maxRetries {
+			if err != nil {
+				return fmt.Errorf("error al enviar al webhook: %w", err)
+			}
+			return fmt.Errorf("el webhook respondió %d", resp.StatusCode)
+		}
+
+		delay := utils.RetryBackoff(c.retryDelay, attempt, resp)
+		if err != nil {
+			log.Printf("[WEBHOOK] Error en el envío %s al usuario %d: %v. Reintento %d/%d en %s", delivery, user.ID, err, attempt+1, c.maxRetries, delay)
+		} else {
+			log.Printf("[WEBHOOK] El envío %s al usuario %d respondió %d. Reintento %d/%d en %s", delivery, user.ID, resp.StatusCode, attempt+1, c.maxRetries, delay)
+		}
+		if err := utils.SleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// post hace un intento de envío con las cabeceras y la firma
+func (c *WebhookChannel) post(ctx context.Context, settings *model.NotificationChannel, event, delivery string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, settings.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", config.Config.App.Name+" webhook")
+	req.Header.Set(HeaderEvent, event)
+	req.Header.Set(HeaderDelivery, delivery)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(settings.WebhookSecret, timestamp, body))
+
+	return c.client.Do(req)
+}
+
+// newWebhookPayload construye el cuerpo del envío a partir del aviso
+func newWebhookPayload(msg *Message, delivery string) webhookPayload {
+	payload := webhookPayload{
+		Event:          msg.Event,
+		Delivery:       delivery,
+		Title:          msg.Title,
+		Message:        msg.Body,
+		ReferencePrice: msg.ReferencePrice,
+		Currency:       msg.Currency,
+		AlertID:        msg.AlertID,
+		SearchID:       msg.SearchID,
+		SearchName:     msg.SearchName,
+		CreatedAt:      msg.CreatedAt,
+	}
+	if msg.ProductID != 0 {
+		payload.Product = &webhookProduct{
+			ID:   msg.ProductID,
+			Name: msg.ProductName,
+			URL:  fmt.Sprintf("%s/producto/%d", config.Config.App.URL, msg.ProductID),
+		}
+	}
+	if msg.Store != "" || msg.OfferURL != "" {
+		payload.Offer = &webhookOffer{Price: msg.Price, Store: msg.Store, URL: msg.OfferURL}
+	}
+	return payload
+}
+
+// Sign calcula la firma de un envío: HMAC-SHA256 en hexadecimal de "<timestamp>.<cuerpo>" con la
+// clave del usuario. El receptor la recalcula para comprobar que el aviso viene del comparador y
+// descarta los envíos con una marca de tiempo antigua
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// GenerateSecret genera una clave aleatoria para firmar los envíos al webhook
+func GenerateSecret() (string, error) {
+	return randomHex(32)
+}
+
+// ValidateWebhookURL comprueba que la URL del webhook es http(s) y, si allowPrivate es false, que
+// no apunta a la propia máquina ni a una red privada
+func ValidateWebhookURL(rawURL string, allowPrivate bool) error {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return fmt.Errorf("la URL del webhook debe ser http(s): %s", rawURL)
+	}
+	if allowPrivate {
+		return nil
+	}
+
+	if utils.IsLocalHost(parsed.Hostname()) {
+		return ErrPrivateWebhook
+	}
+	return nil
+}
+
+// randomHex devuelve n bytes aleatorios en hexadecimal
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error al generar datos aleatorios: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
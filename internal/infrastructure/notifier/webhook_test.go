@@ -0,0 +1,84 @@
+package notifier
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"app/internal/domain/model"
+	"app/pkg/config"
+)
+
+func TestSign(t *testing.T) {
+	const (
+		secret    = "whsec_test"
+		timestamp = "1700000000"
+	)
+	body := []byte(`{"event":"test"}`)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      []byte
+		want      string
+	}{
+		{"vector conocido", secret, timestamp, body, "21d2d3606ebbdbf9307ee15e83085df2b83c83dd87cc2e6d2ea6b1cb61afdc3c"},
+		{"clave y cuerpo vacíos", "", "0", nil, "b849d5a581847b281957065739df36df2463d1977ea8d6e1e4e6cf33fadc68c3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, tt.timestamp, tt.body); got != tt.want {
+				t.Errorf("Sign() = %s, se esperaba %s", got, tt.want)
+			}
+		})
+	}
+
+	// Cambiar la clave, la marca de tiempo o el cuerpo cambia la firma
+	signature := Sign(secret, timestamp, body)
+	for name, other := range map[string]string{
+		"otra clave":          Sign("whsec_other", timestamp, body),
+		"otra marca temporal": Sign(secret, "1700000001", body),
+		"otro cuerpo":         Sign(secret, timestamp, []byte(`{"event":"price_alert"}`)),
+		"separador movido":    Sign(secret, timestamp+".", body[1:]),
+	} {
+		if other == signature {
+			t.Errorf("%s: la firma no debería coincidir", name)
+		}
+	}
+}
+
+func TestWebhookSignatureHeader(t *testing.T) {
+	const secret = "whsec_test"
+
+	// post lee el nombre de la aplicación de la configuración global
+	previous := config.Config
+	config.Config = &config.Configuration{}
+	defer func() { config.Config = previous }()
+
+	received := make(chan bool, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		want := "sha256=" + Sign(secret, r.Header.Get(HeaderTimestamp), body)
+		received <- r.Header.Get(HeaderSignature) == want
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	channel := NewWebhookChannel(config.NotifyConfig{
+		WebhookTimeout:              5 * time.Second,
+		WebhookAllowPrivateNetworks: true,
+	})
+	settings := &model.NotificationChannel{WebhookURL: server.URL, WebhookSecret: secret}
+	msg := &Message{Event: "test", Title: "Prueba", CreatedAt: time.Now()}
+
+	if err := channel.Send(context.Background(), &model.User{ID: 1}, settings, msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if !<-received {
+		t.Error("la cabecera de firma no coincide con Sign del cuerpo recibido")
+	}
+}
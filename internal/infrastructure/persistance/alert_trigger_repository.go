@@ -0,0 +1,40 @@
+package persistance
+
+import (
+	"context"
+
+	"app/internal/domain/model"
+	"app/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+// alertTriggerRepository implementa la interfaz AlertTriggerRepository
+type alertTriggerRepository struct {
+	db *gorm.DB
+}
+
+// NewAlertTriggerRepository crea una nueva instancia del repositorio de disparos de alertas
+func NewAlertTriggerRepository(db *gorm.DB) repositories.AlertTriggerRepository {
+	return &alertTriggerRepository{
+		db: db,
+	}
+}
+
+// Create registra un disparo de una alerta
+func (r *alertTriggerRepository) Create(ctx context.Context, trigger *model.AlertTrigger) error {
+	return r.db.WithContext(ctx).Create(trigger).Error
+}
+
+// FindByAlertID devuelve los últimos disparos de una alerta, del más reciente al más antiguo
+func (r *alertTriggerRepository) FindByAlertID(ctx context.Context, alertID uint, limit int) ([]*model.AlertTrigger, error) {
+	var triggers []*model.AlertTrigger
+	if err := r.db.WithContext(ctx).
+		Where("alert_id = ?", alertID).
+		Order("triggered_at DESC").
+		Limit(limit).
+		Find(&triggers).Error; err != nil {
+		return nil, err
+	}
+	return triggers, nil
+}
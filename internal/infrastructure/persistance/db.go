@@ -67,9 +67,18 @@ func (d *Database) AutoMigrate() error {
 		&model.User{},
 		&model.Category{},
 		&model.Product{},
+		&model.ProductIdentifier{},
 		&model.Price{},
+		&model.PriceObservation{},
+		&model.ExchangeRate{},
+		&model.ScrapeRun{},
 		&model.PriceAlert{},
+		&model.AlertTrigger{},
+		&model.SavedSearch{},
+		&model.SavedSearchMatch{},
 		&model.Notification{},
+		&model.NotificationChannel{},
+		&model.OutboxEmail{},
 		&model.Watchlist{},
 		&model.WatchlistItem{},
 	); err != nil {
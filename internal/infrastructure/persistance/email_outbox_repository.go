@@ -0,0 +1,97 @@
+package persistance
+
+import (
+	"context"
+	"time"
+
+	"app/internal/domain/model"
+	"app/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+// emailOutboxRepository implementa la interfaz EmailOutboxRepository
+type emailOutboxRepository struct {
+	db *gorm.DB
+}
+
+// NewEmailOutboxRepository crea una nueva instancia del repositorio de la bandeja de salida
+func NewEmailOutboxRepository(db *gorm.DB) repositories.EmailOutboxRepository {
+	return &emailOutboxRepository{
+		db: db,
+	}
+}
+
+// Create guarda un correo pendiente de enviar
+func (r *emailOutboxRepository) Create(ctx context.Context, email *model.OutboxEmail) error {
+	return r.db.WithContext(ctx).Create(email).Error
+}
+
+// Update guarda el resultado de un intento de envío
+func (r *emailOutboxRepository) Update(ctx context.Context, email *model.OutboxEmail) error {
+	return r.db.WithContext(ctx).Save(email).Error
+}
+
+// FindByID busca un correo por su ID
+func (r *emailOutboxRepository) FindByID(ctx context.Context, id uint) (*model.OutboxEmail, error) {
+	var email model.OutboxEmail
+	if err := r.db.WithContext(ctx).First(&email, id).Error; err != nil {
+		return nil, err
+	}
+	return &email, nil
+}
+
+// FindDue devuelve los correos pendientes cuyo siguiente intento ya ha llegado, los más antiguos primero
+func (r *emailOutboxRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]*model.OutboxEmail, error) {
+	var emails []*model.OutboxEmail
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", model.OutboxPending, now).
+		Order("next_attempt_at ASC, id ASC").
+		Limit(limit).
+		Find(&emails).Error; err != nil {
+		return nil, err
+	}
+	return emails, nil
+}
+
+// FindByStatus devuelve los últimos correos con un estado, los más recientes primero
+func (r *emailOutboxRepository) FindByStatus(ctx context.Context, status string, limit int) ([]*model.OutboxEmail, error) {
+	var emails []*model.OutboxEmail
+	if err := r.db.WithContext(ctx).
+		Where("status = ?", status).
+		Order("updated_at DESC, id DESC").
+		Limit(limit).
+		Find(&emails).Error; err != nil {
+		return nil, err
+	}
+	return emails, nil
+}
+
+// CountByStatus cuenta los correos de cada estado
+func (r *emailOutboxRepository) CountByStatus(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	if err := r.db.WithContext(ctx).
+		Model(&model.OutboxEmail{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// DeleteSentBefore elimina los correos enviados antes de la fecha
+func (r *emailOutboxRepository) DeleteSentBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("status = ? AND sent_at < ?", model.OutboxSent, before).
+		Delete(&model.OutboxEmail{})
+	return result.RowsAffected, result.Error
+}
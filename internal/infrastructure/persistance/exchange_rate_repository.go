@@ -0,0 +1,64 @@
+package persistance
+
+import (
+	"context"
+	"time"
+
+	"app/internal/domain/model"
+	"app/internal/domain/repositories"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// exchangeRateRepository implementa la interfaz ExchangeRateRepository
+type exchangeRateRepository struct {
+	db *gorm.DB
+}
+
+// NewExchangeRateRepository crea una nueva instancia del repositorio de tipos de cambio
+func NewExchangeRateRepository(db *gorm.DB) repositories.ExchangeRateRepository {
+	return &exchangeRateRepository{
+		db: db,
+	}
+}
+
+// Save crea el tipo de cambio o actualiza el existente para la misma moneda y fecha
+func (r *exchangeRateRepository) Save(ctx context.Context, rate *model.ExchangeRate) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "currency"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "updated_at"}),
+	}).Create(rate).Error
+}
+
+// FindLatest devuelve el tipo de cambio más reciente de cada moneda que ya aplica. Los cargados con
+// una fecha futura se ignoran hasta ese día
+func (r *exchangeRateRepository) FindLatest(ctx context.Context) ([]*model.ExchangeRate, error) {
+	latest := r.db.WithContext(ctx).
+		Model(&model.ExchangeRate{}).
+		Select("currency, MAX(date) AS date").
+		Where("date <= ?", time.Now()).
+		Group("currency")
+
+	var rates []*model.ExchangeRate
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN (?) AS latest ON exchange_rates.currency = latest.currency AND exchange_rates.date = latest.date", latest).
+		Order("exchange_rates.currency asc").
+		Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// FindByCurrency devuelve los últimos tipos de cambio de una moneda
+func (r *exchangeRateRepository) FindByCurrency(ctx context.Context, currency string, limit int) ([]*model.ExchangeRate, error) {
+	var rates []*model.ExchangeRate
+	if err := r.db.WithContext(ctx).
+		Where("currency = ?", currency).
+		Order("date desc").
+		Limit(limit).
+		Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
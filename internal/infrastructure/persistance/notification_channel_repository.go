@@ -0,0 +1,57 @@
+package persistance
+
+import (
+	"context"
+	"errors"
+
+	"app/internal/domain/model"
+	"app/internal/domain/repositories"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// notificationChannelRepository implementa la interfaz NotificationChannelRepository
+type notificationChannelRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationChannelRepository crea una nueva instancia del repositorio de canales de notificación
+func NewNotificationChannelRepository(db *gorm.DB) repositories.NotificationChannelRepository {
+	return &notificationChannelRepository{
+		db: db,
+	}
+}
+
+// FindByUserID devuelve las preferencias de canal guardadas por un usuario
+func (r *notificationChannelRepository) FindByUserID(ctx context.Context, userID uint) ([]*model.NotificationChannel, error) {
+	var channels []*model.NotificationChannel
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Find(&channels).Error; err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+// FindByUserAndChannel devuelve la preferencia de un usuario para un canal, o nil si no existe
+func (r *notificationChannelRepository) FindByUserAndChannel(ctx context.Context, userID uint, channel string) (*model.NotificationChannel, error) {
+	var preference model.NotificationChannel
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND channel = ?", userID, channel).
+		First(&preference).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &preference, nil
+}
+
+// Save crea la preferencia o, si el usuario ya la tenía para ese canal, la actualiza
+func (r *notificationChannelRepository) Save(ctx context.Context, channel *model.NotificationChannel) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "webhook_url", "webhook_secret", "updated_at"}),
+	}).Create(channel).Error
+}
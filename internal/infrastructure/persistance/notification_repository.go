@@ -110,6 +110,33 @@ func (r *notificationRepository) DeleteOldNotifications(ctx context.Context, old
 		Delete(&model.Notification{}).Error
 }
 
+// FindDigestPendingByUserID busca las notificaciones de un usuario pendientes de su próximo resumen
+func (r *notificationRepository) FindDigestPendingByUserID(ctx context.Context, userID uint) ([]*model.Notification, error) {
+	var notifications []*model.Notification
+	result := r.db.WithContext(ctx).
+		Where("user_id = ? AND digest_pending = ?", userID, true).
+		Order("created_at ASC").
+		Find(&notifications)
+	return notifications, result.Error
+}
+
+// ClearDigestPending marca las notificaciones indicadas como ya incluidas en un resumen
+func (r *notificationRepository) ClearDigestPending(ctx context.Context, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&model.Notification{}).
+		Where("id IN ?", ids).
+		Update("digest_pending", false).Error
+}
+
+// ClearDigestPendingByUserID descarta del resumen todas las notificaciones pendientes de un usuario
+func (r *notificationRepository) ClearDigestPendingByUserID(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Model(&model.Notification{}).
+		Where("user_id = ? AND digest_pending = ?", userID, true).
+		Update("digest_pending", false).Error
+}
+
 // FindUnreadByUserID busca todas las notificaciones no leídas de un usuario
 func (r *notificationRepository) FindUnreadByUserID(ctx context.Context, userID uint) ([]*model.Notification, error) {
 	var notifications []*model.Notification
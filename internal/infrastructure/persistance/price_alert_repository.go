@@ -52,11 +52,11 @@ func (r *priceAlertRepository) FindByID(ctx context.Context, alertID uint) (*mod
 	return &alert, nil
 }
 
-// FindByUserID busca alertas de precio por ID de usuario
+// FindByUserID busca alertas de precio por ID de usuario, activas o no
 func (r *priceAlertRepository) FindByUserID(ctx context.Context, userID uint) ([]*model.PriceAlert, error) {
 	var alerts []*model.PriceAlert
 	if err := r.db.WithContext(ctx).
-		Where("user_id = ? AND is_active = ?", userID, true).
+		Where("user_id = ?", userID).
 		Preload("Product").
 		Find(&alerts).Error; err != nil {
 		return nil, err
@@ -89,3 +89,34 @@ func (r *priceAlertRepository) FindActiveAlertsForPrice(ctx context.Context, pro
 	}
 	return alerts, nil
 }
+
+// FindActiveProductIDs busca, ordenados, los IDs de los productos que tienen al menos una alerta
+// activa. Devuelve los posteriores a afterID y como máximo limit (todos si limit <= 0)
+func (r *priceAlertRepository) FindActiveProductIDs(ctx context.Context, afterID uint, limit int) ([]uint, error) {
+	query := r.db.WithContext(ctx).
+		Model(&model.PriceAlert{}).
+		Where("is_active = ? AND product_id > ?", true, afterID).
+		Distinct().
+		Order("product_id")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var productIDs []uint
+	if err := query.Pluck("product_id", &productIDs).Error; err != nil {
+		return nil, err
+	}
+	return productIDs, nil
+}
+
+// UpdateRuleState guarda solo el estado de la regla de una alerta, sin pisar el resto de columnas
+// (por ejemplo, el último disparo registrado por otra evaluación)
+func (r *priceAlertRepository) UpdateRuleState(ctx context.Context, alertID uint, baselinePrice float64, lastAvailable bool) error {
+	return r.db.WithContext(ctx).
+		Model(&model.PriceAlert{}).
+		Where("id = ?", alertID).
+		Updates(map[string]interface{}{
+			"baseline_price": baselinePrice,
+			"last_available": lastAvailable,
+		}).Error
+}
@@ -0,0 +1,84 @@
+package persistance
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"app/internal/domain/model"
+	"app/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+// priceHistoryRepository implementa la interfaz PriceHistoryRepository
+type priceHistoryRepository struct {
+	db *gorm.DB
+}
+
+// NewPriceHistoryRepository crea una nueva instancia del repositorio de histórico de precios
+func NewPriceHistoryRepository(db *gorm.DB) repositories.PriceHistoryRepository {
+	return &priceHistoryRepository{
+		db: db,
+	}
+}
+
+// Create registra una nueva observación de precio
+func (r *priceHistoryRepository) Create(ctx context.Context, observation *model.PriceObservation) error {
+	return r.db.WithContext(ctx).Create(observation).Error
+}
+
+// FindByProductID busca las observaciones de un producto entre dos fechas
+func (r *priceHistoryRepository) FindByProductID(ctx context.Context, productID uint, from, to time.Time) ([]*model.PriceObservation, error) {
+	var observations []*model.PriceObservation
+	if err := r.db.WithContext(ctx).
+		Where("product_id = ? AND observed_at BETWEEN ? AND ?", productID, from, to).
+		Order("observed_at asc").
+		Find(&observations).Error; err != nil {
+		return nil, err
+	}
+	return observations, nil
+}
+
+// FindByProductAndStore busca las observaciones de un producto en una tienda entre dos fechas
+func (r *priceHistoryRepository) FindByProductAndStore(ctx context.Context, productID uint, store string, from, to time.Time) ([]*model.PriceObservation, error) {
+	var observations []*model.PriceObservation
+	if err := r.db.WithContext(ctx).
+		Where("product_id = ? AND store = ? AND observed_at BETWEEN ? AND ?", productID, store, from, to).
+		Order("observed_at asc").
+		Find(&observations).Error; err != nil {
+		return nil, err
+	}
+	return observations, nil
+}
+
+// FindLatestByProductAndStore devuelve la última observación de un producto en una tienda
+func (r *priceHistoryRepository) FindLatestByProductAndStore(ctx context.Context, productID uint, store string) (*model.PriceObservation, error) {
+	var observation model.PriceObservation
+	err := r.db.WithContext(ctx).
+		Where("product_id = ? AND store = ?", productID, store).
+		Order("observed_at desc").
+		First(&observation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &observation, nil
+}
+
+// FindPriceStats calcula el mínimo y la media de los precios normalizados de las ofertas disponibles
+// de un producto observadas desde from (incluida) hasta to (excluida)
+func (r *priceHistoryRepository) FindPriceStats(ctx context.Context, productID uint, from, to time.Time) (*model.PriceStats, error) {
+	var stats model.PriceStats
+	if err := r.db.WithContext(ctx).
+		Model(&model.PriceObservation{}).
+		Select("COALESCE(MIN(normalized_price), 0) AS lowest, COALESCE(AVG(normalized_price), 0) AS average, COUNT(*) AS count").
+		Where("product_id = ? AND is_available = ? AND normalized_price > 0", productID, true).
+		Where("observed_at >= ? AND observed_at < ?", from, to).
+		Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
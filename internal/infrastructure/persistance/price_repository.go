@@ -49,8 +49,8 @@ func (r *priceRepository) FindByProductID(ctx context.Context, productID uint) (
 	return prices, nil
 }
 
-// FindBestPriceByProductID busca el mejor precio para un producto
-func (r *priceRepository) FindBestPriceByProductID(ctx context.Context, productID uint) (*model.Price, error) {
+// FindBestPriceByProductID busca el mejor precio para un producto entre las ofertas que pasan el filtro
+func (r *priceRepository) FindBestPriceByProductID(ctx context.Context, productID uint, filter model.OfferFilter) (*model.Price, error) {
 	var price model.Price
 
 	// Verificar si el contexto ya está cancelado antes de iniciar la consulta
@@ -60,9 +60,10 @@ func (r *priceRepository) FindBestPriceByProductID(ctx context.Context, productI
 		return nil, nil
 	}
 
-	err := r.db.WithContext(ctx).
-		Where("product_id = ? AND is_available = ?", productID, true).
-		Order("price asc").
+	query := r.db.WithContext(ctx).
+		Where("product_id = ? AND is_available = ?", productID, true)
+	err := applyOfferFilter(query, filter).
+		Order(filter.ComparisonColumn() + " asc").
 		Limit(1).
 		First(&price).Error
 
@@ -81,8 +82,20 @@ func (r *priceRepository) FindBestPriceByProductID(ctx context.Context, productI
 	return &price, nil
 }
 
-// FindTopOffersByProductID busca las mejores ofertas para un producto
-func (r *priceRepository) FindTopOffersByProductID(ctx context.Context, productID uint, limit int) ([]*model.Price, error) {
+// applyOfferFilter añade a una consulta sobre prices las condiciones del filtro de ofertas
+// (ver model.OfferFilter.Matches)
+func applyOfferFilter(query *gorm.DB, filter model.OfferFilter) *gorm.DB {
+	if filter.NewOnly {
+		query = query.Where("(`condition` = '' OR `condition` IS NULL OR `condition` = ?)", model.ConditionNew)
+	}
+	if filter.ExcludeAuctions {
+		query = query.Where("(listing_type IS NULL OR listing_type <> ?)", model.ListingTypeAuction)
+	}
+	return query
+}
+
+// FindTopOffersByProductID busca las mejores ofertas para un producto entre las que pasan el filtro
+func (r *priceRepository) FindTopOffersByProductID(ctx context.Context, productID uint, limit int, filter model.OfferFilter) ([]*model.Price, error) {
 	var prices []*model.Price
 
 	// Verificar si el contexto ya está cancelado antes de iniciar la consulta
@@ -90,9 +103,10 @@ func (r *priceRepository) FindTopOffersByProductID(ctx context.Context, productI
 		return nil, nil
 	}
 
-	err := r.db.WithContext(ctx).
-		Where("product_id = ? AND is_available = ?", productID, true).
-		Order("price asc").
+	query := r.db.WithContext(ctx).
+		Where("product_id = ? AND is_available = ?", productID, true)
+	err := applyOfferFilter(query, filter).
+		Order(filter.ComparisonColumn() + " asc").
 		Limit(limit).
 		Find(&prices).Error
 
@@ -122,3 +136,43 @@ func (r *priceRepository) DeleteOldPrices(ctx context.Context, olderThan time.Ti
 	result := r.db.WithContext(ctx).Where("retrieved_at < ?", olderThan).Delete(&model.Price{})
 	return int(result.RowsAffected), result.Error
 }
+
+// landedCostExpr calcula en SQL el coste total de una oferta (ver model.Price.LandedCost)
+const landedCostExpr = "(price + COALESCE(shipping_cost, 0) + COALESCE(import_charges, 0))"
+
+// NormalizePrices recalcula normalized_price y normalized_total con el factor de conversión de cada moneda
+func (r *priceRepository) NormalizePrices(ctx context.Context, factors map[string]float64) (int, error) {
+	updated := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		currencies := make([]string, 0, len(factors))
+		for currency, factor := range factors {
+			currencies = append(currencies, currency)
+			result := tx.Model(&model.Price{}).
+				Where("currency = ?", currency).
+				Updates(map[string]interface{}{
+					"normalized_price": gorm.Expr("price * ?", factor),
+					"normalized_total": gorm.Expr(landedCostExpr+" * ?", factor),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			updated += int(result.RowsAffected)
+		}
+
+		// Monedas sin tipo de cambio: se usa el precio original
+		query := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Model(&model.Price{})
+		if len(currencies) > 0 {
+			query = query.Where("currency NOT IN ?", currencies)
+		}
+		result := query.Updates(map[string]interface{}{
+			"normalized_price": gorm.Expr("price"),
+			"normalized_total": gorm.Expr(landedCostExpr),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		updated += int(result.RowsAffected)
+		return nil
+	})
+	return updated, err
+}
@@ -0,0 +1,77 @@
+package persistance
+
+import (
+	"context"
+
+	"app/internal/domain/model"
+	"app/internal/domain/repositories"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// productIdentifierRepository implementa la interfaz ProductIdentifierRepository
+type productIdentifierRepository struct {
+	db *gorm.DB
+}
+
+// NewProductIdentifierRepository crea una nueva instancia del repositorio de identificadores de producto
+func NewProductIdentifierRepository(db *gorm.DB) repositories.ProductIdentifierRepository {
+	return &productIdentifierRepository{
+		db: db,
+	}
+}
+
+// Save asocia los identificadores al producto. Si un identificador ya existe (de este producto
+// o de otro) se deja como estaba
+func (r *productIdentifierRepository) Save(ctx context.Context, productID uint, identifiers []model.ProductIdentifier) error {
+	if len(identifiers) == 0 {
+		return nil
+	}
+
+	rows := make([]model.ProductIdentifier, len(identifiers))
+	for i, identifier := range identifiers {
+		rows[i] = model.ProductIdentifier{
+			ProductID: productID,
+			Type:      identifier.Type,
+			Value:     identifier.Value,
+			Source:    identifier.Source,
+		}
+	}
+
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&rows).Error
+}
+
+// FindMatching devuelve los identificadores guardados que coinciden con alguno de los dados
+func (r *productIdentifierRepository) FindMatching(ctx context.Context, identifiers []model.ProductIdentifier) ([]*model.ProductIdentifier, error) {
+	if len(identifiers) == 0 {
+		return nil, nil
+	}
+
+	pairs := make([][]interface{}, len(identifiers))
+	for i, identifier := range identifiers {
+		pairs[i] = []interface{}{identifier.Type, identifier.Value}
+	}
+
+	var found []*model.ProductIdentifier
+	if err := r.db.WithContext(ctx).
+		Where("(type, value) IN ?", pairs).
+		Find(&found).Error; err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// FindByProductID devuelve los identificadores de un producto
+func (r *productIdentifierRepository) FindByProductID(ctx context.Context, productID uint) ([]*model.ProductIdentifier, error) {
+	var identifiers []*model.ProductIdentifier
+	if err := r.db.WithContext(ctx).
+		Where("product_id = ?", productID).
+		Order("type asc, value asc").
+		Find(&identifiers).Error; err != nil {
+		return nil, err
+	}
+	return identifiers, nil
+}
@@ -35,7 +35,7 @@ func (r *productRepository) FindByID(ctx context.Context, id uint) (*model.Produ
 	var product model.Product
 	if err := r.db.WithContext(ctx).Preload("Category").First(&product, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("producto no encontrado")
+			return nil, repositories.ErrProductNotFound
 		}
 		return nil, err
 	}
@@ -48,7 +48,7 @@ func (r *productRepository) FindBySlug(ctx context.Context, slug string) (*model
 	// Asegurarnos de cargar todos los campos incluyendo ImageHash
 	if err := r.db.WithContext(ctx).Preload("Category").Where("slug = ?", slug).First(&product).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("producto no encontrado")
+			return nil, repositories.ErrProductNotFound
 		}
 		return nil, err
 	}
@@ -85,8 +85,8 @@ func (r *productRepository) FindByCategory(ctx context.Context, categoryID uint,
 	return products, nil
 }
 
-// FindBestDeals obtiene los productos con mejores ofertas
-func (r *productRepository) FindBestDeals(ctx context.Context, limit int) ([]*model.Product, error) {
+// FindBestDeals obtiene los productos con mejores ofertas entre las que pasan el filtro
+func (r *productRepository) FindBestDeals(ctx context.Context, limit int, filter model.OfferFilter) ([]*model.Product, error) {
 	// Esta consulta es más compleja, necesitamos encontrar productos con los precios más bajos
 	// Usamos un subquery para encontrar el precio mínimo por producto
 	var products []*model.Product
@@ -101,8 +101,9 @@ func (r *productRepository) FindBestDeals(ctx context.Context, limit int) ([]*mo
 	// Subconsulta para obtener los productos con los precios más bajos
 	subQuery := r.db.WithContext(ctx).
 		Table("prices").
-		Select("product_id, MIN(price) as min_price").
-		Where("is_available = ?", true).
+		Select("product_id, MIN("+filter.ComparisonColumn()+") as min_price").
+		Where("is_available = ?", true)
+	subQuery = applyOfferFilter(subQuery, filter).
 		Group("product_id").
 		Order("min_price asc").
 		Limit(limit)
@@ -213,12 +214,13 @@ func (r *productRepository) FindFilteredProductsByCategory(ctx context.Context,
 	// Modificamos para no seleccionar 'store' directamente en la subconsulta, ya que causa problemas con GROUP BY
 	subQuery := r.db.WithContext(ctx).
 		Table("prices").
-		Select("product_id, MIN(price) as min_price").
+		Select("product_id, MIN(" + options.Offers.ComparisonColumn() + ") as min_price").
 		Group("product_id")
 
 	if options.StoreFilter != "" {
 		subQuery = subQuery.Where("store = ?", options.StoreFilter)
 	}
+	subQuery = applyOfferFilter(subQuery, options.Offers)
 
 	subQuery = subQuery.Order("min_price " + options.SortOrder)
 
@@ -265,6 +267,12 @@ func (r *productRepository) FindFilteredProductsByCategory(ctx context.Context,
 	if options.StoreFilter != "" {
 		queryDescription += fmt.Sprintf(", tienda='%s'", options.StoreFilter)
 	}
+	if options.Offers.NewOnly {
+		queryDescription += ", solo nuevos"
+	}
+	if options.Offers.ExcludeAuctions {
+		queryDescription += ", sin subastas"
+	}
 	queryDescription += fmt.Sprintf(", ordenado por precio %s", options.SortOrder)
 	queryDescription += fmt.Sprintf(", límite=%d, offset=%d", options.Limit, options.Offset)
 
@@ -294,12 +302,13 @@ func (r *productRepository) CountFilteredProductsByCategory(ctx context.Context,
 	// Modificamos para no seleccionar 'store' directamente en la subconsulta, ya que causa problemas con GROUP BY
 	subQuery := r.db.WithContext(ctx).
 		Table("prices").
-		Select("product_id, MIN(price) as min_price").
+		Select("product_id, MIN(" + options.Offers.ComparisonColumn() + ") as min_price").
 		Group("product_id")
 
 	if options.StoreFilter != "" {
 		subQuery = subQuery.Where("store = ?", options.StoreFilter)
 	}
+	subQuery = applyOfferFilter(subQuery, options.Offers)
 
 	// Consulta principal uniendo productos con la subconsulta de mejores precios
 	query := r.db.WithContext(ctx).
@@ -328,6 +337,12 @@ func (r *productRepository) CountFilteredProductsByCategory(ctx context.Context,
 	if options.StoreFilter != "" {
 		queryDescription += fmt.Sprintf(", tienda='%s'", options.StoreFilter)
 	}
+	if options.Offers.NewOnly {
+		queryDescription += ", solo nuevos"
+	}
+	if options.Offers.ExcludeAuctions {
+		queryDescription += ", sin subastas"
+	}
 
 	log.Printf("[SQL_DEBUG] Consulta SQL de conteo: %s", queryDescription)
 
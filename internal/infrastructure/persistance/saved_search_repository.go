@@ -0,0 +1,125 @@
+package persistance
+
+import (
+	"context"
+	"errors"
+
+	"app/internal/domain/model"
+	"app/internal/domain/repositories"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// savedSearchRepository implementa la interfaz SavedSearchRepository
+type savedSearchRepository struct {
+	db *gorm.DB
+}
+
+// NewSavedSearchRepository crea una nueva instancia del repositorio de búsquedas guardadas
+func NewSavedSearchRepository(db *gorm.DB) repositories.SavedSearchRepository {
+	return &savedSearchRepository{
+		db: db,
+	}
+}
+
+// Create crea una búsqueda guardada
+func (r *savedSearchRepository) Create(ctx context.Context, search *model.SavedSearch) error {
+	return r.db.WithContext(ctx).Omit("Category").Create(search).Error
+}
+
+// Update actualiza una búsqueda guardada
+func (r *savedSearchRepository) Update(ctx context.Context, search *model.SavedSearch) error {
+	return r.db.WithContext(ctx).Omit("Category").Save(search).Error
+}
+
+// Delete elimina una búsqueda guardada. Sus coincidencias se borran en cascada y sus
+// notificaciones se conservan sin la referencia a la búsqueda
+func (r *savedSearchRepository) Delete(ctx context.Context, searchID uint) error {
+	return r.db.WithContext(ctx).Delete(&model.SavedSearch{}, searchID).Error
+}
+
+// FindByID busca una búsqueda guardada por su ID
+func (r *savedSearchRepository) FindByID(ctx context.Context, searchID uint) (*model.SavedSearch, error) {
+	var search model.SavedSearch
+	if err := r.db.WithContext(ctx).
+		Preload("Category").
+		First(&search, searchID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("búsqueda guardada no encontrada")
+		}
+		return nil, err
+	}
+	return &search, nil
+}
+
+// FindByUserID devuelve las búsquedas guardadas de un usuario, de la más reciente a la más antigua
+func (r *savedSearchRepository) FindByUserID(ctx context.Context, userID uint) ([]*model.SavedSearch, error) {
+	var searches []*model.SavedSearch
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Preload("Category").
+		Order("created_at DESC").
+		Find(&searches).Error; err != nil {
+		return nil, err
+	}
+	return searches, nil
+}
+
+// FindActiveForCategory devuelve las búsquedas activas de la categoría y las de cualquier categoría
+func (r *savedSearchRepository) FindActiveForCategory(ctx context.Context, categoryID uint) ([]*model.SavedSearch, error) {
+	var searches []*model.SavedSearch
+	if err := r.db.WithContext(ctx).
+		Where("is_active = ? AND (category_id = ? OR category_id IS NULL)", true, categoryID).
+		Preload("Category").
+		Find(&searches).Error; err != nil {
+		return nil, err
+	}
+	return searches, nil
+}
+
+// FindCandidateProducts devuelve una página de productos de la categoría (o de todas) ordenados
+// por ID. Solo carga las columnas necesarias para comparar el nombre con la búsqueda
+func (r *savedSearchRepository) FindCandidateProducts(ctx context.Context, categoryID *uint, afterID uint, limit int) ([]*model.Product, error) {
+	query := r.db.WithContext(ctx).
+		Model(&model.Product{}).
+		Select("id, name, category_id").
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit)
+	if categoryID != nil {
+		query = query.Where("category_id = ?", *categoryID)
+	}
+
+	var products []*model.Product
+	if err := query.Find(&products).Error; err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// CreateMatch registra la coincidencia si el producto no había cumplido antes la búsqueda. El
+// índice único (search_id, product_id) evita avisar dos veces aunque se evalúe a la vez
+func (r *savedSearchRepository) CreateMatch(ctx context.Context, match *model.SavedSearchMatch) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(match)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FindMatchesBySearchID devuelve las últimas coincidencias de una búsqueda, de la más reciente a la más antigua
+func (r *savedSearchRepository) FindMatchesBySearchID(ctx context.Context, searchID uint, limit int) ([]*model.SavedSearchMatch, error) {
+	var matches []*model.SavedSearchMatch
+	if err := r.db.WithContext(ctx).
+		Where("search_id = ?", searchID).
+		Preload("Product").
+		Order("matched_at DESC").
+		Limit(limit).
+		Find(&matches).Error; err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
@@ -0,0 +1,58 @@
+package persistance
+
+import (
+	"context"
+	"time"
+
+	"app/internal/domain/model"
+	"app/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+// scrapeRunRepository implementa la interfaz ScrapeRunRepository
+type scrapeRunRepository struct {
+	db *gorm.DB
+}
+
+// NewScrapeRunRepository crea una nueva instancia del repositorio de ejecuciones del scraper
+func NewScrapeRunRepository(db *gorm.DB) repositories.ScrapeRunRepository {
+	return &scrapeRunRepository{
+		db: db,
+	}
+}
+
+// Create registra una nueva ejecución
+func (r *scrapeRunRepository) Create(ctx context.Context, run *model.ScrapeRun) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+// Update actualiza una ejecución
+func (r *scrapeRunRepository) Update(ctx context.Context, run *model.ScrapeRun) error {
+	return r.db.WithContext(ctx).Save(run).Error
+}
+
+// FindSince busca las ejecuciones iniciadas desde una fecha
+func (r *scrapeRunRepository) FindSince(ctx context.Context, since time.Time) ([]*model.ScrapeRun, error) {
+	var runs []*model.ScrapeRun
+	if err := r.db.WithContext(ctx).
+		Where("started_at >= ?", since).
+		Order("started_at desc").
+		Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// FindByStore busca las últimas ejecuciones de una tienda
+func (r *scrapeRunRepository) FindByStore(ctx context.Context, store string, limit int) ([]*model.ScrapeRun, error) {
+	var runs []*model.ScrapeRun
+	if err := r.db.WithContext(ctx).
+		Where("store = ?", store).
+		Order("started_at desc").
+		Limit(limit).
+		Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
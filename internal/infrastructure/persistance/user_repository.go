@@ -3,6 +3,7 @@ package persistance
 import (
 	"context"
 	"errors"
+	"time"
 
 	"app/internal/domain/model"
 	"app/internal/domain/repositories"
@@ -84,3 +85,19 @@ func (r *userRepository) Update(ctx context.Context, user *model.User) error {
 func (r *userRepository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Unscoped().Delete(&model.User{}, id).Error
 }
+
+// FindByDigestFrequency busca los usuarios que reciben sus avisos con la frecuencia indicada
+func (r *userRepository) FindByDigestFrequency(ctx context.Context, frequency string) ([]*model.User, error) {
+	var users []*model.User
+	if err := r.db.WithContext(ctx).Where("digest_frequency = ?", frequency).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// UpdateLastDigestAt guarda solo la fecha del último resumen enviado al usuario
+func (r *userRepository) UpdateLastDigestAt(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ?", id).
+		Update("last_digest_at", at).Error
+}
@@ -156,3 +156,23 @@ func (r *watchlistItemRepository) FindByProductID(ctx context.Context, productID
 	}
 	return items, nil
 }
+
+// FindProductIDs busca los IDs de los productos que están en la lista de seguimiento de algún usuario
+func (r *watchlistItemRepository) FindProductIDs(ctx context.Context) ([]uint, error) {
+	var productIDs []uint
+	if err := r.db.WithContext(ctx).
+		Model(&model.WatchlistItem{}).
+		Distinct().
+		Order("product_id").
+		Pluck("product_id", &productIDs).Error; err != nil {
+		return nil, err
+	}
+	return productIDs, nil
+}
+
+// UpdateDigestPrice guarda solo el mejor precio incluido en el último resumen por correo
+func (r *watchlistItemRepository) UpdateDigestPrice(ctx context.Context, itemID uint, price float64) error {
+	return r.db.WithContext(ctx).Model(&model.WatchlistItem{}).
+		Where("id = ?", itemID).
+		Update("digest_price", price).Error
+}
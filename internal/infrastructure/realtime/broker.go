@@ -0,0 +1,133 @@
+package realtime
+
+import (
+	"errors"
+	"sync"
+
+	"app/internal/domain/model"
+)
+
+// ErrTooManySubscriptions indica que el usuario ya tiene abiertas todas las conexiones permitidas
+var ErrTooManySubscriptions = errors.New("demasiadas conexiones de notificaciones abiertas")
+
+// ErrBrokerClosed indica que el broker se ha cerrado porque la aplicación se está apagando
+var ErrBrokerClosed = errors.New("el servicio de notificaciones en tiempo real está cerrado")
+
+// Broker reparte en memoria las notificaciones nuevas entre las conexiones abiertas de cada usuario
+// (una por pestaña). Solo funciona dentro del proceso: con varias instancias de la aplicación cada
+// una avisa a las pestañas conectadas a ella
+type Broker struct {
+	mu            sync.Mutex
+	subscriptions map[uint]map[*Subscription]struct{}
+	closed        bool
+
+	bufferSize int // Notificaciones que puede acumular una conexión lenta antes de perder alguna
+	maxPerUser int // Conexiones abiertas como mucho por usuario
+}
+
+// Subscription es una conexión de un usuario al broker
+type Subscription struct {
+	broker *Broker
+	userID uint
+	events chan *model.Notification
+	once   sync.Once
+}
+
+// NewBroker crea un broker que guarda hasta bufferSize notificaciones por conexión y admite como
+// mucho maxPerUser conexiones por usuario
+func NewBroker(bufferSize, maxPerUser int) *Broker {
+	if bufferSize <= 0 {
+		bufferSize = 1
+	}
+	return &Broker{
+		subscriptions: make(map[uint]map[*Subscription]struct{}),
+		bufferSize:    bufferSize,
+		maxPerUser:    maxPerUser,
+	}
+}
+
+// Subscribe abre una conexión para recibir las notificaciones nuevas del usuario. Hay que cerrarla
+// con Close al terminar
+func (b *Broker) Subscribe(userID uint) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrBrokerClosed
+	}
+	subs := b.subscriptions[userID]
+	if b.maxPerUser > 0 && len(subs) >= b.maxPerUser {
+		return nil, ErrTooManySubscriptions
+	}
+	if subs == nil {
+		subs = make(map[*Subscription]struct{})
+		b.subscriptions[userID] = subs
+	}
+
+	sub := &Subscription{
+		broker: b,
+		userID: userID,
+		events: make(chan *model.Notification, b.bufferSize),
+	}
+	subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// Publish envía la notificación a todas las conexiones abiertas de su usuario. No bloquea: si una
+// conexión no da abasto y tiene el búfer lleno, esa conexión pierde la notificación (sigue en la
+// página de notificaciones). Devuelve a cuántas conexiones se ha entregado
+func (b *Broker) Publish(notification *model.Notification) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delivered := 0
+	for sub := range b.subscriptions[notification.UserID] {
+		// Cada conexión recibe su propia copia para que nadie comparta el puntero
+		event := *notification
+		select {
+		case sub.events <- &event:
+			delivered++
+		default:
+		}
+	}
+	return delivered
+}
+
+// Close cierra todas las conexiones y rechaza las nuevas. Se llama al apagar el servidor para que
+// las conexiones abiertas terminen y no retrasen el apagado
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for _, subs := range b.subscriptions {
+		for sub := range subs {
+			sub.once.Do(func() { close(sub.events) })
+		}
+	}
+	b.subscriptions = make(map[uint]map[*Subscription]struct{})
+}
+
+// Events devuelve el canal por el que llegan las notificaciones. Se cierra al cerrar la conexión
+// o el broker
+func (s *Subscription) Events() <-chan *model.Notification {
+	return s.events
+}
+
+// Close cierra la conexión y la quita del broker. Se puede llamar más de una vez
+func (s *Subscription) Close() {
+	b := s.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if subs, ok := b.subscriptions[s.userID]; ok {
+		delete(subs, s)
+		if len(subs) == 0 {
+			delete(b.subscriptions, s.userID)
+		}
+	}
+	s.once.Do(func() { close(s.events) })
+}
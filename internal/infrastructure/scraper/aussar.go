@@ -1,13 +1,16 @@
 package scraper
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"regexp"
 	"strings"
 	"time"
 
 	"app/internal/domain/model"
+	"app/pkg/config"
 	"app/pkg/utils"
 
 	"github.com/gocolly/colly/v2"
@@ -15,16 +18,59 @@ import (
 
 // AussarScraper implementa el scraping para Aussar
 type AussarScraper struct {
-	BaseURL string
+	StoreName  string // Nombre de la tienda en los precios (sección "stores" o el de por defecto)
+	BaseURL    string
+	Pagination Pagination
+	Transport  http.RoundTripper // Transporte HTTP de colly (nil usa el de por defecto)
+}
+
+func init() {
+	Register("aussar", func(cfg config.StoreConfig) StoreScraper {
+		s := NewAussarScraper()
+		if cfg.Name != "" {
+			s.StoreName = cfg.Name
+		}
+		if cfg.BaseURL != "" {
+			s.BaseURL = cfg.BaseURL
+		}
+		if cfg.MaxPages > 0 {
+			s.Pagination.MaxPages = cfg.MaxPages
+		}
+		return s
+	})
 }
 
 // NewAussarScraper crea una nueva instancia del scraper de Aussar
 func NewAussarScraper() *AussarScraper {
 	return &AussarScraper{
-		BaseURL: "https://www.aussar.es",
+		StoreName: "Aussar",
+		BaseURL:   "https://www.aussar.es",
+		Pagination: Pagination{
+			PageURLTemplate: "{url}?page={page}",
+		},
 	}
 }
 
+// ID devuelve el identificador de Aussar en la configuración
+func (s *AussarScraper) ID() string {
+	return "aussar"
+}
+
+// Name devuelve el nombre de la tienda que se guarda en los precios
+func (s *AussarScraper) Name() string {
+	return s.StoreName
+}
+
+// SetTransport sustituye el transporte HTTP usado por el scraper
+func (s *AussarScraper) SetTransport(transport http.RoundTripper) {
+	s.Transport = transport
+}
+
+// MatchesURL indica si la URL pertenece a Aussar
+func (s *AussarScraper) MatchesURL(url string) bool {
+	return utils.IsAussarURL(url)
+}
+
 // mapCategoryToURL mapea las categorías de nuestro sistema a URLs de Aussar
 func (s *AussarScraper) mapCategoryToURL(slug string) (string, error) {
 	switch strings.ToLower(slug) {
@@ -46,7 +92,7 @@ func (s *AussarScraper) mapCategoryToURL(slug string) (string, error) {
 }
 
 // ScrapCategory realiza el scraping de productos para una categoría específica
-func (s *AussarScraper) ScrapCategory(category *model.Category) ([]*model.Product, error) {
+func (s *AussarScraper) ScrapCategory(ctx context.Context, category *model.Category) ([]*model.Product, error) {
 	// Mapear categoría a URL de Aussar
 	categoryURL, err := s.mapCategoryToURL(category.Slug)
 	if err != nil {
@@ -56,12 +102,12 @@ func (s *AussarScraper) ScrapCategory(category *model.Category) ([]*model.Produc
 	log.Printf("Scraping Aussar - Categoría: %s, URL: %s", category.Name, categoryURL)
 
 	// Configurar el collector de colly
-	c := colly.NewCollector(
-		colly.UserAgent(utils.GetRandomUserAgent()),
+	c := newCollector(ctx, s.Transport,
 		colly.MaxDepth(1),
 	)
 
 	var products []*model.Product
+	pages := newPageCrawler(c, s.Pagination)
 
 	// Procesar cada producto encontrado
 	c.OnHTML(".product-miniature", func(e *colly.HTMLElement) {
@@ -104,8 +150,8 @@ func (s *AussarScraper) ScrapCategory(category *model.Category) ([]*model.Produc
 			}
 		}
 
-		// Crear el producto solo si tiene precio válido
-		if price > 0 {
+		// Crear el producto solo si tiene precio válido y no se vio en una página anterior
+		if price > 0 && pages.isNew(productURL) {
 			// Generar slug desde el nombre del producto
 			slug := utils.GenerateSlug(name)
 
@@ -120,7 +166,7 @@ func (s *AussarScraper) ScrapCategory(category *model.Category) ([]*model.Produc
 
 			// Crear el precio
 			priceObj := model.Price{
-				Store:       "Aussar",
+				Store:       s.Name(),
 				Price:       price,
 				Currency:    "EUR",
 				URL:         productURL,
@@ -140,8 +186,8 @@ func (s *AussarScraper) ScrapCategory(category *model.Category) ([]*model.Produc
 		log.Printf("Error al scrapear %s: %v", r.Request.URL, err)
 	})
 
-	// Visitar la URL de la categoría
-	if err := c.Visit(categoryURL); err != nil {
+	// Visitar la URL de la categoría y las páginas siguientes
+	if err := pages.crawl(c, categoryURL); err != nil {
 		return nil, fmt.Errorf("error al visitar %s: %w", categoryURL, err)
 	}
 
@@ -150,19 +196,18 @@ func (s *AussarScraper) ScrapCategory(category *model.Category) ([]*model.Produc
 }
 
 // ScrapProductDetails obtiene los detalles completos de un producto específico
-func (s *AussarScraper) ScrapProductDetails(productURL string) (*model.Product, error) {
+func (s *AussarScraper) ScrapProductDetails(ctx context.Context, productURL string) (*model.Product, error) {
 	// Verificar que la URL sea de Aussar
-	if !utils.IsAussarURL(productURL) {
+	if !s.MatchesURL(productURL) {
 		return nil, fmt.Errorf("la URL no pertenece a Aussar: %s", productURL)
 	}
 
 	// Configurar el collector de colly
-	c := colly.NewCollector(
-		colly.UserAgent(utils.GetRandomUserAgent()),
-	)
+	c := newCollector(ctx, s.Transport)
 
 	var product model.Product
 	var price model.Price
+	var structured *structuredData
 
 	// Extraer nombre del producto
 	c.OnHTML("h1.h1", func(e *colly.HTMLElement) {
@@ -210,6 +255,13 @@ func (s *AussarScraper) ScrapProductDetails(productURL string) (*model.Product,
 		}
 	})
 
+	// Extraer referencia del fabricante y EAN, y leer los datos estructurados (schema.org)
+	c.OnHTML("html", func(e *colly.HTMLElement) {
+		utils.AddProductIdentifier(&product, model.IdentifierMPN, firstText(e.DOM, ".product-reference span"), s.Name())
+		utils.AddProductIdentifier(&product, model.IdentifierGTIN, firstText(e.DOM, ".product-ean13 span"), s.Name())
+		structured = extractStructuredData(e.DOM, e.Request.AbsoluteURL)
+	})
+
 	// Manejar errores
 	c.OnError(func(r *colly.Response, err error) {
 		log.Printf("Error al scrapear detalles del producto %s: %v", r.Request.URL, err)
@@ -220,14 +272,21 @@ func (s *AussarScraper) ScrapProductDetails(productURL string) (*model.Product,
 		return nil, fmt.Errorf("error al visitar %s: %w", productURL, err)
 	}
 
+	// Completar con los datos de schema.org lo que los selectores de la tienda no encontraron
+	if structured != nil {
+		structured.complete(&product, &price, s.Name())
+	}
+
 	// Si no se pudo extraer el nombre, devolver error
 	if product.Name == "" {
 		return nil, fmt.Errorf("no se pudo extraer el nombre del producto")
 	}
 
 	// Completar los datos del precio
-	price.Store = "Aussar"
-	price.Currency = "EUR"
+	price.Store = s.Name()
+	if price.Currency == "" {
+		price.Currency = "EUR"
+	}
 	price.URL = productURL
 	price.RetrievedAt = time.Now()
 
@@ -1,6 +1,7 @@
 package scraper
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -8,6 +9,7 @@ import (
 	"time"
 
 	"app/internal/domain/model"
+	"app/pkg/config"
 	"app/pkg/utils"
 
 	"github.com/PuerkitoBio/goquery"
@@ -16,16 +18,61 @@ import (
 
 // CoolmodScraper implementa el scraping para Coolmod
 type CoolmodScraper struct {
-	BaseURL string
+	StoreName  string // Nombre de la tienda en los precios (sección "stores" o el de por defecto)
+	BaseURL    string
+	Pagination Pagination
+	Transport  http.RoundTripper // Transporte HTTP de colly (nil usa el de por defecto)
+}
+
+func init() {
+	Register("coolmod", func(cfg config.StoreConfig) StoreScraper {
+		s := NewCoolmodScraper()
+		if cfg.Name != "" {
+			s.StoreName = cfg.Name
+		}
+		if cfg.BaseURL != "" {
+			s.BaseURL = cfg.BaseURL
+		}
+		if cfg.MaxPages > 0 {
+			s.Pagination.MaxPages = cfg.MaxPages
+		}
+		return s
+	})
 }
 
 // NewCoolmodScraper crea una nueva instancia del scraper de Coolmod
 func NewCoolmodScraper() *CoolmodScraper {
 	return &CoolmodScraper{
-		BaseURL: "https://www.coolmod.com",
+		StoreName: "Coolmod",
+		BaseURL:   "https://www.coolmod.com",
+		// El buscador de Coolmod carga los resultados en el navegador a partir del fragmento (#)
+		// de la URL, así que no hay páginas adicionales que recorrer
+		Pagination: Pagination{
+			MaxPages: 1,
+		},
 	}
 }
 
+// ID devuelve el identificador de Coolmod en la configuración
+func (s *CoolmodScraper) ID() string {
+	return "coolmod"
+}
+
+// Name devuelve el nombre de la tienda que se guarda en los precios
+func (s *CoolmodScraper) Name() string {
+	return s.StoreName
+}
+
+// SetTransport sustituye el transporte HTTP usado por el scraper
+func (s *CoolmodScraper) SetTransport(transport http.RoundTripper) {
+	s.Transport = transport
+}
+
+// MatchesURL indica si la URL pertenece a Coolmod
+func (s *CoolmodScraper) MatchesURL(url string) bool {
+	return utils.IsCoolmodURL(url)
+}
+
 // mapCategoryToURL mapea las categorías de nuestro sistema a URLs de Coolmod
 func (s *CoolmodScraper) mapCategoryToURL(slug string) (string, error) {
 	switch strings.ToLower(slug) {
@@ -47,7 +94,7 @@ func (s *CoolmodScraper) mapCategoryToURL(slug string) (string, error) {
 }
 
 // ScrapCategory realiza el scraping de productos para una categoría específica
-func (s *CoolmodScraper) ScrapCategory(category *model.Category) ([]*model.Product, error) {
+func (s *CoolmodScraper) ScrapCategory(ctx context.Context, category *model.Category) ([]*model.Product, error) {
 	// Mapear categoría a URL de Coolmod
 	categoryURL, err := s.mapCategoryToURL(category.Slug)
 	if err != nil {
@@ -57,8 +104,7 @@ func (s *CoolmodScraper) ScrapCategory(category *model.Category) ([]*model.Produ
 	log.Printf("Scraping Coolmod - Categoría: %s, URL: %s", category.Name, categoryURL)
 
 	// Configurar el collector de colly
-	c := colly.NewCollector(
-		colly.UserAgent(utils.GetRandomUserAgent()),
+	c := newCollector(ctx, s.Transport,
 		colly.MaxDepth(1),
 	)
 
@@ -69,6 +115,7 @@ func (s *CoolmodScraper) ScrapCategory(category *model.Category) ([]*model.Produ
 	})
 
 	var products []*model.Product
+	pages := newPageCrawler(c, s.Pagination)
 
 	// Procesar cada producto encontrado - actualizado con los selectores correctos
 	c.OnHTML("article.product-card", func(e *colly.HTMLElement) {
@@ -117,8 +164,8 @@ func (s *CoolmodScraper) ScrapCategory(category *model.Category) ([]*model.Produ
 			price = 0
 		}
 
-		// Crear el producto solo si tiene precio válido
-		if price > 0 {
+		// Crear el producto solo si tiene precio válido y no se vio en una página anterior
+		if price > 0 && pages.isNew(productURL) {
 			// Generar slug desde el nombre del producto
 			slug := utils.GenerateSlug(name)
 
@@ -133,7 +180,7 @@ func (s *CoolmodScraper) ScrapCategory(category *model.Category) ([]*model.Produ
 
 			// Crear el precio
 			priceObj := model.Price{
-				Store:       "Coolmod",
+				Store:       s.Name(),
 				Price:       price,
 				Currency:    "EUR",
 				URL:         productURL,
@@ -153,8 +200,8 @@ func (s *CoolmodScraper) ScrapCategory(category *model.Category) ([]*model.Produ
 		log.Printf("Error al scrapear %s: %v", r.Request.URL, err)
 	})
 
-	// Visitar la URL de la categoría
-	if err := c.Visit(categoryURL); err != nil {
+	// Visitar la URL de la categoría y las páginas siguientes
+	if err := pages.crawl(c, categoryURL); err != nil {
 		return nil, fmt.Errorf("error al visitar %s: %w", categoryURL, err)
 	}
 
@@ -163,19 +210,18 @@ func (s *CoolmodScraper) ScrapCategory(category *model.Category) ([]*model.Produ
 }
 
 // ScrapProductDetails obtiene los detalles completos de un producto específico
-func (s *CoolmodScraper) ScrapProductDetails(productURL string) (*model.Product, error) {
+func (s *CoolmodScraper) ScrapProductDetails(ctx context.Context, productURL string) (*model.Product, error) {
 	// Verificar que la URL sea de Coolmod
-	if !utils.IsCoolmodURL(productURL) {
+	if !s.MatchesURL(productURL) {
 		return nil, fmt.Errorf("la URL no pertenece a Coolmod: %s", productURL)
 	}
 
 	// Configurar el collector de colly
-	c := colly.NewCollector(
-		colly.UserAgent(utils.GetRandomUserAgent()),
-	)
+	c := newCollector(ctx, s.Transport)
 
 	var product model.Product
 	var price model.Price
+	var structured *structuredData
 
 	// Extraer nombre del producto
 	c.OnHTML("h1.card-title, .product-name", func(e *colly.HTMLElement) {
@@ -236,6 +282,11 @@ func (s *CoolmodScraper) ScrapProductDetails(productURL string) (*model.Product,
 		}
 	})
 
+	// Leer los datos estructurados (schema.org): GTIN, referencia del fabricante y respaldo del resto de campos
+	c.OnHTML("html", func(e *colly.HTMLElement) {
+		structured = extractStructuredData(e.DOM, e.Request.AbsoluteURL)
+	})
+
 	// Manejar errores
 	c.OnError(func(r *colly.Response, err error) {
 		log.Printf("Error al scrapear detalles del producto %s: %v", r.Request.URL, err)
@@ -246,14 +297,21 @@ func (s *CoolmodScraper) ScrapProductDetails(productURL string) (*model.Product,
 		return nil, fmt.Errorf("error al visitar %s: %w", productURL, err)
 	}
 
+	// Completar con los datos de schema.org lo que los selectores de la tienda no encontraron
+	if structured != nil {
+		structured.complete(&product, &price, s.Name())
+	}
+
 	// Si no se pudo extraer el nombre, devolver error
 	if product.Name == "" {
 		return nil, fmt.Errorf("no se pudo extraer el nombre del producto")
 	}
 
 	// Completar los datos del precio
-	price.Store = "Coolmod"
-	price.Currency = "EUR"
+	price.Store = s.Name()
+	if price.Currency == "" {
+		price.Currency = "EUR"
+	}
 	price.URL = productURL
 	price.RetrievedAt = time.Now()
 
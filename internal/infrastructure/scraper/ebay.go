@@ -1,14 +1,17 @@
 package scraper
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"app/internal/domain/model"
+	"app/pkg/config"
 	"app/pkg/utils"
 
 	"github.com/gocolly/colly/v2"
@@ -16,18 +19,61 @@ import (
 
 // EbayScraper implementa el scraping para eBay
 type EbayScraper struct {
-	BaseURL string
+	StoreName  string // Nombre de la tienda en los precios (sección "stores" o el de por defecto)
+	BaseURL    string
+	Pagination Pagination
+	Transport  http.RoundTripper // Transporte HTTP de colly (nil usa el de por defecto)
+}
+
+func init() {
+	Register("ebay", func(cfg config.StoreConfig) StoreScraper {
+		s := NewEbayScraper()
+		if cfg.Name != "" {
+			s.StoreName = cfg.Name
+		}
+		if cfg.BaseURL != "" {
+			s.BaseURL = cfg.BaseURL
+		}
+		if cfg.MaxPages > 0 {
+			s.Pagination.MaxPages = cfg.MaxPages
+		}
+		return s
+	})
 }
 
 // NewEbayScraper crea una nueva instancia del scraper de eBay
 func NewEbayScraper() *EbayScraper {
 	return &EbayScraper{
-		BaseURL: "https://www.ebay.com",
+		StoreName: "eBay",
+		BaseURL:   "https://www.ebay.com",
+		Pagination: Pagination{
+			PageURLTemplate: "{url}&_pgn={page}",
+		},
 	}
 }
 
+// ID devuelve el identificador de eBay en la configuración
+func (s *EbayScraper) ID() string {
+	return "ebay"
+}
+
+// Name devuelve el nombre de la tienda que se guarda en los precios
+func (s *EbayScraper) Name() string {
+	return s.StoreName
+}
+
+// SetTransport sustituye el transporte HTTP usado por el scraper
+func (s *EbayScraper) SetTransport(transport http.RoundTripper) {
+	s.Transport = transport
+}
+
+// MatchesURL indica si la URL pertenece a eBay
+func (s *EbayScraper) MatchesURL(url string) bool {
+	return utils.IsEbayURL(url)
+}
+
 // ScrapCategory obtiene productos de una categoría específica
-func (s *EbayScraper) ScrapCategory(category *model.Category) ([]*model.Product, error) {
+func (s *EbayScraper) ScrapCategory(ctx context.Context, category *model.Category) ([]*model.Product, error) {
 	var products []*model.Product
 
 	// Definir URL según la categoría
@@ -53,10 +99,10 @@ func (s *EbayScraper) ScrapCategory(category *model.Category) ([]*model.Product,
 	searchURL := fmt.Sprintf("%s/sch/i.html?_nkw=%s&_sacat=0", s.BaseURL, searchTerm)
 
 	// Configurar el collector de colly
-	c := colly.NewCollector(
-		colly.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"),
+	c := newCollector(ctx, s.Transport,
 		colly.MaxDepth(1),
 	)
+	pages := newPageCrawler(c, s.Pagination)
 
 	// Primero capturamos las imágenes precargadas (nuevo formato de eBay)
 	prefetchImages := make(map[string]string)
@@ -217,6 +263,11 @@ func (s *EbayScraper) ScrapCategory(category *model.Category) ([]*model.Product,
 			return
 		}
 
+		// Ignorar productos ya vistos en una página anterior
+		if !pages.isNew(url) {
+			return
+		}
+
 		product := &model.Product{
 			Name:        name,
 			Slug:        utils.GenerateSlug(name), // Generar slug a partir del nombre
@@ -226,7 +277,7 @@ func (s *EbayScraper) ScrapCategory(category *model.Category) ([]*model.Product,
 		}
 
 		priceModel := &model.Price{
-			Store:       "eBay",
+			Store:       s.Name(),
 			Price:       price,
 			Currency:    "USD",
 			URL:         url,
@@ -234,6 +285,8 @@ func (s *EbayScraper) ScrapCategory(category *model.Category) ([]*model.Product,
 			RetrievedAt: time.Now(),
 		}
 
+		ebayListingOffer.apply(e.DOM, "en", priceModel)
+
 		product.Prices = []model.Price{*priceModel}
 		products = append(products, product)
 	})
@@ -243,8 +296,8 @@ func (s *EbayScraper) ScrapCategory(category *model.Category) ([]*model.Product,
 		log.Printf("Error al scrapear %s: %v\n", r.Request.URL, err)
 	})
 
-	// Visitar la página de búsqueda
-	if err := c.Visit(searchURL); err != nil {
+	// Visitar la página de búsqueda y las siguientes
+	if err := pages.crawl(c, searchURL); err != nil {
 		return nil, fmt.Errorf("error al visitar %s: %w", searchURL, err)
 	}
 
@@ -269,6 +322,129 @@ func (s *EbayScraper) ScrapCategory(category *model.Category) ([]*model.Product,
 	return products, nil
 }
 
+// ebayListingOffer son los selectores de envío, estado, formato y vendedor de los resultados de búsqueda
+var ebayListingOffer = OfferSelectors{
+	Shipping:       ".s-item__shipping, .s-item__logisticsCost",
+	ImportCharges:  ".s-item__importCharges",
+	SellerLocation: ".s-item__location, .s-item__itemLocation",
+	Condition:      ".SECONDARY_INFO",
+	ListingType:    ".s-item__bids, .s-item__bidCount, .s-item__purchaseOptions, .s-item__formatBuyItNow",
+	SellerName:     ".s-item__seller-info-text",
+	SellerFeedback: ".s-item__seller-feedback",
+}
+
+// ebayDetailOffer son los selectores de envío, estado, formato y vendedor de la página de un anuncio
+var ebayDetailOffer = OfferSelectors{
+	Shipping:       ".ux-labels-values--shipping .ux-labels-values__values",
+	ImportCharges:  ".ux-labels-values--importCharges .ux-labels-values__values",
+	SellerLocation: ".ux-labels-values--itemLocation .ux-labels-values__values",
+	Condition:      ".x-item-condition-text .ux-textspans, .x-item-condition-value .ux-textspans",
+	ListingType:    ".x-bid-count, .x-bin-action",
+	SellerName:     ".x-sellercard-atf__info__about-seller",
+	SellerFeedback: ".x-sellercard-atf__about-seller",
+}
+
+// ScrapProductDetails obtiene los detalles de un anuncio de eBay a partir de su URL
+func (s *EbayScraper) ScrapProductDetails(ctx context.Context, productURL string) (*model.Product, error) {
+	// Verificar que la URL sea de eBay
+	if !s.MatchesURL(productURL) {
+		return nil, fmt.Errorf("la URL no pertenece a eBay: %s", productURL)
+	}
+
+	// Configurar el collector de colly
+	c := newCollector(ctx, s.Transport)
+
+	var product model.Product
+	var price model.Price
+	var structured *structuredData
+	price.IsAvailable = true
+
+	// Extraer nombre del producto (título del anuncio o, en su defecto, og:title)
+	c.OnHTML("h1.x-item-title__mainTitle", func(e *colly.HTMLElement) {
+		product.Name = strings.TrimSpace(e.Text)
+	})
+	c.OnHTML("meta[property='og:title']", func(e *colly.HTMLElement) {
+		if product.Name == "" {
+			product.Name = strings.TrimSpace(e.Attr("content"))
+		}
+	})
+
+	// Extraer imagen principal
+	c.OnHTML("meta[property='og:image']", func(e *colly.HTMLElement) {
+		product.ImageURL = e.Attr("content")
+	})
+
+	// Extraer precio del producto
+	c.OnHTML(".x-price-primary span.ux-textspans", func(e *colly.HTMLElement) {
+		if price.Price > 0 {
+			return
+		}
+		priceText := strings.ReplaceAll(e.Text, "US", "")
+		priceText = strings.ReplaceAll(priceText, ",", "")
+		extractedPrice, err := utils.ExtractPrice(strings.TrimSpace(priceText))
+		if err != nil {
+			log.Printf("Error al convertir precio: %v", err)
+			return
+		}
+		price.Price = extractedPrice
+	})
+
+	// Extraer disponibilidad
+	c.OnHTML(".d-quantity__availability, .x-quantity__availability", func(e *colly.HTMLElement) {
+		availText := strings.ToLower(strings.TrimSpace(e.Text))
+		if strings.Contains(availText, "out of stock") || strings.Contains(availText, "sold out") {
+			price.IsAvailable = false
+		}
+	})
+
+	// Extraer envío, cargos de importación y ubicación del vendedor
+	c.OnHTML("html", func(e *colly.HTMLElement) {
+		ebayDetailOffer.apply(e.DOM, "en", &price)
+	})
+
+	// Extraer MPN, UPC y EAN de las características del artículo y leer los datos estructurados (schema.org)
+	c.OnHTML("html", func(e *colly.HTMLElement) {
+		utils.AddProductIdentifier(&product, model.IdentifierMPN, firstText(e.DOM, ".ux-labels-values--mpn .ux-labels-values__values"), s.Name())
+		utils.AddProductIdentifier(&product, model.IdentifierGTIN, firstText(e.DOM, ".ux-labels-values--upc .ux-labels-values__values"), s.Name())
+		utils.AddProductIdentifier(&product, model.IdentifierGTIN, firstText(e.DOM, ".ux-labels-values--ean .ux-labels-values__values"), s.Name())
+		structured = extractStructuredData(e.DOM, e.Request.AbsoluteURL)
+	})
+
+	// Manejar errores
+	c.OnError(func(r *colly.Response, err error) {
+		log.Printf("Error al scrapear detalles del producto %s: %v", r.Request.URL, err)
+	})
+
+	// Visitar la URL del producto
+	if err := c.Visit(productURL); err != nil {
+		return nil, fmt.Errorf("error al visitar %s: %w", productURL, err)
+	}
+
+	// Completar con los datos de schema.org lo que los selectores de la tienda no encontraron
+	if structured != nil {
+		structured.complete(&product, &price, s.Name())
+	}
+
+	// Si no se pudo extraer el nombre, devolver error
+	if product.Name == "" {
+		return nil, fmt.Errorf("no se pudo extraer el nombre del producto")
+	}
+	product.Slug = utils.GenerateSlug(product.Name)
+
+	// Completar los datos del precio
+	price.Store = s.Name()
+	if price.Currency == "" {
+		price.Currency = "USD"
+	}
+	price.URL = productURL
+	price.RetrievedAt = time.Now()
+
+	// Asignar el precio al producto
+	product.Prices = []model.Price{price}
+
+	return &product, nil
+}
+
 // extractImageIDFromURL extrae el ID de imagen de una URL de eBay
 // Ejemplo: https://i.ebayimg.com/images/g/kpQAAOSwnCdmMmCt/s-l500.webp -> g/kpQAAOSwnCdmMmCt
 func extractImageIDFromURL(url string) string {
@@ -0,0 +1,221 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"app/pkg/config"
+	"app/pkg/utils"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// Fetcher es la capa HTTP compartida por todos los scrapers. Implementa http.RoundTripper
+// para usarse como transporte de colly y aplica, según ScraperConfig:
+//   - un intervalo mínimo entre peticiones al mismo dominio (RequestDelay)
+//   - reintentos con espera exponencial ante errores de red, 429 y 5xx (MaxRetries, RetryDelay)
+//   - la cancelación del contexto de cada petición, también durante las esperas
+//   - salvo AllowPrivateNetworks, el rechazo de las peticiones a la propia máquina o a redes
+//     privadas, ya que los usuarios pueden enviar URLs para seguir y las páginas redirigen
+type Fetcher struct {
+	next         http.RoundTripper
+	maxRetries   int
+	retryDelay   time.Duration
+	requestDelay time.Duration
+	allowPrivate bool
+
+	mu    sync.Mutex
+	hosts map[string]time.Time // Próximo instante en que se puede pedir a cada dominio
+}
+
+// NewFetcher crea la capa HTTP compartida a partir de la configuración de los scrapers.
+// next es el transporte que hace las peticiones reales (si es nil se usa uno como
+// http.DefaultTransport que, salvo AllowPrivateNetworks, no se conecta a IPs privadas)
+func NewFetcher(cfg config.ScraperConfig, next http.RoundTripper) *Fetcher {
+	if next == nil {
+		next = newNetworkTransport(cfg.AllowPrivateNetworks)
+	}
+	return &Fetcher{
+		next:         next,
+		maxRetries:   cfg.MaxRetries,
+		retryDelay:   cfg.RetryDelay,
+		requestDelay: cfg.RequestDelay,
+		allowPrivate: cfg.AllowPrivateNetworks,
+		hosts:        make(map[string]time.Time),
+	}
+}
+
+// newNetworkTransport crea el transporte de las peticiones reales. Si allowPrivate es false
+// comprueba la IP a la que se conecta realmente, de modo que ni un dominio que resuelve a una IP
+// privada ni una redirección hacia él sirven para llegar a la red interna
+func newNetworkTransport(allowPrivate bool) http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivate {
+		dialer := &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control:   utils.PublicDialControl,
+		}
+		transport.DialContext = dialer.DialContext
+	}
+	return transport
+}
+
+// RoundTrip implementa http.RoundTripper. Cada redirección pasa también por aquí
+func (f *Fetcher) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	if !f.allowPrivate && utils.IsLocalHost(req.URL.Hostname()) {
+		return nil, fmt.Errorf("%s: %w", req.URL, utils.ErrPrivateAddress)
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := f.waitForHost(ctx, req.URL.Host); err != nil {
+			return nil, err
+		}
+
+		resp, err := f.next.RoundTrip(req)
+		if !utils.ShouldRetry(resp, err) || attempt >= f.maxRetries {
+			return resp, err
+		}
+
+		delay := utils.RetryBackoff(f.retryDelay, attempt, resp)
+		if err != nil {
+			log.Printf("[FETCH] Error en %s: %v. Reintento %d/%d en %s", req.URL, err, attempt+1, f.maxRetries, delay)
+		} else {
+			log.Printf("[FETCH] %s respondió %d. Reintento %d/%d en %s", req.URL, resp.StatusCode, attempt+1, f.maxRetries, delay)
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if err := utils.SleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// waitForHost espera el turno del dominio para respetar el intervalo mínimo entre peticiones
+func (f *Fetcher) waitForHost(ctx context.Context, host string) error {
+	if f.requestDelay <= 0 {
+		return ctx.Err()
+	}
+
+	f.mu.Lock()
+	now := time.Now()
+	slot := f.hosts[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	f.hosts[host] = slot.Add(f.requestDelay)
+	f.mu.Unlock()
+
+	return utils.SleepContext(ctx, time.Until(slot))
+}
+
+// RequestStats cuenta las peticiones HTTP hechas por los scrapers durante una ejecución.
+// Se asocia al contexto con WithRequestStats
+type RequestStats struct {
+	requests atomic.Int64
+	errors   atomic.Int64
+}
+
+// Requests devuelve el número de peticiones realizadas
+func (s *RequestStats) Requests() int {
+	return int(s.requests.Load())
+}
+
+// Errors devuelve el número de peticiones fallidas (errores de red o respuestas 4xx/5xx)
+func (s *RequestStats) Errors() int {
+	return int(s.errors.Load())
+}
+
+// requestStatsKey es la clave del contexto donde se guardan las RequestStats
+type requestStatsKey struct{}
+
+// WithRequestStats devuelve un contexto en el que los scrapers anotan sus peticiones en stats
+func WithRequestStats(ctx context.Context, stats *RequestStats) context.Context {
+	return context.WithValue(ctx, requestStatsKey{}, stats)
+}
+
+// requestStatsFrom devuelve las RequestStats del contexto, o nil si no hay
+func requestStatsFrom(ctx context.Context) *RequestStats {
+	stats, _ := ctx.Value(requestStatsKey{}).(*RequestStats)
+	return stats
+}
+
+// contextTransport asocia un contexto a todas las peticiones de un collector, ya que colly
+// no permite pasar uno. Al cancelarlo se interrumpen las peticiones en curso
+type contextTransport struct {
+	ctx  context.Context
+	next http.RoundTripper
+}
+
+// RoundTrip implementa http.RoundTripper
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.ctx.Err(); err != nil {
+		return nil, fmt.Errorf("petición cancelada: %w", err)
+	}
+
+	resp, err := t.next.RoundTrip(req.WithContext(t.ctx))
+
+	// El robots.txt no cuenta: que no exista es lo normal
+	if stats := requestStatsFrom(t.ctx); stats != nil && !strings.HasSuffix(req.URL.Path, "/robots.txt") {
+		stats.requests.Add(1)
+		if err != nil || resp.StatusCode >= 400 {
+			stats.errors.Add(1)
+		}
+	}
+
+	return resp, err
+}
+
+// newCollector crea un collector de colly configurado según ScraperConfig (User-Agent,
+// timeout y robots.txt) cuyas peticiones usan el transporte indicado y el contexto ctx
+func newCollector(ctx context.Context, transport http.RoundTripper, options ...colly.CollectorOption) *colly.Collector {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	userAgent := utils.GetRandomUserAgent()
+	timeout := 30 * time.Second
+	respectRobots := true
+	allowPrivate := false
+	if config.Config != nil {
+		if config.Config.Scraper.UserAgent != "" {
+			userAgent = config.Config.Scraper.UserAgent
+		}
+		if config.Config.Scraper.RequestTimeout > 0 {
+			timeout = config.Config.Scraper.RequestTimeout
+		}
+		respectRobots = config.Config.Scraper.RespectRobotsTxt
+		allowPrivate = config.Config.Scraper.AllowPrivateNetworks
+	}
+
+	c := colly.NewCollector(append([]colly.CollectorOption{colly.UserAgent(userAgent)}, options...)...)
+	c.IgnoreRobotsTxt = !respectRobots
+	c.SetRequestTimeout(timeout)
+	c.WithTransport(&contextTransport{ctx: ctx, next: transport})
+
+	// Cada redirección se vuelve a validar: las URLs de los usuarios y las de las páginas que
+	// lee el scraper de schema.org no deben poder llevar a la red interna
+	if !allowPrivate {
+		c.SetRedirectHandler(utils.CheckPublicRedirect)
+	}
+
+	// No lanzar peticiones nuevas una vez cancelado el contexto
+	c.OnRequest(func(r *colly.Request) {
+		if ctx.Err() != nil {
+			r.Abort()
+		}
+	})
+
+	return c
+}
@@ -0,0 +1,165 @@
+package scraper
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// FixtureMode indica si el FixtureTransport graba respuestas reales o sirve las ya grabadas
+type FixtureMode string
+
+const (
+	// FixtureRecord hace las peticiones reales y guarda cada respuesta en el directorio de fixtures
+	FixtureRecord FixtureMode = "record"
+	// FixtureReplay sirve las respuestas desde el directorio de fixtures sin acceder a la red
+	FixtureReplay FixtureMode = "replay"
+)
+
+// fixtureNameRegexp selecciona los caracteres que no pueden formar parte del nombre de un fixture
+var fixtureNameRegexp = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// FixtureTransport es un http.RoundTripper para colly que graba o reproduce las respuestas HTTP
+// de los scrapers en archivos (un archivo por URL). Permite probar el parseo sin conexión
+type FixtureTransport struct {
+	Dir  string
+	Mode FixtureMode
+	next http.RoundTripper
+}
+
+// NewFixtureTransport crea un transporte de fixtures. next es el transporte usado para las
+// peticiones reales en modo grabación (si es nil se usa http.DefaultTransport)
+func NewFixtureTransport(dir string, mode FixtureMode, next http.RoundTripper) *FixtureTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &FixtureTransport{Dir: dir, Mode: mode, next: next}
+}
+
+// FixtureKey devuelve el nombre de archivo con el que se guarda la respuesta de una URL.
+// Se compone de la URL legible (recortada) y un hash corto de la URL completa
+func FixtureKey(rawURL string) string {
+	name := strings.TrimPrefix(strings.TrimPrefix(rawURL, "https://"), "http://")
+	name = strings.Trim(fixtureNameRegexp.ReplaceAllString(name, "_"), "_")
+	if len(name) > 80 {
+		name = name[:80]
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(rawURL)))[:8]
+	return name + "_" + hash + ".html"
+}
+
+// RoundTrip implementa http.RoundTripper
+func (t *FixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := filepath.Join(t.Dir, FixtureKey(req.URL.String()))
+
+	if t.Mode == FixtureRecord {
+		return t.record(req, path)
+	}
+
+	body, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		// Sin fixture se responde 404, igual que haría una tienda con una página inexistente
+		return fixtureResponse(req, http.StatusNotFound, nil), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al leer el fixture %s: %w", path, err)
+	}
+
+	return fixtureResponse(req, http.StatusOK, body), nil
+}
+
+// record hace la petición real y guarda el cuerpo de las respuestas correctas
+func (t *FixtureTransport) record(req *http.Request, path string) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer la respuesta de %s: %w", req.URL, err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("[FIXTURES] No se graba %s: estado %d", req.URL, resp.StatusCode)
+		return resp, nil
+	}
+
+	trimmed, err := TrimFixture(body)
+	if err != nil {
+		log.Printf("[FIXTURES] No se ha podido recortar %s, se graba completo: %v", req.URL, err)
+		trimmed = body
+	}
+
+	if err := os.MkdirAll(t.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("error al crear el directorio de fixtures %s: %w", t.Dir, err)
+	}
+	if err := os.WriteFile(path, trimmed, 0644); err != nil {
+		return nil, fmt.Errorf("error al guardar el fixture %s: %w", path, err)
+	}
+	log.Printf("[FIXTURES] Grabado %s -> %s (%d de %d bytes)", req.URL, filepath.Base(path), len(trimmed), len(body))
+
+	return resp, nil
+}
+
+// fixtureNoiseSelector selecciona los elementos de una página grabada que ningún scraper lee. Los
+// scripts JSON-LD se conservan porque contienen los datos estructurados de schema.org
+const fixtureNoiseSelector = `script:not([type="application/ld+json"]), style, noscript, svg, iframe, template, link`
+
+// TrimFixture recorta una página grabada para guardarla como fixture: quita los scripts, estilos,
+// SVG y comentarios, que son la mayor parte de una página real y no influyen en el parseo. El
+// marcado que leen los scrapers (clases, atributos, microdatos y JSON-LD) se conserva tal cual
+func TrimFixture(body []byte) ([]byte, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	doc.Find(fixtureNoiseSelector).Remove()
+	removeComments(doc.Nodes[0])
+
+	var buf bytes.Buffer
+	if err := html.Render(&buf, doc.Nodes[0]); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// removeComments elimina los comentarios HTML de un nodo y sus descendientes
+func removeComments(node *html.Node) {
+	for child := node.FirstChild; child != nil; {
+		next := child.NextSibling
+		if child.Type == html.CommentNode {
+			node.RemoveChild(child)
+		} else {
+			removeComments(child)
+		}
+		child = next
+	}
+}
+
+// fixtureResponse construye una respuesta HTML a partir del contenido de un fixture
+func fixtureResponse(req *http.Request, status int, body []byte) *http.Response {
+	return &http.Response{
+		StatusCode:    status,
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"text/html; charset=utf-8"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,149 @@
+package scraper
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"app/internal/domain/model"
+	"app/pkg/utils"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// OfferSelectors contiene los selectores opcionales de los costes y datos del vendedor de una
+// oferta. Se pueden indicar tanto en el listado como en el detalle
+type OfferSelectors struct {
+	Shipping       string `mapstructure:"shipping"`        // Gastos de envío ("+4,99 € envío", "Envío gratis")
+	ImportCharges  string `mapstructure:"import_charges"`  // Cargos de importación o aduanas
+	TaxNote        string `mapstructure:"tax_note"`        // Indicación de impuestos ("IVA incluido")
+	SellerLocation string `mapstructure:"seller_location"` // Ubicación del vendedor
+	Condition      string `mapstructure:"condition"`       // Estado del artículo ("Nuevo", "Pre-Owned", "Refurbished")
+	ListingType    string `mapstructure:"listing_type"`    // Formato del anuncio ("3 bids", "Buy It Now")
+	SellerName     string `mapstructure:"seller_name"`     // Vendedor; se ignora lo que vaya tras "(" ("techdeals (1,234) 99.8%")
+	SellerFeedback string `mapstructure:"seller_feedback"` // Valoraciones del vendedor ("(1,234)")
+}
+
+// sellerLocationPrefixes son los prefijos que las tiendas anteponen a la ubicación del vendedor
+var sellerLocationPrefixes = []string{"from ", "located in:", "desde ", "ubicado en:", "enviado desde "}
+
+// apply rellena en el precio los costes y datos del vendedor que encuentre en sel
+func (o OfferSelectors) apply(sel *goquery.Selection, locale string, price *model.Price) {
+	if o.Shipping != "" {
+		price.ShippingCost = utils.ExtractAdditionalCost(firstText(sel, o.Shipping), locale)
+	}
+	if o.ImportCharges != "" {
+		price.ImportCharges = utils.ExtractAdditionalCost(firstText(sel, o.ImportCharges), locale)
+	}
+	if o.TaxNote != "" {
+		price.TaxNote = truncate(firstText(sel, o.TaxNote), 100)
+	}
+	if o.SellerLocation != "" {
+		price.SellerLocation = truncate(cleanSellerLocation(firstText(sel, o.SellerLocation)), 100)
+	}
+	if o.Condition != "" {
+		price.Condition = parseCondition(firstText(sel, o.Condition))
+	}
+	if o.ListingType != "" {
+		price.ListingType = parseListingType(firstText(sel, o.ListingType))
+	}
+	if o.SellerName != "" {
+		price.SellerName = truncate(parseSellerName(firstText(sel, o.SellerName)), 100)
+	}
+	if o.SellerFeedback != "" {
+		price.SellerFeedback = parseSellerFeedback(firstText(sel, o.SellerFeedback))
+	}
+}
+
+// cleanSellerLocation quita de la ubicación del vendedor prefijos como "from " o "Ubicado en:"
+func cleanSellerLocation(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	lower := strings.ToLower(text)
+	for _, prefix := range sellerLocationPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return strings.TrimSpace(text[len(prefix):])
+		}
+	}
+	return text
+}
+
+// truncate recorta un texto a un máximo de caracteres para que quepa en su columna
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max])
+}
+
+// conditionTexts asocia textos de estado de las tiendas a los estados del modelo. Se comprueban
+// en orden, porque "Like New" o "Refurbished - Excellent" contienen textos de otros estados
+var conditionTexts = []struct {
+	condition string
+	texts     []string
+}{
+	{model.ConditionForParts, []string{"for parts", "parts only", "not working", "para piezas", "no funciona"}},
+	{model.ConditionRefurbished, []string{"refurbished", "renewed", "reacondicionado", "remanufactured"}},
+	{model.ConditionUsed, []string{"pre-owned", "used", "open box", "like new", "usado", "segunda mano", "seminuevo"}},
+	{model.ConditionNew, []string{"new", "nuevo"}},
+}
+
+// parseCondition convierte el texto de estado de una tienda en un estado del modelo,
+// o devuelve una cadena vacía si no lo reconoce
+func parseCondition(text string) string {
+	text = strings.ToLower(text)
+	for _, entry := range conditionTexts {
+		for _, marker := range entry.texts {
+			if strings.Contains(text, marker) {
+				return entry.condition
+			}
+		}
+	}
+	return ""
+}
+
+// parseListingType convierte el texto del formato de un anuncio en un tipo del modelo,
+// o devuelve una cadena vacía si no lo reconoce
+func parseListingType(text string) string {
+	text = strings.ToLower(text)
+	switch {
+	case text == "":
+		return ""
+	case strings.Contains(text, "bid"), strings.Contains(text, "auction"),
+		strings.Contains(text, "puja"), strings.Contains(text, "subasta"):
+		return model.ListingTypeAuction
+	case strings.Contains(text, "buy it now"), strings.Contains(text, "best offer"),
+		strings.Contains(text, "cómpralo ya"):
+		return model.ListingTypeBuyItNow
+	}
+	return ""
+}
+
+// parseSellerName devuelve el nombre del vendedor sin las valoraciones que le siguen
+func parseSellerName(text string) string {
+	if i := strings.Index(text, "("); i >= 0 {
+		text = text[:i]
+	}
+	return strings.TrimSpace(text)
+}
+
+// sellerFeedbackPattern reconoce el número de valoraciones entre paréntesis: "(12,345)"
+var sellerFeedbackPattern = regexp.MustCompile(`\(([\d.,]+)\)`)
+
+// parseSellerFeedback extrae el número de valoraciones del vendedor, entre paréntesis o como
+// primer número del texto. Devuelve nil si no hay ninguno
+func parseSellerFeedback(text string) *int {
+	var number string
+	if match := sellerFeedbackPattern.FindStringSubmatch(text); match != nil {
+		number = match[1]
+	} else if fields := strings.Fields(text); len(fields) > 0 {
+		number = fields[0]
+	}
+
+	number = strings.NewReplacer(",", "", ".", "").Replace(number)
+	feedback, err := strconv.Atoi(number)
+	if err != nil {
+		return nil
+	}
+	return &feedback
+}
@@ -0,0 +1,138 @@
+package scraper
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"app/pkg/config"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// defaultMaxPages es el número de páginas por categoría si no hay nada configurado
+const defaultMaxPages = 5
+
+// Pagination indica cómo recorrer las páginas de resultados de una categoría.
+// Si hay PageURLTemplate se generan las URLs por número de página; si no, se sigue el
+// enlace indicado por NextSelector. Sin ninguno de los dos solo se visita la primera página
+type Pagination struct {
+	// NextSelector es el selector CSS del enlace a la página siguiente (ej: "a.next")
+	NextSelector string `mapstructure:"next_selector"`
+
+	// PageURLTemplate genera la URL de cada página. Admite {url} (URL de la categoría)
+	// y {page} (número de página, empezando en 1). Ej: "{url}?page={page}"
+	PageURLTemplate string `mapstructure:"page_url_template"`
+
+	// MaxPages es el máximo de páginas a visitar. 0 usa el valor de scraper.max_pages
+	MaxPages int `mapstructure:"max_pages"`
+
+	// StopWhenNoNew detiene la paginación cuando una página no aporta productos nuevos.
+	// Si es nil se usa el valor de scraper.stop_when_no_new
+	StopWhenNoNew *bool `mapstructure:"stop_when_no_new"`
+}
+
+// maxPages devuelve el número máximo de páginas a visitar
+func (p Pagination) maxPages() int {
+	if p.MaxPages > 0 {
+		return p.MaxPages
+	}
+	if config.Config != nil && config.Config.Scraper.MaxPages > 0 {
+		return config.Config.Scraper.MaxPages
+	}
+	return defaultMaxPages
+}
+
+// stopWhenNoNew indica si hay que parar al encontrar una página sin productos nuevos
+func (p Pagination) stopWhenNoNew() bool {
+	if p.StopWhenNoNew != nil {
+		return *p.StopWhenNoNew
+	}
+	if config.Config != nil {
+		return config.Config.Scraper.StopWhenNoNew
+	}
+	return true
+}
+
+// pageURL construye la URL de una página a partir de la plantilla
+func (p Pagination) pageURL(categoryURL string, page int) string {
+	url := strings.ReplaceAll(p.PageURLTemplate, "{url}", categoryURL)
+	return strings.ReplaceAll(url, "{page}", strconv.Itoa(page))
+}
+
+// pageCrawler recorre secuencialmente las páginas de una categoría con un mismo collector
+// y lleva la cuenta de los productos ya vistos para detectar páginas repetidas o vacías
+type pageCrawler struct {
+	pagination Pagination
+	seen       map[string]bool
+	newItems   int
+	nextURL    string
+}
+
+// newPageCrawler prepara el recorrido de páginas y registra en el collector el selector
+// del enlace a la página siguiente, si lo hay
+func newPageCrawler(c *colly.Collector, pagination Pagination) *pageCrawler {
+	pc := &pageCrawler{
+		pagination: pagination,
+		seen:       make(map[string]bool),
+	}
+
+	if pagination.NextSelector != "" && pagination.PageURLTemplate == "" {
+		c.OnHTML(pagination.NextSelector, func(e *colly.HTMLElement) {
+			if pc.nextURL == "" {
+				pc.nextURL = e.Request.AbsoluteURL(e.Attr("href"))
+			}
+		})
+	}
+
+	return pc
+}
+
+// isNew indica si un producto (identificado por su URL) no ha aparecido en páginas anteriores.
+// Los callbacks del listado deben llamarlo antes de añadir cada producto
+func (pc *pageCrawler) isNew(productURL string) bool {
+	if productURL != "" {
+		if pc.seen[productURL] {
+			return false
+		}
+		pc.seen[productURL] = true
+	}
+	pc.newItems++
+	return true
+}
+
+// crawl visita la primera página de la categoría y las siguientes según la paginación.
+// Solo devuelve error si falla la primera página; un fallo posterior termina el recorrido
+func (pc *pageCrawler) crawl(c *colly.Collector, categoryURL string) error {
+	maxPages := pc.pagination.maxPages()
+	pageURL := categoryURL
+
+	for page := 1; ; page++ {
+		pc.newItems = 0
+		pc.nextURL = ""
+
+		if err := c.Visit(pageURL); err != nil {
+			if page == 1 {
+				return err
+			}
+			log.Printf("Paginación detenida en la página %d (%s): %v", page, pageURL, err)
+			return nil
+		}
+
+		if page >= maxPages {
+			return nil
+		}
+		if pc.newItems == 0 && pc.pagination.stopWhenNoNew() {
+			return nil
+		}
+
+		switch {
+		case pc.pagination.PageURLTemplate != "":
+			pageURL = pc.pagination.pageURL(categoryURL, page+1)
+		case pc.nextURL != "":
+			pageURL = pc.nextURL
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"app/internal/domain/model"
+	"app/pkg/config"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// SchemaOrgScraper es el scraper genérico que el Registry usa para las URLs de tiendas sin
+// implementación propia. Solo obtiene detalles de producto, a partir de los datos estructurados
+// de schema.org (JSON-LD o microdatos) que incluyen la mayoría de tiendas online
+type SchemaOrgScraper struct {
+	Transport http.RoundTripper // Transporte HTTP de colly (nil usa el de por defecto)
+}
+
+// NewSchemaOrgScraper crea una nueva instancia del scraper genérico de schema.org
+func NewSchemaOrgScraper() *SchemaOrgScraper {
+	return &SchemaOrgScraper{}
+}
+
+// ID devuelve el identificador del scraper genérico
+func (s *SchemaOrgScraper) ID() string {
+	return "schema_org"
+}
+
+// Name devuelve el nombre del scraper genérico. Los precios se guardan con el dominio de la
+// tienda (ver storeNameFromURL)
+func (s *SchemaOrgScraper) Name() string {
+	return "Genérico (schema.org)"
+}
+
+// SetTransport sustituye el transporte HTTP usado por el scraper
+func (s *SchemaOrgScraper) SetTransport(transport http.RoundTripper) {
+	s.Transport = transport
+}
+
+// MatchesURL acepta cualquier URL http(s)
+func (s *SchemaOrgScraper) MatchesURL(productURL string) bool {
+	return storeNameFromURL(productURL) != ""
+}
+
+// ScrapCategory no está soportado: sin selectores propios no se pueden recorrer los listados
+func (s *SchemaOrgScraper) ScrapCategory(ctx context.Context, category *model.Category) ([]*model.Product, error) {
+	return nil, fmt.Errorf("el scraper genérico no permite scrapear categorías")
+}
+
+// ScrapProductDetails obtiene los detalles de un producto de cualquier tienda que declare un
+// Product de schema.org en su página
+func (s *SchemaOrgScraper) ScrapProductDetails(ctx context.Context, productURL string) (*model.Product, error) {
+	store := storeNameFromURL(productURL)
+	if store == "" {
+		return nil, fmt.Errorf("URL de producto no válida: %s", productURL)
+	}
+
+	c := newCollector(ctx, s.Transport)
+
+	var product model.Product
+	price := model.Price{IsAvailable: true}
+	var structured *structuredData
+
+	c.OnHTML("html", func(e *colly.HTMLElement) {
+		structured = extractStructuredData(e.DOM, e.Request.AbsoluteURL)
+	})
+
+	c.OnError(func(r *colly.Response, err error) {
+		log.Printf("Error al scrapear detalles del producto %s: %v", r.Request.URL, err)
+	})
+
+	if err := c.Visit(productURL); err != nil {
+		return nil, fmt.Errorf("error al visitar %s: %w", productURL, err)
+	}
+
+	if structured != nil {
+		structured.complete(&product, &price, store)
+	}
+	if product.Name == "" {
+		return nil, fmt.Errorf("la página no declara un producto de schema.org: %s", productURL)
+	}
+	if price.Price == 0 {
+		return nil, fmt.Errorf("la página no declara el precio del producto: %s", productURL)
+	}
+
+	price.Store = store
+	if price.Currency == "" {
+		price.Currency = defaultStructuredCurrency()
+	}
+	price.URL = productURL
+	price.RetrievedAt = time.Now()
+	product.Prices = []model.Price{price}
+
+	return &product, nil
+}
+
+// storeNameFromURL devuelve el dominio de una URL sin "www." (ej: "pccomponentes.com"), que se
+// usa como nombre de la tienda. Devuelve "" si la URL no es http(s)
+func storeNameFromURL(productURL string) string {
+	parsed, err := url.Parse(strings.TrimSpace(productURL))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
+
+// defaultStructuredCurrency es la moneda que se asume cuando la página no declara priceCurrency
+func defaultStructuredCurrency() string {
+	if config.Config != nil && config.Config.Currency.Base != "" {
+		return config.Config.Currency.Base
+	}
+	return "EUR"
+}
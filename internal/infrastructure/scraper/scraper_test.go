@@ -0,0 +1,268 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"app/internal/domain/model"
+	"app/pkg/config"
+	"app/pkg/utils"
+)
+
+// Para regenerar los archivos golden tras un cambio intencionado en el parseo:
+//
+//	go test ./internal/infrastructure/scraper -update
+//
+// Para volver a grabar los fixtures desde las tiendas reales (requiere red):
+//
+//	go test ./internal/infrastructure/scraper -record -update
+var (
+	update = flag.Bool("update", false, "reescribe los archivos golden con el resultado actual")
+	record = flag.Bool("record", false, "graba los fixtures desde las tiendas reales en lugar de reproducirlos")
+)
+
+const (
+	fixturesDir = "testdata/fixtures"
+	goldenDir   = "testdata/golden"
+)
+
+// fixtureTransport devuelve el transporte que sirve (o graba, con -record) los fixtures de los tests
+func fixtureTransport() *FixtureTransport {
+	mode := FixtureReplay
+	if *record {
+		mode = FixtureRecord
+	}
+	return NewFixtureTransport(fixturesDir, mode, nil)
+}
+
+func TestScrapCategoryGolden(t *testing.T) {
+	ebay := NewEbayScraper()
+	ebay.Pagination.MaxPages = 2
+
+	tests := []struct {
+		name     string
+		scraper  StoreScraper
+		category *model.Category
+	}{
+		{"ebay_portatiles", ebay, &model.Category{ID: 1, Name: "Portátiles", Slug: "portatiles"}},
+		{"coolmod_ssd", NewCoolmodScraper(), &model.Category{ID: 6, Name: "Discos SSD", Slug: "ssd"}},
+		{"aussar_tarjetas_graficas", NewAussarScraper(), &model.Category{ID: 2, Name: "Tarjetas Gráficas", Slug: "tarjetas-graficas"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.scraper.(TransportSetter).SetTransport(fixtureTransport())
+
+			products, err := tt.scraper.ScrapCategory(context.Background(), tt.category)
+			if err != nil {
+				t.Fatalf("ScrapCategory() error = %v", err)
+			}
+			if len(products) == 0 {
+				t.Fatal("ScrapCategory() no devolvió productos")
+			}
+
+			assertGolden(t, tt.name, products)
+		})
+	}
+}
+
+func TestScrapProductDetailsGolden(t *testing.T) {
+	tests := []struct {
+		name    string
+		scraper StoreScraper
+		url     string
+	}{
+		{"ebay_detalle", NewEbayScraper(), "https://www.ebay.com/itm/333333333333"},
+		{"coolmod_detalle", NewCoolmodScraper(), "https://www.coolmod.com/samsung-990-pro-2tb-m2-nvme-pcie-40-ssd"},
+		{"aussar_detalle", NewAussarScraper(), "https://www.aussar.es/tarjetas-graficas/101-msi-geforce-rtx-4070-ventus-2x-12g-oc.html"},
+		{"schema_org_jsonld", NewSchemaOrgScraper(), "https://www.tiendaejemplo.es/teclados/logitech-mx-keys-s-grafito"},
+		{"schema_org_microdatos", NewSchemaOrgScraper(), "https://tienda.example.com/p/kingston-fury-beast-32gb-ddr5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.scraper.(TransportSetter).SetTransport(fixtureTransport())
+
+			product, err := tt.scraper.ScrapProductDetails(context.Background(), tt.url)
+			if err != nil {
+				t.Fatalf("ScrapProductDetails() error = %v", err)
+			}
+
+			assertGolden(t, tt.name, []*model.Product{product})
+		})
+	}
+}
+
+func TestRegistryForURLFallback(t *testing.T) {
+	registry := NewRegistry(nil, nil)
+
+	if _, ok := registry.ForURL("https://www.coolmod.com/samsung-990-pro-2tb-m2-nvme-pcie-40-ssd").(*CoolmodScraper); !ok {
+		t.Error("ForURL() de una URL de Coolmod debería devolver su scraper")
+	}
+	if _, ok := registry.ForURL("https://www.tiendaejemplo.es/teclados/logitech-mx-keys-s-grafito").(*SchemaOrgScraper); !ok {
+		t.Error("ForURL() de una tienda sin scraper debería devolver el scraper de schema.org")
+	}
+	if s := registry.ForURL("ftp://tiendaejemplo.es/producto"); s != nil {
+		t.Errorf("ForURL() de una URL que no es http debería devolver nil, devolvió %s", s.Name())
+	}
+}
+
+func TestRegistryConfiguredName(t *testing.T) {
+	registry := NewRegistry([]config.StoreConfig{{ID: "coolmod", Name: "Coolmod ES"}}, nil)
+
+	s := registry.ForURL("https://www.coolmod.com/samsung-990-pro-2tb-m2-nvme-pcie-40-ssd")
+	if s == nil || s.Name() != "Coolmod ES" {
+		t.Errorf("ForURL() debería devolver Coolmod con el nombre configurado, devolvió %v", s)
+	}
+	if s := registry.ForURL("https://www.aussar.es/tarjetas-graficas/"); s == nil || s.Name() != "Aussar" {
+		t.Errorf("una tienda sin nombre configurado debería mantener el de por defecto, devolvió %v", s)
+	}
+}
+
+func TestRegistryEnabledDefaultsToTrue(t *testing.T) {
+	disabled := false
+	registry := NewRegistry([]config.StoreConfig{
+		{ID: "coolmod", Name: "Coolmod ES"}, // Sin "enabled"
+		{ID: "aussar", Enabled: &disabled},
+	}, nil)
+
+	if s := registry.ForURL("https://www.coolmod.com/samsung-990-pro-2tb-m2-nvme-pcie-40-ssd"); s == nil || s.Name() != "Coolmod ES" {
+		t.Errorf("una tienda configurada sin 'enabled' debería estar habilitada, ForURL() devolvió %v", s)
+	}
+	for _, name := range registry.Names() {
+		if name == "Aussar" {
+			t.Error("una tienda con 'enabled: false' no debería estar habilitada")
+		}
+	}
+}
+
+func TestFixtureTransportMissingFixture(t *testing.T) {
+	s := NewAussarScraper()
+	s.SetTransport(NewFixtureTransport(t.TempDir(), FixtureReplay, nil))
+
+	_, err := s.ScrapCategory(context.Background(), &model.Category{ID: 2, Name: "Tarjetas Gráficas", Slug: "tarjetas-graficas"})
+	if err == nil {
+		t.Fatal("ScrapCategory() sin fixtures debería devolver error")
+	}
+}
+
+func TestTrimFixture(t *testing.T) {
+	page := `<html><head><style>body{}</style><script>var tracking = 1;</script>
+<script type="application/ld+json">{"@type":"Product","name":"SSD"}</script></head>
+<body><!-- banner --><svg><path d="M0"/></svg><div class="product-card" itemprop="offers">1.349,95 €</div></body></html>`
+
+	trimmed, err := TrimFixture([]byte(page))
+	if err != nil {
+		t.Fatalf("TrimFixture() error = %v", err)
+	}
+	got := string(trimmed)
+	for _, removed := range []string{"tracking", "body{}", "banner", "<svg"} {
+		if strings.Contains(got, removed) {
+			t.Errorf("TrimFixture() debería quitar %q: %s", removed, got)
+		}
+	}
+	for _, kept := range []string{`"@type":"Product"`, `class="product-card"`, `itemprop="offers"`, "1.349,95 €"} {
+		if !strings.Contains(got, kept) {
+			t.Errorf("TrimFixture() debería conservar %q: %s", kept, got)
+		}
+	}
+}
+
+func TestFetcherRejectsPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	if _, err := NewFetcher(config.ScraperConfig{}, nil).RoundTrip(req); !errors.Is(err, utils.ErrPrivateAddress) {
+		t.Errorf("RoundTrip() a %s error = %v, se esperaba ErrPrivateAddress", server.URL, err)
+	}
+
+	resp, err := NewFetcher(config.ScraperConfig{AllowPrivateNetworks: true}, nil).RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() con AllowPrivateNetworks error = %v", err)
+	}
+	resp.Body.Close()
+}
+
+// redirectTransport responde con una redirección a target a todas las peticiones que no van a
+// target y anota las URLs pedidas
+type redirectTransport struct {
+	target    string
+	requested []string
+}
+
+func (t *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requested = append(t.requested, req.URL.String())
+	header := http.Header{}
+	header.Set("Location", t.target)
+	return &http.Response{
+		StatusCode: http.StatusFound,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+func TestSchemaOrgScraperRejectsPrivateRedirect(t *testing.T) {
+	transport := &redirectTransport{target: "http://169.254.169.254/latest/meta-data/"}
+	s := NewSchemaOrgScraper()
+	s.SetTransport(transport)
+
+	if _, err := s.ScrapProductDetails(context.Background(), "https://www.tiendaejemplo.es/p/redirige"); err == nil {
+		t.Fatal("ScrapProductDetails() debería fallar si la página redirige a una dirección privada")
+	}
+	for _, requested := range transport.requested {
+		if strings.HasPrefix(requested, transport.target) {
+			t.Errorf("no se debería haber pedido %s", requested)
+		}
+	}
+}
+
+// assertGolden compara los productos con testdata/golden/<name>.json (o lo reescribe con -update).
+// La fecha de obtención de los precios se pone a cero para que el resultado sea estable
+func assertGolden(t *testing.T, name string, products []*model.Product) {
+	t.Helper()
+
+	for _, p := range products {
+		for i := range p.Prices {
+			p.Prices[i].RetrievedAt = time.Time{}
+		}
+	}
+
+	got, err := json.MarshalIndent(products, "", "  ")
+	if err != nil {
+		t.Fatalf("error al serializar los productos: %v", err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join(goldenDir, name+".json")
+	if *update {
+		if err := os.MkdirAll(goldenDir, 0755); err != nil {
+			t.Fatalf("error al crear %s: %v", goldenDir, err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("error al escribir %s: %v", path, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error al leer %s (ejecuta con -update para generarlo): %v", path, err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("los productos no coinciden con %s\n--- obtenido ---\n%s\n--- esperado ---\n%s", path, got, want)
+	}
+}
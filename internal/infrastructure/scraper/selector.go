@@ -0,0 +1,440 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"app/internal/domain/model"
+	"app/pkg/config"
+	"app/pkg/utils"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+	"github.com/spf13/viper"
+)
+
+// StoreDefinition describe una tienda de forma declarativa: URLs de categoría, selectores CSS
+// y formato de precio. Se carga desde un archivo YAML del directorio de tiendas, de forma que
+// un cambio en el HTML de la tienda se arregla editando la configuración sin recompilar
+type StoreDefinition struct {
+	ID          string            `mapstructure:"id"`
+	Name        string            `mapstructure:"name"`
+	BaseURL     string            `mapstructure:"base_url"`
+	Domains     []string          `mapstructure:"domains"`
+	Currency    string            `mapstructure:"currency"`
+	PriceLocale string            `mapstructure:"price_locale"`
+	Categories  map[string]string `mapstructure:"categories"`
+	Listing     ListingSelectors  `mapstructure:"listing"`
+	Detail      DetailSelectors   `mapstructure:"detail"`
+	Pagination  Pagination        `mapstructure:"pagination"`
+}
+
+// ListingSelectors contiene los selectores CSS del listado de productos de una categoría.
+// Todos los selectores salvo Item son relativos al elemento de cada producto
+type ListingSelectors struct {
+	Item            string   `mapstructure:"item"`
+	Name            string   `mapstructure:"name"`
+	URL             string   `mapstructure:"url"`
+	Image           string   `mapstructure:"image"`
+	ImageAttrs      []string `mapstructure:"image_attrs"`
+	Price           string   `mapstructure:"price"`
+	PriceDecimals   string   `mapstructure:"price_decimals"`
+	Availability    string   `mapstructure:"availability"`
+	OutOfStockTexts []string `mapstructure:"out_of_stock_texts"`
+	OfferSelectors  `mapstructure:",squash"`
+}
+
+// DetailSelectors contiene los selectores CSS de la página de detalle de un producto
+type DetailSelectors struct {
+	Name            string   `mapstructure:"name"`
+	Description     string   `mapstructure:"description"`
+	Image           string   `mapstructure:"image"`
+	ImageAttrs      []string `mapstructure:"image_attrs"`
+	Price           string   `mapstructure:"price"`
+	Availability    string   `mapstructure:"availability"`
+	OutOfStockTexts []string `mapstructure:"out_of_stock_texts"`
+	GTIN            string   `mapstructure:"gtin"` // EAN/UPC; además se leen siempre los datos estructurados
+	MPN             string   `mapstructure:"mpn"`  // Referencia del fabricante
+	OfferSelectors  `mapstructure:",squash"`
+}
+
+// defaultImageAttrs son los atributos donde se busca la URL de la imagen si no se indican otros
+var defaultImageAttrs = []string{"src", "data-src"}
+
+// defaultOutOfStockTexts son los textos que marcan un producto como no disponible si no se indican otros
+var defaultOutOfStockTexts = []string{"agotado", "no disponible", "sin stock", "out of stock"}
+
+// LoadStoreDefinitions carga todas las definiciones de tienda (*.yaml, *.yml) de un directorio.
+// Si el directorio no existe devuelve una lista vacía sin error
+func LoadStoreDefinitions(dir string) ([]StoreDefinition, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var files []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("error al listar las definiciones de tienda en %s: %w", dir, err)
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+
+	var definitions []StoreDefinition
+	for _, file := range files {
+		def, err := loadStoreDefinition(file)
+		if err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, *def)
+	}
+
+	return definitions, nil
+}
+
+// loadStoreDefinition lee y valida una definición de tienda desde un archivo YAML
+func loadStoreDefinition(file string) (*StoreDefinition, error) {
+	v := viper.New()
+	v.SetConfigFile(file)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("error al leer la definición de tienda %s: %w", file, err)
+	}
+
+	var def StoreDefinition
+	if err := v.Unmarshal(&def); err != nil {
+		return nil, fmt.Errorf("error al interpretar la definición de tienda %s: %w", file, err)
+	}
+
+	if def.ID == "" {
+		def.ID = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+	if err := def.Validate(); err != nil {
+		return nil, fmt.Errorf("definición de tienda %s no válida: %w", file, err)
+	}
+
+	return &def, nil
+}
+
+// Validate comprueba que la definición tenga los campos mínimos para scrapear categorías
+func (d *StoreDefinition) Validate() error {
+	switch {
+	case d.BaseURL == "":
+		return fmt.Errorf("falta base_url")
+	case len(d.Categories) == 0:
+		return fmt.Errorf("no hay ninguna categoría configurada")
+	case d.Listing.Item == "":
+		return fmt.Errorf("falta el selector listing.item")
+	case d.Listing.Name == "":
+		return fmt.Errorf("falta el selector listing.name")
+	case d.Listing.Price == "":
+		return fmt.Errorf("falta el selector listing.price")
+	}
+	return nil
+}
+
+// SelectorScraper es un scraper genérico que se comporta según una StoreDefinition
+type SelectorScraper struct {
+	def       StoreDefinition
+	transport http.RoundTripper
+}
+
+// NewSelectorScraper crea un scraper a partir de una definición declarativa
+func NewSelectorScraper(def StoreDefinition) *SelectorScraper {
+	if def.Name == "" {
+		def.Name = def.ID
+	}
+	if def.Currency == "" {
+		def.Currency = "EUR"
+	}
+	def.BaseURL = strings.TrimRight(def.BaseURL, "/")
+	return &SelectorScraper{def: def}
+}
+
+// storeFactory devuelve el constructor con el que se registra una definición de tienda
+func (d StoreDefinition) storeFactory() StoreFactory {
+	return func(cfg config.StoreConfig) StoreScraper {
+		def := d
+		if cfg.Name != "" {
+			def.Name = cfg.Name
+		}
+		if cfg.BaseURL != "" {
+			def.BaseURL = cfg.BaseURL
+		}
+		if cfg.MaxPages > 0 {
+			def.Pagination.MaxPages = cfg.MaxPages
+		}
+		return NewSelectorScraper(def)
+	}
+}
+
+// ID devuelve el identificador de la tienda en la configuración
+func (s *SelectorScraper) ID() string {
+	return s.def.ID
+}
+
+// Name devuelve el nombre de la tienda que se guarda en los precios
+func (s *SelectorScraper) Name() string {
+	return s.def.Name
+}
+
+// SetTransport sustituye el transporte HTTP usado por el scraper
+func (s *SelectorScraper) SetTransport(transport http.RoundTripper) {
+	s.transport = transport
+}
+
+// MatchesURL indica si la URL pertenece a alguno de los dominios de la tienda
+func (s *SelectorScraper) MatchesURL(rawURL string) bool {
+	domains := s.def.Domains
+	if len(domains) == 0 {
+		if u, err := url.Parse(s.def.BaseURL); err == nil && u.Host != "" {
+			domains = []string{strings.TrimPrefix(u.Host, "www.")}
+		}
+	}
+	for _, domain := range domains {
+		if domain != "" && strings.Contains(rawURL, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// mapCategoryToURL obtiene la URL de una categoría a partir de la definición
+func (s *SelectorScraper) mapCategoryToURL(slug string) (string, error) {
+	path, ok := s.def.Categories[strings.ToLower(slug)]
+	if !ok || path == "" {
+		return "", fmt.Errorf("categoría no soportada para %s: %s", s.Name(), slug)
+	}
+	return s.absoluteURL(path), nil
+}
+
+// absoluteURL completa con BaseURL las URLs relativas
+func (s *SelectorScraper) absoluteURL(path string) string {
+	if path == "" || strings.HasPrefix(path, "http") {
+		return path
+	}
+	if strings.HasPrefix(path, "//") {
+		return "https:" + path
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return s.def.BaseURL + path
+}
+
+// ScrapCategory realiza el scraping de productos para una categoría específica
+func (s *SelectorScraper) ScrapCategory(ctx context.Context, category *model.Category) ([]*model.Product, error) {
+	categoryURL, err := s.mapCategoryToURL(category.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Scraping %s - Categoría: %s, URL: %s", s.Name(), category.Name, categoryURL)
+
+	c := newCollector(ctx, s.transport)
+
+	var products []*model.Product
+	listing := s.def.Listing
+	pages := newPageCrawler(c, s.def.Pagination)
+
+	c.OnHTML(listing.Item, func(e *colly.HTMLElement) {
+		name := firstText(e.DOM, listing.Name)
+		if name == "" {
+			return // Si no hay nombre, ignorar
+		}
+
+		priceText := firstText(e.DOM, listing.Price)
+		if listing.PriceDecimals != "" {
+			// Precio partido en parte entera y decimal (ej: Coolmod)
+			if decimals := firstText(e.DOM, listing.PriceDecimals); decimals != "" {
+				separator := ","
+				if strings.EqualFold(s.def.PriceLocale, "en") {
+					separator = "."
+				}
+				priceText = strings.TrimRight(priceText, ",.") + separator + strings.TrimLeft(decimals, ",.")
+			}
+		}
+
+		price, err := utils.ExtractPriceWithLocale(priceText, s.def.PriceLocale)
+		if err != nil || price <= 0 {
+			return // Solo se guardan productos con precio válido
+		}
+
+		urlSelector := listing.URL
+		if urlSelector == "" {
+			urlSelector = listing.Name
+		}
+		productURL := s.absoluteURL(firstAttr(e.DOM, urlSelector, []string{"href"}))
+
+		imageURL := ""
+		if listing.Image != "" {
+			imageURL = s.absoluteURL(firstAttr(e.DOM, listing.Image, attrsOrDefault(listing.ImageAttrs)))
+		}
+
+		isAvailable := true
+		if listing.Availability != "" {
+			isAvailable = !isOutOfStock(firstText(e.DOM, listing.Availability), listing.OutOfStockTexts)
+		}
+
+		if !pages.isNew(productURL) {
+			return // Ya visto en una página anterior
+		}
+
+		product := &model.Product{
+			Name:       name,
+			Slug:       utils.GenerateSlug(name),
+			ImageURL:   imageURL,
+			CategoryID: category.ID,
+		}
+		offer := model.Price{
+			Store:       s.Name(),
+			Price:       price,
+			Currency:    s.def.Currency,
+			URL:         productURL,
+			IsAvailable: isAvailable,
+			RetrievedAt: time.Now(),
+		}
+		listing.OfferSelectors.apply(e.DOM, s.def.PriceLocale, &offer)
+		product.Prices = []model.Price{offer}
+
+		products = append(products, product)
+	})
+
+	c.OnError(func(r *colly.Response, err error) {
+		log.Printf("Error al scrapear %s: %v", r.Request.URL, err)
+	})
+
+	if err := pages.crawl(c, categoryURL); err != nil {
+		return nil, fmt.Errorf("error al visitar %s: %w", categoryURL, err)
+	}
+
+	log.Printf("Se scrapearon %d productos de %s - %s", len(products), s.Name(), category.Name)
+	return products, nil
+}
+
+// ScrapProductDetails obtiene los detalles completos de un producto específico
+func (s *SelectorScraper) ScrapProductDetails(ctx context.Context, productURL string) (*model.Product, error) {
+	if !s.MatchesURL(productURL) {
+		return nil, fmt.Errorf("la URL no pertenece a %s: %s", s.Name(), productURL)
+	}
+
+	detail := s.def.Detail
+	if detail.Name == "" || detail.Price == "" {
+		return nil, fmt.Errorf("la definición de %s no incluye selectores de detalle", s.Name())
+	}
+
+	c := newCollector(ctx, s.transport)
+
+	var product model.Product
+	price := model.Price{IsAvailable: true}
+	var structured *structuredData
+
+	c.OnHTML("html", func(e *colly.HTMLElement) {
+		product.Name = firstText(e.DOM, detail.Name)
+		product.Slug = utils.GenerateSlug(product.Name)
+
+		if detail.Description != "" {
+			product.Description = firstText(e.DOM, detail.Description)
+		}
+		if detail.Image != "" {
+			product.ImageURL = s.absoluteURL(firstAttr(e.DOM, detail.Image, attrsOrDefault(detail.ImageAttrs)))
+		}
+
+		extractedPrice, err := utils.ExtractPriceWithLocale(firstText(e.DOM, detail.Price), s.def.PriceLocale)
+		if err != nil {
+			log.Printf("Error al convertir precio: %v", err)
+		} else {
+			price.Price = extractedPrice
+		}
+
+		if detail.Availability != "" {
+			price.IsAvailable = !isOutOfStock(firstText(e.DOM, detail.Availability), detail.OutOfStockTexts)
+		}
+
+		detail.OfferSelectors.apply(e.DOM, s.def.PriceLocale, &price)
+
+		if detail.GTIN != "" {
+			utils.AddProductIdentifier(&product, model.IdentifierGTIN, firstText(e.DOM, detail.GTIN), s.Name())
+		}
+		if detail.MPN != "" {
+			utils.AddProductIdentifier(&product, model.IdentifierMPN, firstText(e.DOM, detail.MPN), s.Name())
+		}
+		structured = extractStructuredData(e.DOM, e.Request.AbsoluteURL)
+	})
+
+	c.OnError(func(r *colly.Response, err error) {
+		log.Printf("Error al scrapear detalles del producto %s: %v", r.Request.URL, err)
+	})
+
+	if err := c.Visit(productURL); err != nil {
+		return nil, fmt.Errorf("error al visitar %s: %w", productURL, err)
+	}
+
+	// Completar con los datos de schema.org lo que los selectores de la tienda no encontraron
+	if structured != nil {
+		structured.complete(&product, &price, s.Name())
+	}
+
+	if product.Name == "" {
+		return nil, fmt.Errorf("no se pudo extraer el nombre del producto")
+	}
+
+	price.Store = s.Name()
+	if price.Currency == "" {
+		price.Currency = s.def.Currency
+	}
+	price.URL = productURL
+	price.RetrievedAt = time.Now()
+	product.Prices = []model.Price{price}
+
+	return &product, nil
+}
+
+// firstText devuelve el texto del primer elemento que coincide con el selector
+func firstText(sel *goquery.Selection, selector string) string {
+	return strings.TrimSpace(sel.Find(selector).First().Text())
+}
+
+// firstAttr devuelve el primer atributo no vacío del primer elemento que coincide con el selector
+func firstAttr(sel *goquery.Selection, selector string, attrs []string) string {
+	node := sel.Find(selector).First()
+	for _, attr := range attrs {
+		if value, ok := node.Attr(attr); ok && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// attrsOrDefault devuelve los atributos de imagen configurados o los de por defecto
+func attrsOrDefault(attrs []string) []string {
+	if len(attrs) == 0 {
+		return defaultImageAttrs
+	}
+	return attrs
+}
+
+// isOutOfStock indica si el texto de disponibilidad contiene alguno de los textos de agotado
+func isOutOfStock(text string, outOfStockTexts []string) bool {
+	if len(outOfStockTexts) == 0 {
+		outOfStockTexts = defaultOutOfStockTexts
+	}
+	text = strings.ToLower(text)
+	for _, marker := range outOfStockTexts {
+		if marker != "" && strings.Contains(text, strings.ToLower(marker)) {
+			return true
+		}
+	}
+	return false
+}
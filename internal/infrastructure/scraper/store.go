@@ -0,0 +1,163 @@
+package scraper
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"app/internal/domain/model"
+	"app/pkg/config"
+)
+
+// StoreScraper define el contrato que debe cumplir el scraper de cualquier tienda.
+// Para añadir una tienda nueva basta con implementar esta interfaz y registrarla con Register
+type StoreScraper interface {
+	// ID devuelve el identificador de la tienda usado en la configuración (ej: "ebay")
+	ID() string
+
+	// Name devuelve el nombre de la tienda tal y como se guarda en los precios (ej: "eBay")
+	Name() string
+
+	// ScrapCategory obtiene los productos de una categoría del sistema.
+	// Al cancelar ctx se interrumpen las peticiones pendientes
+	ScrapCategory(ctx context.Context, category *model.Category) ([]*model.Product, error)
+
+	// ScrapProductDetails obtiene los detalles completos de un producto a partir de su URL
+	ScrapProductDetails(ctx context.Context, productURL string) (*model.Product, error)
+
+	// MatchesURL indica si una URL pertenece a esta tienda
+	MatchesURL(url string) bool
+}
+
+// TransportSetter lo implementan los scrapers que permiten sustituir su transporte HTTP
+// (por ejemplo, para grabar o reproducir fixtures)
+type TransportSetter interface {
+	SetTransport(transport http.RoundTripper)
+}
+
+// StoreFactory construye el scraper de una tienda a partir de su configuración
+type StoreFactory func(cfg config.StoreConfig) StoreScraper
+
+// registeredStore asocia el identificador de una tienda con su constructor
+type registeredStore struct {
+	id      string
+	factory StoreFactory
+}
+
+// registeredStores contiene las tiendas registradas, en orden de registro
+var registeredStores []registeredStore
+
+// Register registra un tipo de tienda para que el Registry pueda construirla.
+// Está pensado para llamarse desde la función init() del archivo de cada scraper
+func Register(id string, factory StoreFactory) {
+	id = strings.ToLower(id)
+	for _, store := range registeredStores {
+		if store.id == id {
+			log.Panicf("[SCRAPER] La tienda '%s' ya está registrada", id)
+		}
+	}
+	registeredStores = append(registeredStores, registeredStore{id: id, factory: factory})
+}
+
+// Registry contiene los scrapers de las tiendas habilitadas
+type Registry struct {
+	scrapers []StoreScraper
+	fallback StoreScraper // Scraper de las URLs que no reconoce ninguna tienda habilitada
+}
+
+// NewRegistry construye los scrapers de las tiendas registradas aplicando la sección
+// "stores" de la configuración, respetando el orden en que aparecen en ella.
+// Las definiciones declarativas (YAML) se añaden como tiendas nuevas o sustituyen al
+// scraper registrado con el mismo id.
+// Una tienda registrada que no aparece en la configuración se habilita con sus valores
+// por defecto; para desactivarla hay que indicar "enabled: false"
+func NewRegistry(stores []config.StoreConfig, definitions []StoreDefinition) *Registry {
+	available := append([]registeredStore(nil), registeredStores...)
+	for _, def := range definitions {
+		id := strings.ToLower(def.ID)
+		replaced := false
+		for i := range available {
+			if available[i].id == id {
+				log.Printf("[SCRAPER] La definición YAML de '%s' sustituye al scraper integrado", id)
+				available[i].factory = def.storeFactory()
+				replaced = true
+			}
+		}
+		if !replaced {
+			available = append(available, registeredStore{id: id, factory: def.storeFactory()})
+		}
+	}
+
+	factories := make(map[string]StoreFactory, len(available))
+	for _, store := range available {
+		factories[store.id] = store.factory
+	}
+
+	registry := &Registry{fallback: NewSchemaOrgScraper()}
+	for _, cfg := range stores {
+		id := strings.ToLower(cfg.ID)
+		factory, ok := factories[id]
+		if !ok {
+			log.Printf("[SCRAPER] La tienda '%s' aparece en la configuración pero no tiene scraper registrado", cfg.ID)
+			continue
+		}
+		delete(factories, id)
+
+		if !cfg.IsEnabled() {
+			log.Printf("[SCRAPER] Tienda '%s' desactivada por configuración", id)
+			continue
+		}
+		registry.scrapers = append(registry.scrapers, factory(cfg))
+	}
+
+	// Tiendas registradas sin entrada en la configuración
+	for _, store := range available {
+		if factory, pending := factories[store.id]; pending {
+			registry.scrapers = append(registry.scrapers, factory(config.StoreConfig{ID: store.id}))
+		}
+	}
+
+	return registry
+}
+
+// Scrapers devuelve los scrapers habilitados
+func (r *Registry) Scrapers() []StoreScraper {
+	return r.scrapers
+}
+
+// Names devuelve los nombres de las tiendas habilitadas, en orden
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.scrapers))
+	for _, s := range r.scrapers {
+		names = append(names, s.Name())
+	}
+	return names
+}
+
+// UseTransport hace que todos los scrapers que lo permitan usen el transporte HTTP indicado
+func (r *Registry) UseTransport(transport http.RoundTripper) {
+	for _, s := range r.scrapers {
+		if setter, ok := s.(TransportSetter); ok {
+			setter.SetTransport(transport)
+		}
+	}
+	if setter, ok := r.fallback.(TransportSetter); ok {
+		setter.SetTransport(transport)
+	}
+}
+
+// ForURL devuelve el scraper de la tienda a la que pertenece una URL. Si ninguna tienda
+// habilitada la reconoce se usa el scraper genérico de schema.org; devuelve nil solo si
+// tampoco este la acepta (por ejemplo, una URL que no es http)
+func (r *Registry) ForURL(url string) StoreScraper {
+	for _, s := range r.scrapers {
+		if s.MatchesURL(url) {
+			return s
+		}
+	}
+	if r.fallback != nil && r.fallback.MatchesURL(url) {
+		return r.fallback
+	}
+	return nil
+}
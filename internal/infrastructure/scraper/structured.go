@@ -0,0 +1,381 @@
+package scraper
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"app/internal/domain/model"
+	"app/pkg/utils"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// gtinProperties son las propiedades de schema.org (y variantes habituales) que contienen un GTIN
+var gtinProperties = []string{"gtin", "gtin8", "gtin12", "gtin13", "gtin14", "ean", "upc"}
+
+// availableStates y unavailableStates son los valores de schema.org/ItemAvailability que indican
+// si la oferta se puede comprar. Cualquier otro valor se considera desconocido
+var (
+	availableStates   = []string{"instock", "limitedavailability", "onlineonly", "instoreonly", "preorder", "presale", "backorder", "madetoorder"}
+	unavailableStates = []string{"outofstock", "soldout", "discontinued"}
+)
+
+// brandSpecification es la clave de Specifications en la que se guarda la marca del producto
+const brandSpecification = "Marca"
+
+// structuredData contiene los datos de un producto que una página declara con schema.org,
+// ya sea en JSON-LD o en microdatos. Los campos vacíos no se declaran en la página
+type structuredData struct {
+	Name         string
+	Description  string
+	ImageURL     string
+	Brand        string
+	Price        float64
+	Currency     string
+	Availability string // Valor de schema.org en minúsculas y sin prefijo (ej: "instock")
+	GTINs        []string
+	MPNs         []string
+}
+
+// extractStructuredData lee el primer Product de schema.org de la página. Se da prioridad al
+// JSON-LD y los microdatos solo completan lo que este no declara. resolveURL convierte las URLs
+// relativas de las imágenes en absolutas (puede ser nil)
+func extractStructuredData(doc *goquery.Selection, resolveURL func(string) string) *structuredData {
+	data := &structuredData{}
+	foundJSONLD := false
+
+	doc.Find(`script[type="application/ld+json"]`).Each(func(_ int, sel *goquery.Selection) {
+		decoder := json.NewDecoder(strings.NewReader(sel.Text()))
+		decoder.UseNumber() // Los GTIN y los precios a veces vienen como número
+		var document interface{}
+		if err := decoder.Decode(&document); err != nil {
+			return
+		}
+		// Solo cuenta el primer Product: el resto suelen ser productos relacionados
+		if nodes := findJSONLDNodes(document, "product"); len(nodes) > 0 && !foundJSONLD {
+			data.mergeJSONLD(nodes[0])
+			foundJSONLD = true
+		}
+	})
+
+	doc.Find(`[itemscope][itemtype]`).EachWithBreak(func(_ int, sel *goquery.Selection) bool {
+		if !isSchemaType(sel.AttrOr("itemtype", ""), "product") {
+			return true
+		}
+		data.mergeMicrodata(sel)
+		return false
+	})
+
+	if data.ImageURL != "" && resolveURL != nil {
+		data.ImageURL = resolveURL(data.ImageURL)
+	}
+	return data
+}
+
+// addIdentifiers añade al producto los GTIN y MPN declarados
+func (d *structuredData) addIdentifiers(product *model.Product, source string) {
+	for _, gtin := range d.GTINs {
+		utils.AddProductIdentifier(product, model.IdentifierGTIN, gtin, source)
+	}
+	for _, mpn := range d.MPNs {
+		utils.AddProductIdentifier(product, model.IdentifierMPN, mpn, source)
+	}
+}
+
+// isAvailable indica si la oferta declarada se puede comprar. known es false si la página no
+// declara la disponibilidad o usa un valor que no se reconoce
+func (d *structuredData) isAvailable() (available bool, known bool) {
+	switch {
+	case containsString(availableStates, d.Availability):
+		return true, true
+	case containsString(unavailableStates, d.Availability):
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// complete rellena los datos del producto y de su precio que el scraper de la tienda no ha
+// encontrado con los declarados en schema.org, y añade los identificadores.
+// El precio, la moneda y la disponibilidad solo se toman si la tienda no aportó precio
+func (d *structuredData) complete(product *model.Product, price *model.Price, source string) {
+	if product.Name == "" && d.Name != "" {
+		product.Name = d.Name
+		product.Slug = utils.GenerateSlug(product.Name)
+	}
+	if product.Description == "" {
+		product.Description = d.Description
+	}
+	if product.ImageURL == "" {
+		product.ImageURL = d.ImageURL
+	}
+	if d.Brand != "" {
+		if product.Specifications == nil {
+			product.Specifications = make(map[string]string)
+		}
+		if _, ok := product.Specifications[brandSpecification]; !ok {
+			product.Specifications[brandSpecification] = d.Brand
+		}
+	}
+	d.addIdentifiers(product, source)
+
+	if price.Price == 0 && d.Price > 0 {
+		price.Price = d.Price
+		if d.Currency != "" {
+			price.Currency = d.Currency
+		}
+		if available, known := d.isAvailable(); known {
+			price.IsAvailable = available
+		}
+	}
+}
+
+// mergeJSONLD completa los datos con un nodo Product de JSON-LD
+func (d *structuredData) mergeJSONLD(node map[string]interface{}) {
+	d.setText(&d.Name, jsonLDText(node["name"]))
+	d.setText(&d.Description, jsonLDText(node["description"]))
+	d.setText(&d.ImageURL, jsonLDText(node["image"]))
+	d.setText(&d.Brand, jsonLDText(node["brand"]))
+
+	for _, offer := range findJSONLDNodes(node["offers"], "offer", "aggregateoffer") {
+		if d.Price == 0 {
+			// Las AggregateOffer declaran el precio más bajo en lowPrice
+			for _, key := range []string{"price", "lowPrice"} {
+				if text := jsonLDText(offer[key]); text != "" {
+					d.Price = parseStructuredPrice(text)
+					break
+				}
+			}
+			if spec, ok := offer["priceSpecification"].(map[string]interface{}); ok && d.Price == 0 {
+				d.Price = parseStructuredPrice(jsonLDText(spec["price"]))
+				d.setText(&d.Currency, strings.ToUpper(jsonLDText(spec["priceCurrency"])))
+			}
+		}
+		d.setText(&d.Currency, strings.ToUpper(jsonLDText(offer["priceCurrency"])))
+		d.setText(&d.Availability, schemaValue(jsonLDText(offer["availability"])))
+	}
+
+	// Los identificadores solo se leen en el propio Product y en sus ofertas: los productos
+	// anidados (isRelatedTo, isSimilarTo...) tienen los suyos
+	addIdentifier := func(key, value string) {
+		switch {
+		case key == "mpn":
+			d.MPNs = append(d.MPNs, value)
+		case containsString(gtinProperties, key):
+			d.GTINs = append(d.GTINs, value)
+		}
+	}
+	ownJSONLDProperties(node, addIdentifier)
+	for _, offer := range findJSONLDNodes(node["offers"], "offer", "aggregateoffer") {
+		ownJSONLDProperties(offer, addIdentifier)
+	}
+}
+
+// mergeMicrodata completa los datos con un elemento itemscope de tipo Product
+func (d *structuredData) mergeMicrodata(scope *goquery.Selection) {
+	d.setText(&d.Name, itempropValue(ownItemprop(scope, "name")))
+	d.setText(&d.Description, itempropValue(ownItemprop(scope, "description")))
+	d.setText(&d.ImageURL, itempropValue(ownItemprop(scope, "image")))
+
+	brand := ownItemprop(scope, "brand")
+	if _, nested := brand.Attr("itemscope"); nested {
+		brand = ownItemprop(brand, "name")
+	}
+	d.setText(&d.Brand, itempropValue(brand))
+
+	if d.Price == 0 {
+		for _, property := range []string{"price", "lowPrice"} {
+			sel := scope.Find(`[itemprop="` + property + `"]`).First()
+			if sel.Length() == 0 {
+				continue
+			}
+			if content, ok := sel.Attr("content"); ok {
+				d.Price = parseStructuredPrice(content)
+			} else if value, err := utils.ExtractPrice(sel.Text()); err == nil {
+				d.Price = value
+			}
+			break
+		}
+	}
+	d.setText(&d.Currency, strings.ToUpper(itempropValue(scope.Find(`[itemprop="priceCurrency"]`).First())))
+	d.setText(&d.Availability, schemaValue(itempropValue(scope.Find(`[itemprop="availability"]`).First())))
+
+	// Los identificadores solo se leen en el propio Product y en sus ofertas: los productos
+	// relacionados de la página tienen los suyos
+	for _, property := range gtinProperties {
+		productItemprop(scope, property).Each(func(_ int, sel *goquery.Selection) {
+			d.GTINs = append(d.GTINs, itempropValue(sel))
+		})
+	}
+	productItemprop(scope, "mpn").Each(func(_ int, sel *goquery.Selection) {
+		d.MPNs = append(d.MPNs, itempropValue(sel))
+	})
+}
+
+// setText asigna value a field si este todavía está vacío
+func (d *structuredData) setText(field *string, value string) {
+	if *field == "" {
+		*field = strings.TrimSpace(value)
+	}
+}
+
+// findJSONLDNodes devuelve los objetos de un documento JSON-LD cuyo @type es alguno de los
+// indicados (en minúsculas). No se buscan nodos dentro de los que ya coinciden
+func findJSONLDNodes(data interface{}, types ...string) []map[string]interface{} {
+	var nodes []map[string]interface{}
+	switch node := data.(type) {
+	case map[string]interface{}:
+		for _, t := range types {
+			if jsonLDHasType(node["@type"], t) {
+				return []map[string]interface{}{node}
+			}
+		}
+		keys := make([]string, 0, len(node))
+		for key := range node {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			nodes = append(nodes, findJSONLDNodes(node[key], types...)...)
+		}
+	case []interface{}:
+		for _, item := range node {
+			nodes = append(nodes, findJSONLDNodes(item, types...)...)
+		}
+	}
+	return nodes
+}
+
+// jsonLDHasType indica si el valor de @type (texto o lista) incluye el tipo indicado
+func jsonLDHasType(value interface{}, schemaType string) bool {
+	switch v := value.(type) {
+	case string:
+		return isSchemaType(v, schemaType)
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && isSchemaType(s, schemaType) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isSchemaType compara un tipo de schema.org, con o sin prefijo ("https://schema.org/Product",
+// "schema:Product"), con el nombre indicado en minúsculas
+func isSchemaType(value, schemaType string) bool {
+	return schemaValue(value) == schemaType
+}
+
+// schemaValue quita el prefijo de schema.org de un valor y lo pasa a minúsculas
+// ("https://schema.org/InStock" -> "instock")
+func schemaValue(value string) string {
+	value = strings.TrimSpace(value)
+	if i := strings.LastIndexAny(value, "/:"); i >= 0 {
+		value = value[i+1:]
+	}
+	return strings.ToLower(value)
+}
+
+// jsonLDText devuelve el texto de un valor JSON-LD: los textos y números tal cual, de una lista
+// el primer elemento y de un objeto su "name", "url" o "@id" (marcas, imágenes...)
+func jsonLDText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case json.Number:
+		return v.String()
+	case []interface{}:
+		for _, item := range v {
+			if text := jsonLDText(item); text != "" {
+				return text
+			}
+		}
+	case map[string]interface{}:
+		for _, key := range []string{"name", "url", "@id"} {
+			if text := jsonLDText(v[key]); text != "" {
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// parseStructuredPrice interpreta un precio de schema.org, que usa el punto como separador decimal.
+// Devuelve 0 si el texto no contiene un precio
+func parseStructuredPrice(text string) float64 {
+	value, err := utils.ExtractPriceWithLocale(text, "en")
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// ownItemprop devuelve el primer elemento con la propiedad indicada que pertenece directamente a
+// scope, descartando los de otros itemscope anidados (por ejemplo, el nombre de la marca)
+func ownItemprop(scope *goquery.Selection, property string) *goquery.Selection {
+	return scope.Find(`[itemprop~="` + property + `"]`).FilterFunction(func(_ int, sel *goquery.Selection) bool {
+		return sel.ParentsFiltered("[itemscope]").First().IsSelection(scope)
+	}).First()
+}
+
+// productItemprop devuelve los elementos con la propiedad indicada que pertenecen al Product scope
+// o a una de sus ofertas (itemprop="offers"), descartando los de productos anidados
+func productItemprop(scope *goquery.Selection, property string) *goquery.Selection {
+	return scope.Find(`[itemprop~="` + property + `"]`).FilterFunction(func(_ int, sel *goquery.Selection) bool {
+		owner := sel.ParentsFiltered("[itemscope]").First()
+		if owner.IsSelection(scope) {
+			return true
+		}
+		return owner.Is(`[itemprop~="offers"]`) && owner.ParentsFiltered("[itemscope]").First().IsSelection(scope)
+	})
+}
+
+// itempropValue devuelve el valor de un elemento con microdatos: su atributo content, el enlace
+// de los <link>/<a>, el src de las imágenes o su texto
+func itempropValue(sel *goquery.Selection) string {
+	if sel.Length() == 0 {
+		return ""
+	}
+	if content, ok := sel.Attr("content"); ok {
+		return strings.TrimSpace(content)
+	}
+	switch goquery.NodeName(sel) {
+	case "link", "a":
+		return strings.TrimSpace(sel.AttrOr("href", ""))
+	case "img":
+		return strings.TrimSpace(sel.AttrOr("src", ""))
+	}
+	return strings.TrimSpace(sel.Text())
+}
+
+// ownJSONLDProperties llama a fn con cada propiedad (en minúsculas) de un nodo JSON-LD cuyo valor
+// es un texto o un número, sin entrar en los nodos anidados (productos relacionados, variantes...)
+func ownJSONLDProperties(node map[string]interface{}, fn func(key, value string)) {
+	// Claves ordenadas para que el resultado no dependa del orden de recorrido del mapa
+	keys := make([]string, 0, len(node))
+	for key := range node {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		switch v := node[key].(type) {
+		case string:
+			fn(strings.ToLower(key), v)
+		case json.Number:
+			fn(strings.ToLower(key), v.String())
+		}
+	}
+}
+
+// containsString indica si una lista de textos contiene uno dado
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+
+	"app/internal/domain/model"
+	"app/internal/usecase"
+
+	"github.com/robfig/cron/v3"
+)
+
+// DigestJob envía los resúmenes diarios y semanales de avisos por correo según sus expresiones cron
+type DigestJob struct {
+	cron          *cron.Cron
+	digestUseCase *usecase.DigestUseCase
+	ctx           context.Context // Se cancela en Stop
+	cancel        context.CancelFunc
+}
+
+// NewDigestJob crea el envío programado de resúmenes. Devuelve un error si alguna de las
+// expresiones cron no es válida
+func NewDigestJob(digestUseCase *usecase.DigestUseCase, dailySchedule, weeklySchedule string) (*DigestJob, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &DigestJob{
+		cron:          cron.New(),
+		digestUseCase: digestUseCase,
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+
+	schedules := []struct {
+		spec      string
+		frequency string
+	}{
+		{dailySchedule, model.DigestDaily},
+		{weeklySchedule, model.DigestWeekly},
+	}
+	for _, schedule := range schedules {
+		frequency := schedule.frequency
+		if _, err := job.cron.AddFunc(schedule.spec, func() { job.SendDigests(frequency) }); err != nil {
+			cancel()
+			return nil, fmt.Errorf("expresión cron del resumen %s no válida (%q): %w", frequency, schedule.spec, err)
+		}
+	}
+
+	return job, nil
+}
+
+// Start inicia el envío programado de resúmenes
+func (j *DigestJob) Start() {
+	j.cron.Start()
+	logSuccess("[RESUMEN] Envío de resúmenes de avisos iniciado")
+}
+
+// Stop detiene el envío programado y espera a que termine el envío en curso
+func (j *DigestJob) Stop() {
+	j.cancel()
+	<-j.cron.Stop().Done()
+	logWarning("[RESUMEN] Envío de resúmenes de avisos detenido")
+}
+
+// SendDigests envía los resúmenes de los usuarios con la frecuencia indicada
+func (j *DigestJob) SendDigests(frequency string) {
+	logInfo("[RESUMEN] Enviando los resúmenes %s...", frequency)
+
+	stats, err := j.digestUseCase.SendDigests(j.ctx, frequency)
+	if err != nil && j.ctx.Err() == nil {
+		logError("[RESUMEN] Error al enviar los resúmenes %s: %v", frequency, err)
+	}
+	logSuccess("[RESUMEN] Resúmenes %s: %d enviados, %d sin novedades, %d con error, %d dados de baja",
+		frequency, stats.Sent, stats.Empty, stats.Failed, stats.Skipped)
+}
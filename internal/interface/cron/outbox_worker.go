@@ -0,0 +1,91 @@
+package cron
+
+import (
+	"context"
+	"time"
+
+	"app/internal/usecase"
+)
+
+// outboxPurgeInterval es cada cuánto se eliminan los correos enviados que superan la retención
+const outboxPurgeInterval = time.Hour
+
+// EmailOutboxWorker envía en segundo plano los correos de la bandeja de salida. Hace una pasada
+// cada pollInterval y, además, en cuanto el Mailer avisa de que hay un correo nuevo
+type EmailOutboxWorker struct {
+	outboxUseCase *usecase.EmailOutboxUseCase
+	queued        <-chan struct{}
+	pollInterval  time.Duration
+	ctx           context.Context // Se cancela en Stop
+	cancel        context.CancelFunc
+	done          chan struct{}
+}
+
+// NewEmailOutboxWorker crea el worker de la bandeja de salida. queued es el canal Mailer.Queued
+func NewEmailOutboxWorker(outboxUseCase *usecase.EmailOutboxUseCase, queued <-chan struct{}, pollInterval time.Duration) *EmailOutboxWorker {
+	if pollInterval <= 0 {
+		pollInterval = 15 * time.Second
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &EmailOutboxWorker{
+		outboxUseCase: outboxUseCase,
+		queued:        queued,
+		pollInterval:  pollInterval,
+		ctx:           ctx,
+		cancel:        cancel,
+		done:          make(chan struct{}),
+	}
+}
+
+// Start inicia el worker
+func (w *EmailOutboxWorker) Start() {
+	go w.run()
+	logSuccess("[CORREO] Bandeja de salida iniciada (revisión cada %s)", w.pollInterval)
+}
+
+// Stop detiene el worker y espera a que termine la pasada en curso
+func (w *EmailOutboxWorker) Stop() {
+	w.cancel()
+	<-w.done
+	logWarning("[CORREO] Bandeja de salida detenida")
+}
+
+// run procesa la bandeja hasta que se detiene el worker
+func (w *EmailOutboxWorker) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	var lastPurge time.Time
+	for {
+		w.processOutbox()
+
+		if time.Since(lastPurge) >= outboxPurgeInterval {
+			lastPurge = time.Now()
+			if deleted, err := w.outboxUseCase.PurgeSent(w.ctx); err != nil {
+				logError("[CORREO] Error al eliminar los correos enviados antiguos: %v", err)
+			} else if deleted > 0 {
+				logInfo("[CORREO] Eliminados %d correos enviados antiguos", deleted)
+			}
+		}
+
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.queued:
+		}
+	}
+}
+
+// processOutbox hace una pasada por los correos pendientes
+func (w *EmailOutboxWorker) processOutbox() {
+	stats, err := w.outboxUseCase.ProcessOutbox(w.ctx)
+	if err != nil && w.ctx.Err() == nil {
+		logError("[CORREO] Error al procesar la bandeja de salida: %v", err)
+	}
+	if stats.Sent > 0 || stats.Retried > 0 || stats.Discarded > 0 {
+		logInfo("[CORREO] Enviados: %d | Reintentos pendientes: %d | Descartados: %d", stats.Sent, stats.Retried, stats.Discarded)
+	}
+}
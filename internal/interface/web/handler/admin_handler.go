@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"app/internal/interface/web/views"
+	"app/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler maneja las páginas de administración
+type AdminHandler struct {
+	storeHealthUseCase *usecase.StoreHealthUseCase
+	currencyUseCase    *usecase.CurrencyUseCase
+	emailOutboxUseCase *usecase.EmailOutboxUseCase
+	templateRenderer   *views.TemplateRenderer
+}
+
+// NewAdminHandler crea una nueva instancia del AdminHandler
+func NewAdminHandler(storeHealthUseCase *usecase.StoreHealthUseCase, currencyUseCase *usecase.CurrencyUseCase, emailOutboxUseCase *usecase.EmailOutboxUseCase, templateRenderer *views.TemplateRenderer) *AdminHandler {
+	return &AdminHandler{
+		storeHealthUseCase: storeHealthUseCase,
+		currencyUseCase:    currencyUseCase,
+		emailOutboxUseCase: emailOutboxUseCase,
+		templateRenderer:   templateRenderer,
+	}
+}
+
+// ShowStoreHealth muestra el estado del scraping de cada tienda
+func (h *AdminHandler) ShowStoreHealth(c *gin.Context) {
+	health, err := h.storeHealthUseCase.GetStoreHealth(c.Request.Context())
+	if err != nil {
+		h.templateRenderer.RenderServerError(c, err)
+		return
+	}
+
+	h.templateRenderer.Render(c, http.StatusOK, "store_health.html", gin.H{
+		"Title":  "Estado de las tiendas - Comparador de Precios",
+		"Stores": health,
+	})
+}
+
+// GetStoreHealthAPI devuelve en JSON el estado del scraping de cada tienda
+func (h *AdminHandler) GetStoreHealthAPI(c *gin.Context) {
+	health, err := h.storeHealthUseCase.GetStoreHealth(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"stores": health})
+}
+
+// GetStoreRunsAPI devuelve en JSON las últimas ejecuciones del scraper de una tienda
+func (h *AdminHandler) GetStoreRunsAPI(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limite", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Límite inválido (1-500)"})
+		return
+	}
+
+	runs, err := h.storeHealthUseCase.GetStoreRuns(c.Request.Context(), c.Param("store"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"store": c.Param("store"), "runs": runs})
+}
+
+// ShowExchangeRates muestra los tipos de cambio vigentes y el formulario para introducir uno nuevo
+func (h *AdminHandler) ShowExchangeRates(c *gin.Context) {
+	h.renderExchangeRates(c, http.StatusOK, "")
+}
+
+// SetExchangeRate guarda el tipo de cambio introducido en el formulario y recalcula los precios
+func (h *AdminHandler) SetExchangeRate(c *gin.Context) {
+	rate, err := strconv.ParseFloat(strings.ReplaceAll(c.PostForm("rate"), ",", "."), 64)
+	if err != nil {
+		h.renderExchangeRates(c, http.StatusBadRequest, "El tipo de cambio debe ser un número")
+		return
+	}
+
+	date := time.Now()
+	if dateStr := c.PostForm("date"); dateStr != "" {
+		if date, err = time.Parse("2006-01-02", dateStr); err != nil {
+			h.renderExchangeRates(c, http.StatusBadRequest, "Fecha inválida, usa el formato AAAA-MM-DD")
+			return
+		}
+	}
+
+	if err := h.currencyUseCase.SetRate(c.Request.Context(), c.PostForm("currency"), rate, date); err != nil {
+		h.renderExchangeRates(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/admin/tipos-cambio?success=1")
+}
+
+// GetExchangeRatesAPI devuelve en JSON los tipos de cambio vigentes
+func (h *AdminHandler) GetExchangeRatesAPI(c *gin.Context) {
+	rates, err := h.currencyUseCase.GetLatestRates(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"base":    h.currencyUseCase.BaseCurrency(),
+		"display": h.currencyUseCase.DisplayCurrency(),
+		"rates":   rates,
+	})
+}
+
+// renderExchangeRates renderiza la página de tipos de cambio con un posible mensaje de error
+func (h *AdminHandler) renderExchangeRates(c *gin.Context, status int, errorMessage string) {
+	rates, err := h.currencyUseCase.GetLatestRates(c.Request.Context())
+	if err != nil {
+		h.templateRenderer.RenderServerError(c, err)
+		return
+	}
+
+	h.templateRenderer.Render(c, status, "exchange_rates.html", gin.H{
+		"Title":           "Tipos de cambio - Comparador de Precios",
+		"Rates":           rates,
+		"BaseCurrency":    h.currencyUseCase.BaseCurrency(),
+		"DisplayCurrency": h.currencyUseCase.DisplayCurrency(),
+		"Today":           time.Now().Format("2006-01-02"),
+		"Error":           errorMessage,
+		"Success":         c.Query("success") != "",
+	})
+}
+
+// ShowEmailOutbox muestra el estado de la bandeja de salida de correos: pendientes, enviados,
+// descartados tras agotar los reintentos y los que están reintentándose
+func (h *AdminHandler) ShowEmailOutbox(c *gin.Context) {
+	status, err := h.emailOutboxUseCase.GetOutboxStatus(c.Request.Context())
+	if err != nil {
+		h.templateRenderer.RenderServerError(c, err)
+		return
+	}
+
+	h.templateRenderer.Render(c, http.StatusOK, "email_outbox.html", gin.H{
+		"Title":   "Bandeja de salida - Comparador de Precios",
+		"Outbox":  status,
+		"Error":   c.Query("error"),
+		"Success": c.Query("success") != "",
+	})
+}
+
+// RetryOutboxEmail vuelve a poner en cola un correo descartado
+func (h *AdminHandler) RetryOutboxEmail(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/admin/correos?error="+url.QueryEscape("ID de correo no válido"))
+		return
+	}
+
+	if err := h.emailOutboxUseCase.RetryEmail(c.Request.Context(), uint(id)); err != nil {
+		c.Redirect(http.StatusFound, "/admin/correos?error="+url.QueryEscape(err.Error()))
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/admin/correos?success=1")
+}
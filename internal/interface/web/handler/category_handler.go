@@ -16,13 +16,15 @@ import (
 // CategoryHandler maneja las peticiones para las páginas de categoría
 type CategoryHandler struct {
 	productUseCase   *usecase.ProductUseCase
+	currencyUseCase  *usecase.CurrencyUseCase
 	templateRenderer *views.TemplateRenderer
 }
 
 // NewCategoryHandler crea una nueva instancia del CategoryHandler
-func NewCategoryHandler(productUseCase *usecase.ProductUseCase, templateRenderer *views.TemplateRenderer) *CategoryHandler {
+func NewCategoryHandler(productUseCase *usecase.ProductUseCase, currencyUseCase *usecase.CurrencyUseCase, templateRenderer *views.TemplateRenderer) *CategoryHandler {
 	return &CategoryHandler{
 		productUseCase:   productUseCase,
+		currencyUseCase:  currencyUseCase,
 		templateRenderer: templateRenderer,
 	}
 }
@@ -62,7 +64,8 @@ func (h *CategoryHandler) GetCategory(c *gin.Context) {
 	storeFilter := c.Query("store")
 
 	// Obtener productos
-	products, err := h.productUseCase.GetProductsByCategory(ctx, slug, limit, offset, storeFilter)
+	filter := userOfferFilter(c)
+	products, err := h.productUseCase.GetProductsByCategory(ctx, slug, limit, offset, storeFilter, filter)
 	if err != nil {
 		h.templateRenderer.Render(c, http.StatusInternalServerError, "error.html", gin.H{
 			"Message": "Error al obtener productos",
@@ -75,10 +78,10 @@ func (h *CategoryHandler) GetCategory(c *gin.Context) {
 	sort.SliceStable(products, func(i, j int) bool {
 		var priceI, priceJ float64
 		if len(products[i].Prices) > 0 {
-			priceI = products[i].Prices[0].Price
+			priceI = filter.PriceOf(&products[i].Prices[0])
 		}
 		if len(products[j].Prices) > 0 {
-			priceJ = products[j].Prices[0].Price
+			priceJ = filter.PriceOf(&products[j].Prices[0])
 		}
 		// Si alguno no tiene precio, lo mandamos al final
 		if priceI == 0 {
@@ -97,7 +100,7 @@ func (h *CategoryHandler) GetCategory(c *gin.Context) {
 		if len(p.Prices) > 0 {
 			bestPrice = &p.Prices[0]
 		}
-		productVMs = append(productVMs, views.ToProductViewModel(p, bestPrice))
+		productVMs = append(productVMs, views.ToProductViewModel(p, bestPrice, filter))
 	}
 
 	// Obtener las categorías para el menú de navegación
@@ -195,7 +198,8 @@ func (h *CategoryHandler) GetCategoryAPI(c *gin.Context) {
 		sortOrder = "asc" // Valor por defecto
 	}
 
-	// Leer filtros de precio
+	// Leer filtros de precio (en la moneda del usuario)
+	currency := userCurrency(c)
 	var minPrice, maxPrice float64
 	var err error
 
@@ -213,8 +217,20 @@ func (h *CategoryHandler) GetCategoryAPI(c *gin.Context) {
 		}
 	}
 
-	log.Printf("[API_DEBUG] Parámetros procesados: página=%d, offset=%d, limite=%d, filtroTienda=%s, ordenación=%s, precioMin=%.2f, precioMax=%.2f",
-		page+1, offset, limit, storeFilter, sortOrder, minPrice, maxPrice)
+	minPrice = h.currencyUseCase.ToComparison(minPrice, currency)
+	maxPrice = h.currencyUseCase.ToComparison(maxPrice, currency)
+
+	// Leer filtros de ofertas (solo artículos nuevos, sin subastas) y si se compara por coste total,
+	// que por defecto es lo que el usuario ha elegido en su perfil
+	offers := userOfferFilter(c)
+	offers.NewOnly = c.Query("new_only") == "1"
+	offers.ExcludeAuctions = c.Query("exclude_auctions") == "1"
+	if landed := c.Query("landed_cost"); landed != "" {
+		offers.LandedCost = landed == "1"
+	}
+
+	log.Printf("[API_DEBUG] Parámetros procesados: página=%d, offset=%d, limite=%d, filtroTienda=%s, ordenación=%s, precioMin=%.2f, precioMax=%.2f, soloNuevos=%t, sinSubastas=%t, costeTotal=%t",
+		page+1, offset, limit, storeFilter, sortOrder, minPrice, maxPrice, offers.NewOnly, offers.ExcludeAuctions, offers.LandedCost)
 
 	// IMPORTANTE: Modificamos el enfoque para obtener los productos ya filtrados y ordenados desde la base de datos
 	// Creamos un objeto de opciones de filtrado para pasar al usecase
@@ -226,6 +242,7 @@ func (h *CategoryHandler) GetCategoryAPI(c *gin.Context) {
 		SortOrder:    sortOrder,
 		MinPrice:     minPrice,
 		MaxPrice:     maxPrice,
+		Offers:       offers,
 	}
 
 	// Obtener productos filtrados y ordenados directamente desde la base de datos
@@ -245,7 +262,7 @@ func (h *CategoryHandler) GetCategoryAPI(c *gin.Context) {
 	for _, product := range products {
 		if len(product.Prices) == 0 {
 			// Si no tiene precios cargados, intentamos cargarlos
-			productWithPrices, err := h.productUseCase.GetProductWithPrices(ctx, product.ID)
+			productWithPrices, err := h.productUseCase.GetProductWithPrices(ctx, product.ID, offers)
 			if err == nil && productWithPrices != nil && len(productWithPrices.Prices) > 0 {
 				product.Prices = productWithPrices.Prices
 			}
@@ -257,7 +274,8 @@ func (h *CategoryHandler) GetCategoryAPI(c *gin.Context) {
 		ID         uint    `json:"id"`
 		Name       string  `json:"name"`
 		ImageURL   string  `json:"image_url"`
-		BestPrice  float64 `json:"best_price,omitempty"`
+		BestPrice  float64 `json:"best_price,omitempty"` // En la moneda del usuario
+		BestText   string  `json:"best_price_text,omitempty"`
 		BestStore  string  `json:"best_store,omitempty"`
 		CategoryID uint    `json:"category_id"`
 		Category   struct {
@@ -286,17 +304,19 @@ func (h *CategoryHandler) GetCategoryAPI(c *gin.Context) {
 			},
 		}
 
-		// Añadir el mejor precio si existe
-		if len(p.Prices) > 0 {
-			// Encontrar el mejor precio (el más bajo)
-			bestPrice := p.Prices[0] // Empezamos con el primer precio
-			for i := 1; i < len(p.Prices); i++ {
-				if p.Prices[i].Price < bestPrice.Price {
-					bestPrice = p.Prices[i]
-				}
+		// Añadir el mejor precio (el más bajo entre las ofertas que pasan el filtro) si existe
+		var bestPrice *model.Price
+		for i := range p.Prices {
+			if !offers.Matches(&p.Prices[i]) {
+				continue
 			}
-
-			prod.BestPrice = bestPrice.Price
+			if bestPrice == nil || offers.PriceOf(&p.Prices[i]) < offers.PriceOf(bestPrice) {
+				bestPrice = &p.Prices[i]
+			}
+		}
+		if bestPrice != nil {
+			prod.BestPrice, _ = h.currencyUseCase.FromComparison(offers.PriceOf(bestPrice), currency)
+			prod.BestText = h.currencyUseCase.Format(offers.PriceOf(bestPrice), currency)
 			prod.BestStore = bestPrice.Store
 		}
 
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"app/internal/domain/model"
+	"app/internal/interface/web/views"
+	"app/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailPreferencesHandler maneja los tipos de correo que recibe el usuario y las bajas desde los
+// enlaces de los correos
+type EmailPreferencesHandler struct {
+	userUseCase      *usecase.UserUseCase
+	digestUseCase    *usecase.DigestUseCase
+	templateRenderer *views.TemplateRenderer
+}
+
+// NewEmailPreferencesHandler crea una nueva instancia del EmailPreferencesHandler
+func NewEmailPreferencesHandler(userUseCase *usecase.UserUseCase, digestUseCase *usecase.DigestUseCase, templateRenderer *views.TemplateRenderer) *EmailPreferencesHandler {
+	return &EmailPreferencesHandler{
+		userUseCase:      userUseCase,
+		digestUseCase:    digestUseCase,
+		templateRenderer: templateRenderer,
+	}
+}
+
+// emailPreferencesRequest son los datos del formulario de preferencias de correo
+type emailPreferencesRequest struct {
+	Alerts          bool   `form:"email_alerts"`
+	Digest          bool   `form:"email_digest"`
+	Account         bool   `form:"email_account"`
+	DigestFrequency string `form:"digest_frequency"`
+}
+
+// emailCategoryNames son los nombres de los tipos de correo que se muestran al darse de baja
+var emailCategoryNames = map[string]string{
+	model.EmailCategoryAlerts:  "alertas de precio y búsquedas guardadas",
+	model.EmailCategoryDigest:  "resúmenes de avisos",
+	model.EmailCategoryAccount: "avisos de cuenta y seguridad",
+}
+
+// ShowEmailPreferences muestra los tipos de correo que acepta el usuario
+func (h *EmailPreferencesHandler) ShowEmailPreferences(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.Redirect(http.StatusFound, "/login")
+		return
+	}
+
+	allCategories, _ := c.Get("allCategories")
+	h.templateRenderer.Render(c, http.StatusOK, "email_preferences.html", gin.H{
+		"Title":           "Preferencias de correo - Comparador de Precios",
+		"Categories":      allCategories,
+		"User":            user,
+		"DigestImmediate": model.DigestImmediate,
+		"DigestDaily":     model.DigestDaily,
+		"DigestWeekly":    model.DigestWeekly,
+		"Error":           c.Query("error"),
+		"Success":         c.Query("success"),
+	})
+}
+
+// SaveEmailPreferences guarda los tipos de correo que acepta el usuario y la frecuencia de los avisos
+func (h *EmailPreferencesHandler) SaveEmailPreferences(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.Redirect(http.StatusFound, "/login")
+		return
+	}
+
+	var req emailPreferencesRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.Redirect(http.StatusFound, "/perfil/correo?error="+url.QueryEscape("Datos del formulario no válidos"))
+		return
+	}
+	ctx := c.Request.Context()
+
+	prefs := usecase.EmailPreferences{Alerts: req.Alerts, Digest: req.Digest, Account: req.Account}
+	if err := h.userUseCase.UpdateEmailPreferences(ctx, user.ID, prefs); err != nil {
+		c.Redirect(http.StatusFound, "/perfil/correo?error="+url.QueryEscape(err.Error()))
+		return
+	}
+	if req.DigestFrequency != "" {
+		if err := h.digestUseCase.SetDigestFrequency(ctx, user.ID, req.DigestFrequency); err != nil {
+			c.Redirect(http.StatusFound, "/perfil/correo?error="+url.QueryEscape(err.Error()))
+			return
+		}
+	}
+
+	c.Redirect(http.StatusFound, "/perfil/correo?success="+url.QueryEscape("Preferencias de correo guardadas"))
+}
+
+// ShowUnsubscribe muestra la confirmación de baja de un enlace de correo. La baja no se aplica con
+// GET porque los antivirus y las vistas previas de los clientes de correo abren los enlaces
+func (h *EmailPreferencesHandler) ShowUnsubscribe(c *gin.Context) {
+	token := c.Query("token")
+	user, category, err := h.userUseCase.CheckUnsubscribeLink(c.Request.Context(), token)
+	if err != nil {
+		h.renderInvalidUnsubscribe(c)
+		return
+	}
+
+	h.templateRenderer.Render(c, http.StatusOK, "unsubscribe.html", gin.H{
+		"Title":        "Darse de baja - Comparador de Precios",
+		"Token":        token,
+		"Email":        user.Email,
+		"CategoryName": emailCategoryNames[category],
+		"Done":         !user.AcceptsEmail(category),
+	})
+}
+
+// Unsubscribe aplica la baja de un enlace de correo. Sirve tanto para el botón de la página de
+// confirmación como para la baja en un clic de los clientes de correo (RFC 8058), que hacen un POST
+// a la URL de la cabecera List-Unsubscribe sin cookies de sesión
+func (h *EmailPreferencesHandler) Unsubscribe(c *gin.Context) {
+	user, category, err := h.userUseCase.Unsubscribe(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidUnsubscribeLink) {
+			h.renderInvalidUnsubscribe(c)
+			return
+		}
+		h.templateRenderer.RenderServerError(c, err)
+		return
+	}
+
+	h.templateRenderer.Render(c, http.StatusOK, "unsubscribe.html", gin.H{
+		"Title":        "Baja confirmada - Comparador de Precios",
+		"Email":        user.Email,
+		"CategoryName": emailCategoryNames[category],
+		"Done":         true,
+	})
+}
+
+// renderInvalidUnsubscribe muestra el error de un enlace para darse de baja no válido
+func (h *EmailPreferencesHandler) renderInvalidUnsubscribe(c *gin.Context) {
+	h.templateRenderer.Render(c, http.StatusBadRequest, "error.html", gin.H{
+		"Title":   "Enlace no válido - Comparador de Precios",
+		"Message": "El enlace para darse de baja no es válido. Puedes cambiar los correos que recibes en tus preferencias de correo.",
+	})
+}
@@ -32,7 +32,8 @@ func (h *HomeHandler) GetHome(c *gin.Context) {
 
 	// Obtener los mejores productos
 	ctx := c.Request.Context()
-	products, err := h.productUseCase.GetBestDeals(ctx, 12) // Mostrar 12 productos en la página principal
+	filter := userOfferFilter(c)
+	products, err := h.productUseCase.GetBestDeals(ctx, 12, filter) // Mostrar 12 productos en la página principal
 	if err != nil {
 		h.templateRenderer.Render(c, http.StatusInternalServerError, "error.html", gin.H{
 			"Message": "Error al obtener productos",
@@ -48,7 +49,7 @@ func (h *HomeHandler) GetHome(c *gin.Context) {
 		if len(p.Prices) > 0 {
 			bestPrice = &p.Prices[0]
 		}
-		productVMs = append(productVMs, views.ToProductViewModel(p, bestPrice))
+		productVMs = append(productVMs, views.ToProductViewModel(p, bestPrice, filter))
 	}
 
 	// Obtener las categorías para el menú de navegación
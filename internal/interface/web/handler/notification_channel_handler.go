@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"app/internal/domain/model"
+	"app/internal/infrastructure/notifier"
+	"app/internal/interface/web/views"
+	"app/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationChannelHandler maneja los canales por los que el usuario recibe sus avisos (correo y
+// webhook)
+type NotificationChannelHandler struct {
+	notificationChannelUseCase *usecase.NotificationChannelUseCase
+	templateRenderer           *views.TemplateRenderer
+}
+
+// NewNotificationChannelHandler crea una nueva instancia del NotificationChannelHandler
+func NewNotificationChannelHandler(notificationChannelUseCase *usecase.NotificationChannelUseCase, templateRenderer *views.TemplateRenderer) *NotificationChannelHandler {
+	return &NotificationChannelHandler{
+		notificationChannelUseCase: notificationChannelUseCase,
+		templateRenderer:           templateRenderer,
+	}
+}
+
+// notificationChannelsRequest son los datos del formulario de canales. El correo se configura en
+// la página de preferencias de correo (EmailPreferencesHandler)
+type notificationChannelsRequest struct {
+	WebhookURL       string `form:"webhook_url"`
+	WebhookEnabled   bool   `form:"webhook_enabled"`
+	RegenerateSecret bool   `form:"regenerate_secret"`
+}
+
+// ShowNotificationChannels muestra la configuración de canales del usuario
+func (h *NotificationChannelHandler) ShowNotificationChannels(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.Redirect(http.StatusFound, "/login")
+		return
+	}
+
+	webhook, err := h.notificationChannelUseCase.GetWebhook(c.Request.Context(), user.ID)
+	if err != nil {
+		h.templateRenderer.RenderServerError(c, err)
+		return
+	}
+
+	allCategories, _ := c.Get("allCategories")
+	h.templateRenderer.Render(c, http.StatusOK, "notification_channels.html", gin.H{
+		"Title":           "Canales de aviso - Comparador de Precios",
+		"Categories":      allCategories,
+		"User":            user,
+		"Webhook":         webhook,
+		"DigestWeekly":    model.DigestWeekly,
+		"HeaderEvent":     notifier.HeaderEvent,
+		"HeaderDelivery":  notifier.HeaderDelivery,
+		"HeaderTimestamp": notifier.HeaderTimestamp,
+		"HeaderSignature": notifier.HeaderSignature,
+		"Error":           c.Query("error"),
+		"Success":         c.Query("success"),
+	})
+}
+
+// SaveNotificationChannels guarda el webhook del usuario
+func (h *NotificationChannelHandler) SaveNotificationChannels(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.Redirect(http.StatusFound, "/login")
+		return
+	}
+
+	var req notificationChannelsRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.Redirect(http.StatusFound, "/perfil/avisos?error="+url.QueryEscape("Datos del formulario no válidos"))
+		return
+	}
+
+	if _, err := h.notificationChannelUseCase.SaveWebhook(c.Request.Context(), user.ID, req.WebhookURL, req.WebhookEnabled, req.RegenerateSecret); err != nil {
+		message := "No se pudo guardar el webhook"
+		if errors.Is(err, usecase.ErrInvalidWebhook) {
+			message = err.Error()
+		}
+		c.Redirect(http.StatusFound, "/perfil/avisos?error="+url.QueryEscape(message))
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/perfil/avisos?success="+url.QueryEscape("Canales de aviso guardados"))
+}
+
+// TestWebhook envía un aviso de prueba al webhook del usuario
+func (h *NotificationChannelHandler) TestWebhook(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.Redirect(http.StatusFound, "/login")
+		return
+	}
+
+	if err := h.notificationChannelUseCase.SendTestWebhook(c.Request.Context(), user); err != nil {
+		c.Redirect(http.StatusFound, "/perfil/avisos?error="+url.QueryEscape("El aviso de prueba ha fallado: "+err.Error()))
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/perfil/avisos?success="+url.QueryEscape("Aviso de prueba entregado al webhook"))
+}
+
+// currentUser devuelve el usuario cargado por el middleware LoadUser
+func currentUser(c *gin.Context) (*model.User, bool) {
+	value, exists := c.Get("user")
+	if !exists {
+		return nil, false
+	}
+	user, ok := value.(*model.User)
+	return user, ok && user != nil
+}
+
+// userCurrency devuelve la moneda en la que el usuario ve los precios (middleware.IncludePricePreferences),
+// que es también en la que escribe los importes de los formularios
+func userCurrency(c *gin.Context) string {
+	return c.GetString("Currency")
+}
+
+// userOfferFilter devuelve el filtro de ofertas con el que el usuario ve los listados y el mejor
+// precio: compara por coste total si lo ha elegido en su perfil (middleware.IncludePricePreferences)
+func userOfferFilter(c *gin.Context) model.OfferFilter {
+	return model.OfferFilter{LandedCost: c.GetBool("CompareLandedCost")}
+}
@@ -2,9 +2,15 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
+	"app/internal/domain/model"
+	"app/internal/infrastructure/realtime"
 	"app/internal/interface/web/views"
 	"app/internal/usecase"
 
@@ -15,13 +21,20 @@ import (
 // NotificationHandler maneja las peticiones relacionadas con notificaciones
 type NotificationHandler struct {
 	notificationUseCase *usecase.PriceAlertUseCase
+	broker              *realtime.Broker // Notificaciones nuevas para las pestañas abiertas
+	streamHeartbeat     time.Duration    // Cada cuánto se mantiene viva la conexión en tiempo real
 	templateRenderer    *views.TemplateRenderer
 }
 
 // NewNotificationHandler crea una nueva instancia del NotificationHandler
-func NewNotificationHandler(notificationUseCase *usecase.PriceAlertUseCase, templateRenderer *views.TemplateRenderer) *NotificationHandler {
+func NewNotificationHandler(notificationUseCase *usecase.PriceAlertUseCase, broker *realtime.Broker, streamHeartbeat time.Duration, templateRenderer *views.TemplateRenderer) *NotificationHandler {
+	if streamHeartbeat <= 0 {
+		streamHeartbeat = 25 * time.Second
+	}
 	return &NotificationHandler{
 		notificationUseCase: notificationUseCase,
+		broker:              broker,
+		streamHeartbeat:     streamHeartbeat,
 		templateRenderer:    templateRenderer,
 	}
 }
@@ -165,6 +178,88 @@ func (h *NotificationHandler) DeleteReadNotifications(c *gin.Context) {
 	})
 }
 
+// StreamNotifications mantiene abierta una conexión Server-Sent Events por la que se envían al
+// momento las notificaciones nuevas del usuario, para actualizar el contador y avisar en cualquier
+// pestaña sin recargar. Al conectar se envía el contador actual (evento "unread") y después un
+// evento "notification" por cada notificación nueva
+func (h *NotificationHandler) StreamNotifications(c *gin.Context) {
+	userID := sessions.Default(c).Get("user_id")
+	if userID == nil {
+		// Con 401 el navegador no reintenta la conexión
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Acceso no autorizado"})
+		return
+	}
+
+	sub, err := h.broker.Subscribe(userID.(uint))
+	if err != nil {
+		status := http.StatusServiceUnavailable
+		if errors.Is(err, realtime.ErrTooManySubscriptions) {
+			status = http.StatusTooManyRequests
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Que nginx no acumule los eventos
+	c.Status(http.StatusOK)
+
+	// Tras cortarse la conexión, el navegador la reabre pasado este tiempo y recibe otra vez el contador
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", (5 * time.Second).Milliseconds())
+	ctx := c.Request.Context()
+	c.SSEvent("unread", gin.H{"count": h.unreadCount(ctx, userID.(uint))})
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notification, ok := <-sub.Events():
+			if !ok {
+				// El broker se ha cerrado: la aplicación se está apagando
+				return
+			}
+			c.SSEvent("notification", notificationEvent(notification, h.unreadCount(ctx, userID.(uint))))
+		case <-heartbeat.C:
+			// Los comentarios no llegan a la página, pero evitan que los proxies cierren la conexión
+			if _, err := c.Writer.WriteString(": ping\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+// notificationEvent prepara los datos del evento "notification" de StreamNotifications
+func notificationEvent(notification *model.Notification, unread int) gin.H {
+	return gin.H{
+		"id":           notification.ID,
+		"title":        notification.Title,
+		"message":      notification.Message,
+		"product_id":   notification.ProductID,
+		"url":          fmt.Sprintf("/producto/%d", notification.ProductID),
+		"created_at":   notification.CreatedAt,
+		"unread_count": unread,
+	}
+}
+
+// unreadCount cuenta las notificaciones no leídas del usuario para los eventos en tiempo real. Si
+// falla devuelve -1 y la página mantiene su contador
+func (h *NotificationHandler) unreadCount(ctx context.Context, userID uint) int {
+	count, err := h.notificationUseCase.GetUnreadNotificationsCount(ctx, userID)
+	if err != nil {
+		log.Printf("[NOTIFICACIONES] Error al contar las notificaciones no leídas del usuario %d: %v", userID, err)
+		return -1
+	}
+	return count
+}
+
 // getUnreadNotificationsCount obtiene el número de notificaciones no leídas para un usuario
 func (h *NotificationHandler) getUnreadNotificationsCount(userID uint) int {
 	notifications, err := h.notificationUseCase.GetUserNotifications(context.Background(), userID)
@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"app/internal/domain/model"
 	"app/internal/domain/repositories"
@@ -21,6 +25,7 @@ type PriceAlertHandler struct {
 	productUseCase    *usecase.ProductUseCase
 	watchlistRepo     repositories.WatchlistRepository
 	watchlistItemRepo repositories.WatchlistItemRepository
+	currencyUseCase   *usecase.CurrencyUseCase
 	templateRenderer  *views.TemplateRenderer
 }
 
@@ -30,6 +35,7 @@ func NewPriceAlertHandler(
 	productUseCase *usecase.ProductUseCase,
 	watchlistRepo repositories.WatchlistRepository,
 	watchlistItemRepo repositories.WatchlistItemRepository,
+	currencyUseCase *usecase.CurrencyUseCase,
 	templateRenderer *views.TemplateRenderer,
 ) *PriceAlertHandler {
 	return &PriceAlertHandler{
@@ -37,6 +43,7 @@ func NewPriceAlertHandler(
 		productUseCase:    productUseCase,
 		watchlistRepo:     watchlistRepo,
 		watchlistItemRepo: watchlistItemRepo,
+		currencyUseCase:   currencyUseCase,
 		templateRenderer:  templateRenderer,
 	}
 }
@@ -64,8 +71,29 @@ func (h *PriceAlertHandler) SetPriceAlert(c *gin.Context) {
 	productIDStr := c.PostForm("product_id")
 	targetPriceStr := c.PostForm("target_price")
 
-	// Siempre enviar notificación por email
-	notifyByEmail := true
+	// Aviso por correo (casilla del formulario, marcada por defecto)
+	notifyByEmail := c.PostForm("notify_by_email") != ""
+
+	// Ofertas que cuentan para la alerta, comparadas por precio o coste total según el perfil
+	offerFilter := userOfferFilter(c)
+	offerFilter.NewOnly = c.PostForm("new_only") != ""
+	offerFilter.ExcludeAuctions = c.PostForm("exclude_auctions") != ""
+
+	// Cuándo se vuelve a notificar (si el formulario no lo indica se mantiene el de la alerta)
+	var notifyPolicy *model.NotifyPolicy
+	if mode := c.PostForm("notify_mode"); mode != "" {
+		cooldownHours, _ := strconv.Atoi(c.PostForm("cooldown_hours"))
+		policy := model.NotifyPolicy{NotifyMode: mode, CooldownHours: cooldownHours}.Normalize()
+		notifyPolicy = &policy
+	}
+
+	// Regla de la alerta (si el formulario no la indica, precio objetivo)
+	ruleValue, _ := strconv.ParseFloat(strings.ReplaceAll(c.PostForm("rule_value"), ",", "."), 64)
+	rule := model.AlertRule{
+		RuleType:  c.PostForm("rule_type"),
+		RuleValue: ruleValue,
+		RuleStore: c.PostForm("rule_store"),
+	}.Normalize()
 
 	productID, err := strconv.ParseUint(productIDStr, 10, 32)
 	if err != nil {
@@ -83,7 +111,7 @@ func (h *PriceAlertHandler) SetPriceAlert(c *gin.Context) {
 		return
 	}
 
-	targetPrice, err := strconv.ParseFloat(targetPriceStr, 64)
+	targetPrice, err := parseTargetPrice(targetPriceStr)
 	if err != nil {
 		if isAjax {
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -99,35 +127,201 @@ func (h *PriceAlertHandler) SetPriceAlert(c *gin.Context) {
 		return
 	}
 
-	// Verificar si ya existe una alerta para este producto y usuario
+	// El precio objetivo se escribe en la moneda del usuario y se guarda en la de visualización
+	targetPrice = h.currencyUseCase.ToComparison(targetPrice, userCurrency(c))
+
 	ctx := c.Request.Context()
-	alerts, err := h.priceAlertUseCase.GetUserAlerts(ctx, userID.(uint))
+	savedAlert, isUpdate, err := h.saveUserAlert(ctx, userID.(uint), uint(productID), targetPrice, &notifyByEmail, offerFilter, notifyPolicy, rule)
 	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, usecase.ErrInvalidAlertRule) {
+			status = http.StatusBadRequest
+		}
 		if isAjax {
-			c.JSON(http.StatusInternalServerError, gin.H{
+			c.JSON(status, gin.H{
 				"success": false,
-				"error":   "Error al verificar alertas existentes: " + err.Error(),
+				"error":   "Error al guardar la alerta de precio: " + err.Error(),
 			})
 			return
 		}
-		h.templateRenderer.Render(c, http.StatusInternalServerError, "error.html", gin.H{
-			"Message": "Error al verificar alertas existentes",
+		h.templateRenderer.Render(c, status, "error.html", gin.H{
+			"Message": "Error al guardar la alerta de precio",
 			"Error":   err.Error(),
 		})
 		return
 	}
 
+	// Si llegamos aquí, la operación fue exitosa (creación o actualización)
+	// y la sincronización con watchlist_items se intentó (los errores se loguearon pero no detuvieron el flujo principal de la alerta).
+
+	// Determinar mensaje de éxito
+	successMessage := "Producto añadido a tu cesta correctamente."
+	if isUpdate {
+		successMessage = "Este producto ya estaba en tu cesta. Alerta actualizada correctamente."
+	}
+
+	if isAjax {
+		log.Printf("[SetPriceAlert] Operación AJAX exitosa (isUpdate: %t) para producto %s. Devolviendo JSON.", isUpdate, productIDStr)
+		responseData := gin.H{
+			"success":   true,
+			"message":   successMessage,
+			"is_update": isUpdate,
+		}
+		if savedAlert != nil {
+			responseData["alert_id"] = savedAlert.ID
+		}
+		c.JSON(http.StatusOK, responseData)
+		return // Asegurar que la función termina aquí para la respuesta AJAX
+	}
+
+	// Flujo no-AJAX (si alguna vez se usa): Redirigir con un mensaje flash
+	log.Printf("[SetPriceAlert] Operación NO-AJAX exitosa (isUpdate: %t) para producto %s. Redirigiendo.", isUpdate, productIDStr)
+	session := sessions.Default(c)
+	session.AddFlash(successMessage, "success_message") // Usar una clave consistente para mensajes flash
+	if err := session.Save(); err != nil {
+		log.Printf("[SetPriceAlert] Error al guardar sesión para flash message: %v", err)
+		// No es crítico, continuar con la redirección
+	}
+	// Redirigir de vuelta a la página del producto. El productoIDStr ya fue validado.
+	c.Redirect(http.StatusFound, "/producto/"+productIDStr)
+}
+
+// alertRequest son los datos JSON para crear o actualizar una alerta de precio por API
+type alertRequest struct {
+	ProductID       uint    `json:"product_id"`
+	RuleType        string  `json:"rule_type"`    // target_price (por defecto), percent_drop, any_drop, all_time_low, back_in_stock, store_price o below_average
+	TargetPrice     float64 `json:"target_price"` // target_price y store_price, en la moneda del usuario
+	RuleValue       float64 `json:"rule_value"`   // Porcentaje (percent_drop) o días (below_average)
+	RuleStore       string  `json:"rule_store"`   // store_price
+	NewOnly         bool    `json:"new_only"`
+	ExcludeAuctions bool    `json:"exclude_auctions"`
+	NotifyMode      string  `json:"notify_mode"` // Vacío para mantener el de la alerta existente
+	CooldownHours   int     `json:"cooldown_hours"`
+	NotifyByEmail   *bool   `json:"notify_by_email"` // Sin indicar: se mantiene el de la alerta existente (sí en las nuevas)
+}
+
+// GetPriceAlertsAPI devuelve en JSON las alertas del usuario con la descripción de su regla
+func (h *PriceAlertHandler) GetPriceAlertsAPI(c *gin.Context) {
+	userID := sessions.Default(c).Get("user_id")
+	if userID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Debe iniciar sesión para ver sus alertas"})
+		return
+	}
+
+	alerts, err := h.priceAlertUseCase.GetUserAlerts(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las alertas: " + err.Error()})
+		return
+	}
+
+	response := make([]gin.H, 0, len(alerts))
+	for _, alert := range alerts {
+		response = append(response, h.alertResponse(alert, userCurrency(c)))
+	}
+	c.JSON(http.StatusOK, gin.H{"alerts": response})
+}
+
+// SetPriceAlertAPI crea o actualiza, a partir de JSON, la alerta del usuario para un producto con
+// cualquiera de los tipos de regla
+func (h *PriceAlertHandler) SetPriceAlertAPI(c *gin.Context) {
+	userID := sessions.Default(c).Get("user_id")
+	if userID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Debe iniciar sesión para crear alertas"})
+		return
+	}
+
+	var req alertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "JSON no válido: " + err.Error()})
+		return
+	}
+	if req.ProductID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Falta el producto (product_id)"})
+		return
+	}
+
+	var notifyPolicy *model.NotifyPolicy
+	if req.NotifyMode != "" {
+		policy := model.NotifyPolicy{NotifyMode: req.NotifyMode, CooldownHours: req.CooldownHours}.Normalize()
+		notifyPolicy = &policy
+	}
+	rule := model.AlertRule{RuleType: req.RuleType, RuleValue: req.RuleValue, RuleStore: req.RuleStore}.Normalize()
+	filter := userOfferFilter(c)
+	filter.NewOnly, filter.ExcludeAuctions = req.NewOnly, req.ExcludeAuctions
+
+	currency := userCurrency(c)
+	targetPrice := h.currencyUseCase.ToComparison(req.TargetPrice, currency)
+	alert, isUpdate, err := h.saveUserAlert(c.Request.Context(), userID.(uint), req.ProductID, targetPrice, req.NotifyByEmail, filter, notifyPolicy, rule)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, usecase.ErrInvalidAlertRule) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"alert":     h.alertResponse(alert, currency),
+		"is_update": isUpdate,
+	})
+}
+
+// alertResponse prepara una alerta para las respuestas JSON, con la descripción de su regla y los
+// importes en la moneda del usuario
+func (h *PriceAlertHandler) alertResponse(alert *model.PriceAlert, currency string) gin.H {
+	targetPrice, currency := h.currencyUseCase.FromComparison(alert.TargetPrice, currency)
+	baselinePrice, _ := h.currencyUseCase.FromComparison(alert.BaselinePrice, currency)
+	lastNotifiedPrice, _ := h.currencyUseCase.FromComparison(alert.LastNotifiedPrice, currency)
+	return gin.H{
+		"id":                  alert.ID,
+		"product_id":          alert.ProductID,
+		"rule_type":           alert.RuleType,
+		"description":         alert.RuleDescription(h.currencyUseCase.Formatter(currency)),
+		"currency":            currency,
+		"target_price":        targetPrice,
+		"rule_value":          alert.RuleValue,
+		"rule_store":          alert.RuleStore,
+		"baseline_price":      baselinePrice,
+		"new_only":            alert.NewOnly,
+		"exclude_auctions":    alert.ExcludeAuctions,
+		"landed_cost":         alert.LandedCost,
+		"notify_mode":         alert.NotifyMode,
+		"cooldown_hours":      alert.CooldownHours,
+		"notify_by_email":     alert.NotifyByEmail,
+		"is_active":           alert.IsActive,
+		"last_notified_price": lastNotifiedPrice,
+		"last_notified_at":    alert.LastNotifiedAt,
+	}
+}
+
+// saveUserAlert crea la alerta del usuario para el producto o, si ya tiene una, la actualiza con los
+// nuevos datos (y la reactiva). Si notifyByEmail o policy son nil se mantienen los de la alerta
+// existente; las alertas nuevas avisan por correo salvo que notifyByEmail diga lo contrario.
+// También añade el producto a la watchlist del usuario; los errores de ese paso solo se registran
+func (h *PriceAlertHandler) saveUserAlert(
+	ctx context.Context,
+	userID, productID uint,
+	targetPrice float64,
+	notifyByEmail *bool,
+	offerFilter model.OfferFilter,
+	notifyPolicy *model.NotifyPolicy,
+	rule model.AlertRule,
+) (*model.PriceAlert, bool, error) {
+	// Verificar si ya existe una alerta para este producto y usuario
+	alerts, err := h.priceAlertUseCase.GetUserAlerts(ctx, userID)
+	if err != nil {
+		return nil, false, fmt.Errorf("error al verificar alertas existentes: %w", err)
+	}
+
 	var existingAlert *model.PriceAlert
 	for _, alert := range alerts {
-		if alert.ProductID == uint(productID) {
+		if alert.ProductID == productID {
 			existingAlert = alert
 			break
 		}
 	}
 
-	// Variable para almacenar si es una actualización o creación
-	isUpdate := existingAlert != nil
-
 	// Crear o actualizar la alerta
 	var savedAlert *model.PriceAlert
 	if existingAlert != nil {
@@ -135,120 +329,84 @@ func (h *PriceAlertHandler) SetPriceAlert(c *gin.Context) {
 		savedAlert, err = h.priceAlertUseCase.UpdateAlert(
 			ctx,
 			existingAlert.ID,
-			userID.(uint),
+			userID,
 			targetPrice,
 			notifyByEmail,
 			true, // alerta activa
+			&offerFilter,
+			notifyPolicy,
+			&rule,
 		)
 	} else {
 		// Crear nueva alerta
+		policy := model.NotifyPolicy{}
+		if notifyPolicy != nil {
+			policy = *notifyPolicy
+		}
+		withEmail := notifyByEmail == nil || *notifyByEmail
 		savedAlert, err = h.priceAlertUseCase.CreateAlert(
 			ctx,
-			userID.(uint),
-			uint(productID),
+			userID,
+			productID,
 			targetPrice,
-			notifyByEmail,
+			withEmail,
+			offerFilter,
+			policy,
+			rule,
 		)
 	}
-
 	if err != nil {
-		if isAjax {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   "Error al guardar la alerta de precio: " + err.Error(),
-			})
-			return
-		}
-		h.templateRenderer.Render(c, http.StatusInternalServerError, "error.html", gin.H{
-			"Message": "Error al guardar la alerta de precio",
-			"Error":   err.Error(),
-		})
-		return
+		return nil, existingAlert != nil, err
 	}
 
 	// Asegurarnos de que el producto esté también en la tabla watchlist_items
 	// (puede fallar silenciosamente sin afectar al flujo principal)
-	if err == nil {
-		// Primero asegurarnos de que el usuario tiene una watchlist
-		_, errWatchlist := h.watchlistRepo.FindByUserID(ctx, userID.(uint))
-		if errWatchlist != nil {
-			// Crear la watchlist para este usuario si no existe
-			newWatchlist := &model.Watchlist{
-				UserID: userID.(uint),
-				Name:   "Mi lista de seguimiento",
-			}
-			if errCreate := h.watchlistRepo.Create(ctx, newWatchlist); errCreate != nil {
-				log.Printf("[Watchlist] Error al crear watchlist para usuario=%d: %v", userID.(uint), errCreate)
-			} else {
-				log.Printf("[Watchlist] Creada watchlist para usuario=%d", userID.(uint))
-			}
+	// Primero asegurarnos de que el usuario tiene una watchlist
+	if _, errWatchlist := h.watchlistRepo.FindByUserID(ctx, userID); errWatchlist != nil {
+		// Crear la watchlist para este usuario si no existe
+		newWatchlist := &model.Watchlist{
+			UserID: userID,
+			Name:   "Mi lista de seguimiento",
 		}
-
-		// Comprobar si ya existe en la watchlist
-		if exists, _ := h.watchlistItemRepo.IsProductInWatchlist(ctx, userID.(uint), uint(productID)); !exists {
-			if errCreate := h.watchlistItemRepo.Create(ctx, &model.WatchlistItem{
-				UserID:      userID.(uint),
-				ProductID:   uint(productID),
-				TargetPrice: targetPrice,
-			}); errCreate != nil {
-				log.Printf("[Watchlist] Error al insertar item usuario=%d producto=%d: %v", userID.(uint), productID, errCreate)
-			} else {
-				log.Printf("[Watchlist] Item añadido usuario=%d producto=%d precio=%v", userID.(uint), productID, targetPrice)
-			}
+		if errCreate := h.watchlistRepo.Create(ctx, newWatchlist); errCreate != nil {
+			log.Printf("[Watchlist] Error al crear watchlist para usuario=%d: %v", userID, errCreate)
 		} else {
-			// Si ya existe, actualizar el target_price
-			items, errItems := h.watchlistItemRepo.FindByUserID(ctx, userID.(uint))
-			if errItems == nil {
-				for _, item := range items {
-					if item.ProductID == uint(productID) {
-						item.TargetPrice = targetPrice
-						if errUpdate := h.watchlistItemRepo.Update(ctx, item); errUpdate != nil {
-							log.Printf("[Watchlist] Error al actualizar precio objetivo de item usuario=%d producto=%d: %v",
-								userID.(uint), productID, errUpdate)
-						} else {
-							log.Printf("[Watchlist] Precio objetivo actualizado para usuario=%d producto=%d precio=%v",
-								userID.(uint), productID, targetPrice)
-						}
-						break
-					}
-				}
-			}
+			log.Printf("[Watchlist] Creada watchlist para usuario=%d", userID)
 		}
 	}
 
-	// Si llegamos aquí, la operación fue exitosa (creación o actualización)
-	// y la sincronización con watchlist_items se intentó (los errores se loguearon pero no detuvieron el flujo principal de la alerta).
-
-	// Determinar mensaje de éxito
-	successMessage := "Producto añadido a tu cesta correctamente."
-	if isUpdate {
-		successMessage = "Este producto ya estaba en tu cesta. Precio objetivo actualizado correctamente."
-	}
-
-	if isAjax {
-		log.Printf("[SetPriceAlert] Operación AJAX exitosa (isUpdate: %t) para producto %s. Devolviendo JSON.", isUpdate, productIDStr)
-		responseData := gin.H{
-			"success":   true,
-			"message":   successMessage,
-			"is_update": isUpdate,
+	// Comprobar si ya existe en la watchlist
+	if exists, _ := h.watchlistItemRepo.IsProductInWatchlist(ctx, userID, productID); !exists {
+		if errCreate := h.watchlistItemRepo.Create(ctx, &model.WatchlistItem{
+			UserID:      userID,
+			ProductID:   productID,
+			TargetPrice: savedAlert.TargetPrice,
+		}); errCreate != nil {
+			log.Printf("[Watchlist] Error al insertar item usuario=%d producto=%d: %v", userID, productID, errCreate)
+		} else {
+			log.Printf("[Watchlist] Item añadido usuario=%d producto=%d precio=%v", userID, productID, savedAlert.TargetPrice)
 		}
-		if savedAlert != nil {
-			responseData["alert_id"] = savedAlert.ID
+	} else {
+		// Si ya existe, actualizar el target_price
+		items, errItems := h.watchlistItemRepo.FindByUserID(ctx, userID)
+		if errItems == nil {
+			for _, item := range items {
+				if item.ProductID == productID {
+					item.TargetPrice = savedAlert.TargetPrice
+					if errUpdate := h.watchlistItemRepo.Update(ctx, item); errUpdate != nil {
+						log.Printf("[Watchlist] Error al actualizar precio objetivo de item usuario=%d producto=%d: %v",
+							userID, productID, errUpdate)
+					} else {
+						log.Printf("[Watchlist] Precio objetivo actualizado para usuario=%d producto=%d precio=%v",
+							userID, productID, savedAlert.TargetPrice)
+					}
+					break
+				}
+			}
 		}
-		c.JSON(http.StatusOK, responseData)
-		return // Asegurar que la función termina aquí para la respuesta AJAX
 	}
 
-	// Flujo no-AJAX (si alguna vez se usa): Redirigir con un mensaje flash
-	log.Printf("[SetPriceAlert] Operación NO-AJAX exitosa (isUpdate: %t) para producto %s. Redirigiendo.", isUpdate, productIDStr)
-	session := sessions.Default(c)
-	session.AddFlash(successMessage, "success_message") // Usar una clave consistente para mensajes flash
-	if err := session.Save(); err != nil {
-		log.Printf("[SetPriceAlert] Error al guardar sesión para flash message: %v", err)
-		// No es crítico, continuar con la redirección
-	}
-	// Redirigir de vuelta a la página del producto. El productoIDStr ya fue validado.
-	c.Redirect(http.StatusFound, "/producto/"+productIDStr)
+	return savedAlert, existingAlert != nil, nil
 }
 
 // DeletePriceAlert maneja la eliminación de una alerta de precio
@@ -388,18 +546,26 @@ func (h *PriceAlertHandler) ShowWatchlist(c *gin.Context) {
 		Alert        *model.PriceAlert
 		Product      *model.Product
 		CurrentPrice *model.Price
+		CurrentValue float64 // Valor con el que la alerta compara el precio actual (precio o coste total)
 		PriceDiff    float64
+		Triggers     []*model.AlertTrigger // Últimos avisos de la alerta
 	}
 
 	var watchlistItems []WatchlistItem
 
 	for _, alert := range alerts {
 		// Obtener detalles del producto
-		product, err := h.productUseCase.GetProductDetail(ctx, alert.ProductID)
+		product, err := h.productUseCase.GetProductDetail(ctx, alert.ProductID, alert.OfferFilter)
 		if err != nil {
 			continue // Saltamos este producto si hay error
 		}
 
+		// Últimos disparos de la alerta (si falla, la tarjeta se muestra sin historial)
+		triggers, err := h.priceAlertUseCase.GetAlertTriggers(ctx, alert.ID, 5)
+		if err != nil {
+			log.Printf("[Watchlist] Error al obtener historial de la alerta %d: %v", alert.ID, err)
+		}
+
 		// Obtener precio actual (el primero en la lista si existe)
 		var currentPrice *model.Price
 		if len(product.Prices) > 0 {
@@ -410,21 +576,25 @@ func (h *PriceAlertHandler) ShowWatchlist(c *gin.Context) {
 		if currentPrice == nil {
 			// Añadir el item sin precio actual
 			watchlistItems = append(watchlistItems, WatchlistItem{
-				Alert:   alert,
-				Product: product,
+				Alert:    alert,
+				Product:  product,
+				Triggers: triggers,
 			})
 			continue
 		}
 
 		// Calcular diferencia de precio (positivo = falta para alcanzar el objetivo)
-		priceDiff := alert.TargetPrice - currentPrice.Price
+		currentValue := alert.OfferFilter.PriceOf(currentPrice)
+		priceDiff := alert.TargetPrice - currentValue
 
 		// Añadir a la lista
 		watchlistItems = append(watchlistItems, WatchlistItem{
 			Alert:        alert,
 			Product:      product,
 			CurrentPrice: currentPrice,
+			CurrentValue: currentValue,
 			PriceDiff:    priceDiff,
+			Triggers:     triggers,
 		})
 	}
 
@@ -483,15 +653,19 @@ func (h *PriceAlertHandler) UpdatePriceAlert(c *gin.Context) {
 		return
 	}
 
-	// Actualizar la alerta
+	// Actualizar la alerta (el precio se escribe en la moneda del usuario)
 	ctx := c.Request.Context()
+	targetPrice = h.currencyUseCase.ToComparison(targetPrice, userCurrency(c))
 	_, err = h.priceAlertUseCase.UpdateAlert(
 		ctx,
 		uint(alertID),
 		userID.(uint),
 		targetPrice,
-		true, // notificar por email
+		nil,  // mantener el aviso por correo
 		true, // alerta activa
+		nil,  // mantener el filtro de ofertas
+		nil,  // mantener el modo de notificación
+		nil,  // mantener la regla
 	)
 
 	if err != nil {
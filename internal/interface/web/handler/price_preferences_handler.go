@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+
+	"app/internal/interface/web/views"
+	"app/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PricePreferencesHandler maneja cómo se muestran y comparan los precios al usuario (moneda y coste total)
+type PricePreferencesHandler struct {
+	userUseCase      *usecase.UserUseCase
+	currencyUseCase  *usecase.CurrencyUseCase
+	templateRenderer *views.TemplateRenderer
+}
+
+// NewPricePreferencesHandler crea una nueva instancia del PricePreferencesHandler
+func NewPricePreferencesHandler(userUseCase *usecase.UserUseCase, currencyUseCase *usecase.CurrencyUseCase, templateRenderer *views.TemplateRenderer) *PricePreferencesHandler {
+	return &PricePreferencesHandler{
+		userUseCase:      userUseCase,
+		currencyUseCase:  currencyUseCase,
+		templateRenderer: templateRenderer,
+	}
+}
+
+// pricePreferencesRequest son los datos del formulario de preferencias de precios
+type pricePreferencesRequest struct {
+	Currency          string `form:"currency"`            // Vacía para usar la moneda por defecto
+	CompareLandedCost bool   `form:"compare_landed_cost"` // Comparar por coste total (casilla)
+}
+
+// ShowPricePreferences muestra la moneda en la que el usuario ve los precios y si los compara por
+// coste total
+func (h *PricePreferencesHandler) ShowPricePreferences(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.Redirect(http.StatusFound, "/login")
+		return
+	}
+
+	allCategories, _ := c.Get("allCategories")
+	h.templateRenderer.Render(c, http.StatusOK, "price_preferences.html", gin.H{
+		"Title":           "Preferencias de precios - Comparador de Precios",
+		"Categories":      allCategories,
+		"User":            user,
+		"Currencies":      h.currencyUseCase.Currencies(),
+		"DefaultCurrency": h.currencyUseCase.DisplayCurrency(),
+		"Error":           c.Query("error"),
+		"Success":         c.Query("success"),
+	})
+}
+
+// SavePricePreferences guarda la moneda en la que el usuario ve los precios y si los compara por
+// coste total
+func (h *PricePreferencesHandler) SavePricePreferences(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.Redirect(http.StatusFound, "/login")
+		return
+	}
+
+	var req pricePreferencesRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.Redirect(http.StatusFound, "/perfil/precios?error="+url.QueryEscape("Datos del formulario no válidos"))
+		return
+	}
+
+	prefs := usecase.PricePreferences{Currency: req.Currency, CompareLandedCost: req.CompareLandedCost}
+	if err := h.userUseCase.UpdatePricePreferences(c.Request.Context(), user.ID, prefs); err != nil {
+		c.Redirect(http.StatusFound, "/perfil/precios?error="+url.QueryEscape(err.Error()))
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/perfil/precios?success="+url.QueryEscape("Preferencias de precios guardadas"))
+}
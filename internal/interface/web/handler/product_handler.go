@@ -52,7 +52,8 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 
 	// Obtener detalle del producto
 	ctx := c.Request.Context()
-	product, err := h.productUseCase.GetProductDetail(ctx, uint(id))
+	filter := userOfferFilter(c)
+	product, err := h.productUseCase.GetProductDetail(ctx, uint(id), filter)
 	if err != nil {
 		h.templateRenderer.Render(c, http.StatusInternalServerError, "error.html", gin.H{
 			"Message": "Error al obtener el producto",
@@ -86,7 +87,7 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 	}
 
 	// Obtener productos similares
-	similarProducts, err := h.productUseCase.GetSimilarProducts(ctx, uint(id), 3)
+	similarProducts, err := h.productUseCase.GetSimilarProducts(ctx, uint(id), 3, filter)
 	if err != nil {
 		// Si hay error, continuamos sin productos similares
 		similarProducts = []*model.Product{}
@@ -99,12 +100,12 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 	}
 
 	// Preparar viewmodel para el producto
-	productVM := views.ToProductViewModel(product, bestPrice)
+	productVM := views.ToProductViewModel(product, bestPrice, filter)
 
 	// Preparar viewmodel para el mejor precio
 	var bestPriceVM views.PriceViewModel
 	if bestPrice != nil {
-		bestPriceVM = views.ToPriceViewModel(*bestPrice)
+		bestPriceVM = views.ToPriceViewModel(*bestPrice, filter)
 	}
 
 	// Preparar viewmodels para productos similares
@@ -123,7 +124,7 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		}
 
 		if similarBestPrice != nil {
-			spVM.Price = similarBestPrice.Price
+			spVM.Price = filter.PriceOf(similarBestPrice)
 			spVM.Store = similarBestPrice.Store
 			spVM.URL = similarBestPrice.URL
 		}
@@ -145,3 +146,48 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		"RelatedProducts":     []views.ProductViewModel{},
 	})
 }
+
+// GetPriceHistoryAPI devuelve en JSON el histórico de precios de un producto.
+// Acepta los parámetros opcionales "desde" y "hasta" (YYYY-MM-DD) y "tienda".
+// Por defecto devuelve los últimos 30 días
+func (h *ProductHandler) GetPriceHistoryAPI(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de producto inválido"})
+		return
+	}
+
+	to := time.Now()
+	if toStr := c.Query("hasta"); toStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", toStr, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha 'hasta' inválida, usa el formato YYYY-MM-DD"})
+			return
+		}
+		// Incluir el día completo
+		to = parsed.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	from := to.AddDate(0, 0, -30)
+	if fromStr := c.Query("desde"); fromStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", fromStr, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha 'desde' inválida, usa el formato YYYY-MM-DD"})
+			return
+		}
+		from = parsed
+	}
+
+	observations, err := h.productUseCase.GetPriceHistory(c.Request.Context(), uint(id), c.Query("tienda"), from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"product_id":   id,
+		"from":         from,
+		"to":           to,
+		"observations": observations,
+	})
+}
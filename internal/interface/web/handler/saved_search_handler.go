@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"app/internal/domain/model"
+	"app/internal/interface/web/views"
+	"app/internal/usecase"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// savedSearchMatchesShown es el número de coincidencias recientes que se muestran por búsqueda
+const savedSearchMatchesShown = 5
+
+// SavedSearchHandler maneja las búsquedas guardadas de los usuarios
+type SavedSearchHandler struct {
+	savedSearchUseCase *usecase.SavedSearchUseCase
+	currencyUseCase    *usecase.CurrencyUseCase
+	templateRenderer   *views.TemplateRenderer
+}
+
+// NewSavedSearchHandler crea una nueva instancia del SavedSearchHandler
+func NewSavedSearchHandler(savedSearchUseCase *usecase.SavedSearchUseCase, currencyUseCase *usecase.CurrencyUseCase, templateRenderer *views.TemplateRenderer) *SavedSearchHandler {
+	return &SavedSearchHandler{
+		savedSearchUseCase: savedSearchUseCase,
+		currencyUseCase:    currencyUseCase,
+		templateRenderer:   templateRenderer,
+	}
+}
+
+// savedSearchRequest son los datos del formulario de una búsqueda. Los nombres coinciden con los
+// filtros del listado de categoría, de modo que el formulario puede rellenarse desde su URL. Los
+// precios están en la moneda del usuario
+type savedSearchRequest struct {
+	Name            string `form:"name"`
+	Category        string `form:"categoria"`
+	Keywords        string `form:"q"`
+	Store           string `form:"store"`
+	MinPrice        string `form:"min_price"`
+	MaxPrice        string `form:"max_price"`
+	NewOnly         bool   `form:"new_only"`
+	ExcludeAuctions bool   `form:"exclude_auctions"`
+	NotifyByEmail   bool   `form:"notify_by_email"`
+}
+
+// SavedSearchItem es una búsqueda guardada con sus últimas coincidencias, para la vista
+type SavedSearchItem struct {
+	Search  *model.SavedSearch
+	Matches []*model.SavedSearchMatch
+}
+
+// ShowSavedSearches muestra las búsquedas guardadas del usuario y el formulario para crear una,
+// rellenado con los filtros recibidos en la URL
+func (h *SavedSearchHandler) ShowSavedSearches(c *gin.Context) {
+	var req savedSearchRequest
+	_ = c.ShouldBindQuery(&req)
+	req.NotifyByEmail = true
+	h.renderSavedSearches(c, http.StatusOK, req, "")
+}
+
+// CreateSavedSearch guarda una búsqueda nueva
+func (h *SavedSearchHandler) CreateSavedSearch(c *gin.Context) {
+	userID := sessions.Default(c).Get("user_id")
+	if userID == nil {
+		c.Redirect(http.StatusFound, "/login")
+		return
+	}
+
+	var req savedSearchRequest
+	if err := c.ShouldBind(&req); err != nil {
+		h.renderSavedSearches(c, http.StatusBadRequest, req, "Datos del formulario no válidos")
+		return
+	}
+
+	minPrice, err := parseOptionalPrice(req.MinPrice)
+	if err != nil {
+		h.renderSavedSearches(c, http.StatusBadRequest, req, "El precio mínimo debe ser un número positivo")
+		return
+	}
+	maxPrice, err := parseOptionalPrice(req.MaxPrice)
+	if err != nil {
+		h.renderSavedSearches(c, http.StatusBadRequest, req, "El precio máximo debe ser un número positivo")
+		return
+	}
+
+	currency := userCurrency(c)
+	offers := userOfferFilter(c)
+	offers.NewOnly, offers.ExcludeAuctions = req.NewOnly, req.ExcludeAuctions
+	search := model.SavedSearch{
+		Name:          req.Name,
+		Keywords:      req.Keywords,
+		Store:         req.Store,
+		MinPrice:      h.currencyUseCase.ToComparison(minPrice, currency),
+		MaxPrice:      h.currencyUseCase.ToComparison(maxPrice, currency),
+		OfferFilter:   offers,
+		NotifyByEmail: req.NotifyByEmail,
+	}
+	if _, err := h.savedSearchUseCase.CreateSearch(c.Request.Context(), userID.(uint), req.Category, search); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, usecase.ErrInvalidSavedSearch) {
+			status = http.StatusBadRequest
+		}
+		h.renderSavedSearches(c, status, req, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/busquedas?success="+url.QueryEscape("Búsqueda guardada. Te avisaremos de los nuevos resultados."))
+}
+
+// ToggleSavedSearch pausa o reanuda una búsqueda (campo active del formulario: 1 o 0)
+func (h *SavedSearchHandler) ToggleSavedSearch(c *gin.Context) {
+	userID := sessions.Default(c).Get("user_id")
+	if userID == nil {
+		c.Redirect(http.StatusFound, "/login")
+		return
+	}
+
+	searchID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/busquedas?error="+url.QueryEscape("ID de búsqueda no válido"))
+		return
+	}
+
+	active := c.PostForm("active") == "1"
+	if err := h.savedSearchUseCase.SetSearchActive(c.Request.Context(), uint(searchID), userID.(uint), active); err != nil {
+		c.Redirect(http.StatusFound, "/busquedas?error="+url.QueryEscape(err.Error()))
+		return
+	}
+
+	message := "Búsqueda pausada"
+	if active {
+		message = "Búsqueda reanudada"
+	}
+	c.Redirect(http.StatusFound, "/busquedas?success="+url.QueryEscape(message))
+}
+
+// DeleteSavedSearch elimina una búsqueda
+func (h *SavedSearchHandler) DeleteSavedSearch(c *gin.Context) {
+	userID := sessions.Default(c).Get("user_id")
+	if userID == nil {
+		c.Redirect(http.StatusFound, "/login")
+		return
+	}
+
+	searchID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/busquedas?error="+url.QueryEscape("ID de búsqueda no válido"))
+		return
+	}
+
+	if err := h.savedSearchUseCase.DeleteSearch(c.Request.Context(), uint(searchID), userID.(uint)); err != nil {
+		c.Redirect(http.StatusFound, "/busquedas?error="+url.QueryEscape(err.Error()))
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/busquedas?success="+url.QueryEscape("Búsqueda eliminada"))
+}
+
+// renderSavedSearches muestra la página de búsquedas con el formulario rellenado con req
+func (h *SavedSearchHandler) renderSavedSearches(c *gin.Context, status int, req savedSearchRequest, errorMessage string) {
+	userID := sessions.Default(c).Get("user_id")
+	if userID == nil {
+		c.Redirect(http.StatusFound, "/login")
+		return
+	}
+	ctx := c.Request.Context()
+
+	searches, err := h.savedSearchUseCase.GetUserSearches(ctx, userID.(uint))
+	if err != nil {
+		h.templateRenderer.RenderServerError(c, err)
+		return
+	}
+
+	items := make([]SavedSearchItem, 0, len(searches))
+	for _, search := range searches {
+		matches, err := h.savedSearchUseCase.GetSearchMatches(ctx, search.ID, savedSearchMatchesShown)
+		if err != nil {
+			log.Printf("Error al obtener las coincidencias de la búsqueda %d: %v", search.ID, err)
+		}
+		items = append(items, SavedSearchItem{Search: search, Matches: matches})
+	}
+
+	if errorMessage == "" {
+		errorMessage = c.Query("error")
+	}
+	allCategories, _ := c.Get("allCategories")
+	h.templateRenderer.Render(c, status, "saved_searches.html", gin.H{
+		"Title":      "Mis búsquedas - Comparador de Precios",
+		"Categories": allCategories,
+		"Searches":   items,
+		"Form":       req,
+		"Error":      errorMessage,
+		"Success":    c.Query("success"),
+	})
+}
+
+// parseOptionalPrice interpreta un precio opcional del formulario. Vacío equivale a 0 (sin límite)
+func parseOptionalPrice(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	price, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64)
+	if err != nil || price < 0 {
+		return 0, errors.New("precio no válido")
+	}
+	return price, nil
+}
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"app/internal/interface/web/views"
+	"app/internal/usecase"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// TrackingHandler maneja el seguimiento de URLs de producto enviadas por los usuarios
+type TrackingHandler struct {
+	trackingUseCase  *usecase.TrackingUseCase
+	currencyUseCase  *usecase.CurrencyUseCase
+	templateRenderer *views.TemplateRenderer
+}
+
+// NewTrackingHandler crea una nueva instancia del TrackingHandler
+func NewTrackingHandler(trackingUseCase *usecase.TrackingUseCase, currencyUseCase *usecase.CurrencyUseCase, templateRenderer *views.TemplateRenderer) *TrackingHandler {
+	return &TrackingHandler{
+		trackingUseCase:  trackingUseCase,
+		currencyUseCase:  currencyUseCase,
+		templateRenderer: templateRenderer,
+	}
+}
+
+// trackRequest son los datos del formulario (o del JSON) para seguir una URL
+type trackRequest struct {
+	URL             string `form:"url" json:"url"`
+	TargetPrice     string `form:"target_price" json:"-"`
+	NewOnly         bool   `form:"new_only" json:"new_only"`
+	ExcludeAuctions bool   `form:"exclude_auctions" json:"exclude_auctions"`
+
+	// En JSON el precio objetivo llega como número. En los dos casos está en la moneda del usuario
+	TargetPriceJSON float64 `form:"-" json:"target_price"`
+}
+
+// ShowTrackForm muestra el formulario para seguir la URL de un producto
+func (h *TrackingHandler) ShowTrackForm(c *gin.Context) {
+	data := gin.H{
+		"Title":   "Seguir un producto - Comparador de Precios",
+		"Success": c.Query("success") != "",
+	}
+	if productID := c.Query("producto"); productID != "" {
+		data["ProductID"] = productID
+	}
+	h.templateRenderer.Render(c, http.StatusOK, "track_url.html", data)
+}
+
+// TrackURL procesa el formulario: scrapea la URL, la vincula al catálogo y crea la alerta si se pidió
+func (h *TrackingHandler) TrackURL(c *gin.Context) {
+	userID := sessions.Default(c).Get("user_id")
+	if userID == nil {
+		c.Redirect(http.StatusFound, "/login")
+		return
+	}
+
+	var req trackRequest
+	if err := c.ShouldBind(&req); err != nil {
+		h.renderTrackForm(c, http.StatusBadRequest, req, "Datos del formulario no válidos")
+		return
+	}
+
+	targetPrice, err := parseTargetPrice(req.TargetPrice)
+	if err != nil {
+		h.renderTrackForm(c, http.StatusBadRequest, req, err.Error())
+		return
+	}
+
+	targetPrice = h.currencyUseCase.ToComparison(targetPrice, userCurrency(c))
+	filter := userOfferFilter(c)
+	filter.NewOnly, filter.ExcludeAuctions = req.NewOnly, req.ExcludeAuctions
+	result, err := h.trackingUseCase.TrackURL(c.Request.Context(), userID.(uint), req.URL, targetPrice, filter)
+	if err != nil && (result == nil || result.Product == nil) {
+		h.renderTrackForm(c, trackErrorStatus(err), req, err.Error())
+		return
+	}
+	if err != nil {
+		// El producto se sigue, pero la alerta no se pudo guardar
+		h.renderTrackForm(c, http.StatusInternalServerError, req, err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/seguir-url?success=1&producto=%d", result.Product.ID))
+}
+
+// TrackURLAPI hace lo mismo que TrackURL recibiendo JSON y devuelve el producto y la alerta en JSON
+func (h *TrackingHandler) TrackURLAPI(c *gin.Context) {
+	userID := sessions.Default(c).Get("user_id")
+	if userID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Debe iniciar sesión para seguir productos"})
+		return
+	}
+
+	var req trackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "JSON no válido: " + err.Error()})
+		return
+	}
+	if req.TargetPriceJSON < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El precio objetivo debe ser un número positivo"})
+		return
+	}
+
+	currency := userCurrency(c)
+	targetPrice := h.currencyUseCase.ToComparison(req.TargetPriceJSON, currency)
+	filter := userOfferFilter(c)
+	filter.NewOnly, filter.ExcludeAuctions = req.NewOnly, req.ExcludeAuctions
+	result, err := h.trackingUseCase.TrackURL(c.Request.Context(), userID.(uint), req.URL, targetPrice, filter)
+	if err != nil && (result == nil || result.Product == nil) {
+		c.JSON(trackErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{
+		"product": gin.H{
+			"id":          result.Product.ID,
+			"name":        result.Product.Name,
+			"category_id": result.Product.CategoryID,
+			"url":         fmt.Sprintf("/producto/%d", result.Product.ID),
+		},
+	}
+	if result.Alert != nil {
+		alertPrice, currency := h.currencyUseCase.FromComparison(result.Alert.TargetPrice, currency)
+		response["alert"] = gin.H{
+			"id":           result.Alert.ID,
+			"target_price": alertPrice,
+			"currency":     currency,
+			"updated":      result.AlertUpdated,
+		}
+	}
+	if err != nil {
+		// El producto se sigue, pero la alerta no se pudo guardar
+		response["error"] = err.Error()
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// renderTrackForm vuelve a mostrar el formulario con los datos enviados y un mensaje de error
+func (h *TrackingHandler) renderTrackForm(c *gin.Context, status int, req trackRequest, errorMessage string) {
+	h.templateRenderer.Render(c, status, "track_url.html", gin.H{
+		"Title":           "Seguir un producto - Comparador de Precios",
+		"Error":           errorMessage,
+		"URL":             req.URL,
+		"TargetPrice":     req.TargetPrice,
+		"NewOnly":         req.NewOnly,
+		"ExcludeAuctions": req.ExcludeAuctions,
+	})
+}
+
+// parseTargetPrice interpreta el precio objetivo del formulario. Vacío equivale a no crear alerta
+func parseTargetPrice(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	price, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64)
+	if err != nil || price < 0 {
+		return 0, errors.New("el precio objetivo debe ser un número positivo")
+	}
+	return price, nil
+}
+
+// trackErrorStatus devuelve el código HTTP que corresponde a un error al seguir una URL
+func trackErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidTrackURL):
+		return http.StatusBadRequest
+	case errors.Is(err, usecase.ErrProductNotTrackable):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
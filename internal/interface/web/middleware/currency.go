@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"app/internal/domain/model"
+	"app/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IncludePricePreferences agrega al contexto la moneda en la que el usuario ve los precios y si los
+// compara por coste total. Los visitantes y los usuarios que no han elegido usan los valores de la
+// configuración
+func IncludePricePreferences(currencyUseCase *usecase.CurrencyUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user *model.User
+		if userInterface, exists := c.Get("user"); exists {
+			user, _ = userInterface.(*model.User)
+		}
+
+		c.Set("Currency", currencyUseCase.UserCurrency(user))
+		c.Set("CompareLandedCost", currencyUseCase.CompareLandedCost(user))
+		c.Next()
+	}
+}
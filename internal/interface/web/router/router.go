@@ -4,8 +4,10 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"app/internal/domain/repositories"
+	"app/internal/infrastructure/realtime"
 	"app/internal/interface/web/handler"
 	"app/internal/interface/web/middleware"
 	"app/internal/interface/web/views"
@@ -17,7 +19,7 @@ import (
 )
 
 // SetupRouter configura las rutas y handlers de la aplicación
-func SetupRouter(productUseCase *usecase.ProductUseCase, userUseCase *usecase.UserUseCase, priceAlertUseCase *usecase.PriceAlertUseCase, watchlistRepo repositories.WatchlistRepository, watchlistItemRepo repositories.WatchlistItemRepository) *gin.Engine {
+func SetupRouter(productUseCase *usecase.ProductUseCase, userUseCase *usecase.UserUseCase, priceAlertUseCase *usecase.PriceAlertUseCase, savedSearchUseCase *usecase.SavedSearchUseCase, notificationChannelUseCase *usecase.NotificationChannelUseCase, digestUseCase *usecase.DigestUseCase, storeHealthUseCase *usecase.StoreHealthUseCase, currencyUseCase *usecase.CurrencyUseCase, emailOutboxUseCase *usecase.EmailOutboxUseCase, trackingUseCase *usecase.TrackingUseCase, watchlistRepo repositories.WatchlistRepository, watchlistItemRepo repositories.WatchlistItemRepository, notificationBroker *realtime.Broker, streamHeartbeat time.Duration) *gin.Engine {
 	// Inicializar Gin
 	r := gin.Default()
 
@@ -39,23 +41,32 @@ func SetupRouter(productUseCase *usecase.ProductUseCase, userUseCase *usecase.Us
 	r.Use(middleware.IncludePriceAlerts(priceAlertUseCase))
 	r.Use(middleware.IncludeUnreadNotificationsCount(priceAlertUseCase))
 
+	// Moneda en la que el usuario ve los precios (y escribe los importes de los formularios)
+	r.Use(middleware.IncludePricePreferences(currencyUseCase))
+
 	// Cargar archivos estáticos
 	r.Static("/static", "./web/static")
 
 	// Crear renderer para las plantillas
 	var templateRenderer *views.TemplateRenderer
 	var err error
-	if templateRenderer, err = views.SetupTemplates(r); err != nil {
+	if templateRenderer, err = views.SetupTemplates(r, currencyUseCase); err != nil {
 		log.Fatalf("Error al configurar las plantillas HTML: %v", err)
 	}
 
 	// Inicializar handlers
 	homeHandler := handler.NewHomeHandler(productUseCase, templateRenderer)
 	productHandler := handler.NewProductHandler(productUseCase, templateRenderer)
-	categoryHandler := handler.NewCategoryHandler(productUseCase, templateRenderer)
+	categoryHandler := handler.NewCategoryHandler(productUseCase, currencyUseCase, templateRenderer)
 	authHandler := handler.NewAuthHandler(userUseCase, templateRenderer)
-	notificationHandler := handler.NewNotificationHandler(priceAlertUseCase, templateRenderer)
-	priceAlertHandler := handler.NewPriceAlertHandler(priceAlertUseCase, productUseCase, watchlistRepo, watchlistItemRepo, templateRenderer)
+	notificationHandler := handler.NewNotificationHandler(priceAlertUseCase, notificationBroker, streamHeartbeat, templateRenderer)
+	priceAlertHandler := handler.NewPriceAlertHandler(priceAlertUseCase, productUseCase, watchlistRepo, watchlistItemRepo, currencyUseCase, templateRenderer)
+	adminHandler := handler.NewAdminHandler(storeHealthUseCase, currencyUseCase, emailOutboxUseCase, templateRenderer)
+	trackingHandler := handler.NewTrackingHandler(trackingUseCase, currencyUseCase, templateRenderer)
+	savedSearchHandler := handler.NewSavedSearchHandler(savedSearchUseCase, currencyUseCase, templateRenderer)
+	notificationChannelHandler := handler.NewNotificationChannelHandler(notificationChannelUseCase, templateRenderer)
+	emailPreferencesHandler := handler.NewEmailPreferencesHandler(userUseCase, digestUseCase, templateRenderer)
+	pricePreferencesHandler := handler.NewPricePreferencesHandler(userUseCase, currencyUseCase, templateRenderer)
 
 	// Rutas públicas
 	r.GET("/", homeHandler.GetHome)
@@ -76,11 +87,23 @@ func SetupRouter(productUseCase *usecase.ProductUseCase, userUseCase *usecase.Us
 	r.GET("/restablecer-password", authHandler.ShowPasswordResetForm)
 	r.POST("/restablecer-password", authHandler.ProcessPasswordReset)
 
+	// Bajas desde los enlaces de los correos: el token firmado identifica al usuario, sin sesión.
+	// El POST es también la baja en un clic de la cabecera List-Unsubscribe (RFC 8058)
+	r.GET("/correo/baja", emailPreferencesHandler.ShowUnsubscribe)
+	r.POST("/correo/baja", emailPreferencesHandler.Unsubscribe)
+
 	// API endpoints
 	api := r.Group("/api")
 	{
 		api.GET("/categoria/:slug", categoryHandler.GetCategoryAPI)
+		api.GET("/producto/:id/historial", productHandler.GetPriceHistoryAPI)
 		api.POST("/notifications/delete-read", notificationHandler.DeleteReadNotifications)
+		api.POST("/seguir-url", trackingHandler.TrackURLAPI)     // Responde 401 en JSON sin sesión
+		api.GET("/alertas", priceAlertHandler.GetPriceAlertsAPI) // Responde 401 en JSON sin sesión
+		api.POST("/alertas", priceAlertHandler.SetPriceAlertAPI) // Crea o actualiza la alerta de un producto
+
+		// Notificaciones nuevas en tiempo real (Server-Sent Events); responde 401 en JSON sin sesión
+		api.GET("/notificaciones/stream", notificationHandler.StreamNotifications)
 	}
 
 	// Rutas protegidas (requieren autenticación)
@@ -103,18 +126,55 @@ func SetupRouter(productUseCase *usecase.ProductUseCase, userUseCase *usecase.Us
 		authorized.POST("/notificaciones/marcar-leida", notificationHandler.MarkAsRead)
 		authorized.POST("/notificaciones/marcar-leidas", notificationHandler.MarkAllAsRead)
 
+		// Canales de aviso (correo y webhook)
+		authorized.GET("/perfil/avisos", notificationChannelHandler.ShowNotificationChannels)
+		authorized.POST("/perfil/avisos", notificationChannelHandler.SaveNotificationChannels)
+		authorized.POST("/perfil/avisos/probar", notificationChannelHandler.TestWebhook)
+
+		// Preferencias de correo (tipos de correo que acepta el usuario y frecuencia de los avisos)
+		authorized.GET("/perfil/correo", emailPreferencesHandler.ShowEmailPreferences)
+		authorized.POST("/perfil/correo", emailPreferencesHandler.SaveEmailPreferences)
+
+		// Preferencias de precios (moneda en la que se muestran)
+		authorized.GET("/perfil/precios", pricePreferencesHandler.ShowPricePreferences)
+		authorized.POST("/perfil/precios", pricePreferencesHandler.SavePricePreferences)
+
 		// Lista de deseos y alertas (unificado)
 		authorized.GET("/watchlist", priceAlertHandler.ShowWatchlist)
 		authorized.POST("/price-alert/set", priceAlertHandler.SetPriceAlert)
 		authorized.GET("/price-alert/delete", priceAlertHandler.DeletePriceAlert)
 		authorized.GET("/price-alert/update", priceAlertHandler.UpdatePriceAlert)
 
+		// Seguir la URL de un producto de cualquier tienda
+		authorized.GET("/seguir-url", trackingHandler.ShowTrackForm)
+		authorized.POST("/seguir-url", trackingHandler.TrackURL)
+
+		// Búsquedas guardadas (avisos de productos que cumplen unos filtros)
+		authorized.GET("/busquedas", savedSearchHandler.ShowSavedSearches)
+		authorized.POST("/busquedas", savedSearchHandler.CreateSavedSearch)
+		authorized.POST("/busquedas/:id/estado", savedSearchHandler.ToggleSavedSearch)
+		authorized.POST("/busquedas/:id/eliminar", savedSearchHandler.DeleteSavedSearch)
+
 		// Mantener esta ruta por compatibilidad pero redirigir a /watchlist
 		authorized.GET("/price-alerts", func(c *gin.Context) {
 			c.Redirect(http.StatusFound, "/watchlist")
 		})
 	}
 
+	// Rutas de administración (requieren un usuario administrador)
+	admin := r.Group("/")
+	admin.Use(middleware.AuthRequired(), middleware.AdminRequired())
+	{
+		admin.GET("/admin/tiendas", adminHandler.ShowStoreHealth)
+		admin.GET("/api/admin/tiendas", adminHandler.GetStoreHealthAPI)
+		admin.GET("/api/admin/tiendas/:store/ejecuciones", adminHandler.GetStoreRunsAPI)
+		admin.GET("/admin/tipos-cambio", adminHandler.ShowExchangeRates)
+		admin.POST("/admin/tipos-cambio", adminHandler.SetExchangeRate)
+		admin.GET("/api/admin/tipos-cambio", adminHandler.GetExchangeRatesAPI)
+		admin.GET("/admin/correos", adminHandler.ShowEmailOutbox)
+		admin.POST("/admin/correos/:id/reintentar", adminHandler.RetryOutboxEmail)
+	}
+
 	// Ruta para páginas no encontradas
 	r.NoRoute(func(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{
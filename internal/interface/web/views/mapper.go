@@ -20,8 +20,9 @@ func ToUserViewModel(user *model.User) *UserViewModel {
 	}
 }
 
-// ToProductViewModel convierte un modelo de dominio Product a un ViewModel
-func ToProductViewModel(product *model.Product, bestPrice *model.Price) ProductViewModel {
+// ToProductViewModel convierte un modelo de dominio Product a un ViewModel. El mejor precio se
+// muestra como lo compara el filtro (precio o coste total)
+func ToProductViewModel(product *model.Product, bestPrice *model.Price, filter model.OfferFilter) ProductViewModel {
 	var categoryVM CategoryViewModel
 	if product.CategoryID > 0 {
 		categoryVM = ToCategoryViewModel(product.Category, 0)
@@ -39,7 +40,7 @@ func ToProductViewModel(product *model.Product, bestPrice *model.Price) ProductV
 	bestPriceValue := 0.0
 	bestStore := ""
 	if bestPrice != nil {
-		bestPriceValue = bestPrice.Price
+		bestPriceValue = filter.PriceOf(bestPrice)
 		bestStore = bestPrice.Store
 	}
 
@@ -66,15 +67,37 @@ func ToCategoryViewModel(category model.Category, productCount int) CategoryView
 	}
 }
 
-// ToPriceViewModel convierte un modelo de dominio Price a ViewModel
-func ToPriceViewModel(price model.Price) PriceViewModel {
+// ToPriceViewModel convierte un modelo de dominio Price a ViewModel. El precio se muestra como lo
+// compara el filtro (precio o coste total)
+func ToPriceViewModel(price model.Price, filter model.OfferFilter) PriceViewModel {
 	return PriceViewModel{
-		Store: price.Store,
-		Price: price.Price,
-		URL:   price.URL,
+		Store:         price.Store,
+		Price:         filter.PriceOf(&price),
+		OriginalPrice: price.Price,
+		Currency:      price.Currency,
+		URL:           price.URL,
+
+		ShippingCost:   price.ShippingCost,
+		ImportCharges:  price.ImportCharges,
+		LandedCost:     price.ComparableTotal(),
+		SellerLocation: price.SellerLocation,
+		TaxNote:        price.TaxNote,
+
+		Condition:      conditionLabels[price.Condition],
+		IsAuction:      price.ListingType == model.ListingTypeAuction,
+		SellerName:     price.SellerName,
+		SellerFeedback: price.SellerFeedback,
 	}
 }
 
+// conditionLabels son los textos con los que se muestra el estado del artículo de una oferta
+var conditionLabels = map[string]string{
+	model.ConditionNew:         "Nuevo",
+	model.ConditionUsed:        "Usado",
+	model.ConditionRefurbished: "Reacondicionado",
+	model.ConditionForParts:    "Para piezas",
+}
+
 // BuildHomePageViewModel construye un modelo completo para la vista de la página de inicio
 func BuildHomePageViewModel(
 	user *model.User,
@@ -82,13 +105,14 @@ func BuildHomePageViewModel(
 	categories []model.Category,
 	bestPrices map[uint]*model.Price,
 	productCounts map[uint]int,
+	filter model.OfferFilter,
 ) HomePageViewModel {
 
 	// Construir los productos destacados
 	productViewModels := make([]ProductViewModel, 0, len(featuredProducts))
 	for _, product := range featuredProducts {
 		bestPrice := bestPrices[product.ID]
-		productViewModels = append(productViewModels, ToProductViewModel(product, bestPrice))
+		productViewModels = append(productViewModels, ToProductViewModel(product, bestPrice, filter))
 	}
 
 	// Construir las categorías
@@ -112,6 +136,7 @@ func BuildProductDetailViewModel(
 	prices []model.Price,
 	relatedProducts []*model.Product,
 	relatedBestPrices map[uint]*model.Price,
+	filter model.OfferFilter,
 ) ProductDetailViewModel {
 
 	var bestPrice PriceViewModel
@@ -119,10 +144,10 @@ func BuildProductDetailViewModel(
 
 	// Obtener el mejor precio y los demás precios
 	if len(prices) > 0 {
-		bestPrice = ToPriceViewModel(prices[0])
+		bestPrice = ToPriceViewModel(prices[0], filter)
 
 		for i := 1; i < len(prices); i++ {
-			otherPrices = append(otherPrices, ToPriceViewModel(prices[i]))
+			otherPrices = append(otherPrices, ToPriceViewModel(prices[i], filter))
 		}
 	}
 
@@ -130,7 +155,7 @@ func BuildProductDetailViewModel(
 	relatedProductVMs := make([]ProductViewModel, 0, len(relatedProducts))
 	for _, relProduct := range relatedProducts {
 		bestPrice := relatedBestPrices[relProduct.ID]
-		relatedProductVMs = append(relatedProductVMs, ToProductViewModel(relProduct, bestPrice))
+		relatedProductVMs = append(relatedProductVMs, ToProductViewModel(relProduct, bestPrice, filter))
 	}
 
 	// Fecha de última actualización
@@ -138,7 +163,7 @@ func BuildProductDetailViewModel(
 
 	return ProductDetailViewModel{
 		User:            ToUserViewModel(user),
-		Product:         ToProductViewModel(product, &prices[0]),
+		Product:         ToProductViewModel(product, &prices[0], filter),
 		BestPrice:       bestPrice,
 		OtherPrices:     otherPrices,
 		RelatedProducts: relatedProductVMs,
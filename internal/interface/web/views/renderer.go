@@ -12,9 +12,9 @@ type TemplateRenderer struct {
 }
 
 // NewTemplateRenderer crea una nueva instancia del renderizador de plantillas
-func NewTemplateRenderer() (*TemplateRenderer, error) {
+func NewTemplateRenderer(prices PriceConverter) (*TemplateRenderer, error) {
 	// Construir las plantillas utilizando el nuevo TemplateBuilder
-	builder, err := BuildTemplates()
+	builder, err := BuildTemplates(prices)
 	if err != nil {
 		return nil, err
 	}
@@ -49,10 +49,11 @@ func (r *TemplateRenderer) RenderServerError(c *gin.Context, err error) {
 	r.RenderError(c, http.StatusInternalServerError, "Error interno del servidor: "+err.Error())
 }
 
-// SetupTemplates configura las plantillas HTML para el motor Gin
-func SetupTemplates(engine *gin.Engine) (*TemplateRenderer, error) {
+// SetupTemplates configura las plantillas HTML para el motor Gin. prices pasa los importes a la
+// moneda de cada usuario
+func SetupTemplates(engine *gin.Engine, prices PriceConverter) (*TemplateRenderer, error) {
 	// Construir las plantillas utilizando el nuevo TemplateBuilder
-	builder, err := BuildTemplates()
+	builder, err := BuildTemplates(prices)
 	if err != nil {
 		return nil, err
 	}
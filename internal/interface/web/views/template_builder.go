@@ -3,26 +3,37 @@ package views
 import (
 	"fmt"
 	"html/template"
+	"math"
 	"net/http"
 	"os"
 	"path/filepath"
 
+	"app/internal/domain/model"
+
 	"github.com/gin-gonic/gin"
 )
 
+// PriceConverter pasa los importes de la moneda de visualización, en la que se guardan y comparan
+// los precios, a la moneda de cada usuario (usecase.CurrencyUseCase)
+type PriceConverter interface {
+	FromComparison(amount float64, currency string) (float64, string)
+}
+
 // TemplateBuilder es el encargado de construir las plantillas HTML
 type TemplateBuilder struct {
 	baseTemplate   string
 	templates      map[string]*template.Template
 	templatesFuncs template.FuncMap
+	prices         PriceConverter
 }
 
 // NewTemplateBuilder crea una nueva instancia del constructor de plantillas
-func NewTemplateBuilder() *TemplateBuilder {
+func NewTemplateBuilder(prices PriceConverter) *TemplateBuilder {
 	return &TemplateBuilder{
 		baseTemplate:   "layout.html",
 		templates:      make(map[string]*template.Template),
 		templatesFuncs: make(template.FuncMap),
+		prices:         prices,
 	}
 }
 
@@ -35,8 +46,22 @@ func (tb *TemplateBuilder) AddFunc(name string, fn interface{}) {
 func (tb *TemplateBuilder) LoadTemplates() error {
 	// Definir funciones personalizadas
 	funcMap := template.FuncMap{
-		"formatPrice": func(price float64) string {
-			return fmt.Sprintf("%.2f €", price)
+		// Los precios se muestran en la moneda del usuario (clave "Currency" del contexto):
+		// {{ price .Price $.Currency }}
+		"price": func(amount float64, currency string) string {
+			return model.FormatPrice(tb.prices.FromComparison(amount, currency))
+		},
+		// convertPrice devuelve el importe en la moneda del usuario para los campos de los formularios
+		"convertPrice": func(amount float64, currency string) float64 {
+			converted, _ := tb.prices.FromComparison(amount, currency)
+			return math.Round(converted*100) / 100
+		},
+		"currencySymbol": model.CurrencySymbol,
+		"ruleDescription": func(alert *model.PriceAlert, currency string) string {
+			return alert.RuleDescription(tb.formatter(currency))
+		},
+		"searchDescription": func(search *model.SavedSearch, currency string) string {
+			return search.Describe(tb.formatter(currency))
 		},
 		// Funciones matemáticas para la watchlist
 		"subFloat": func(a, b float64) float64 {
@@ -51,6 +76,13 @@ func (tb *TemplateBuilder) LoadTemplates() error {
 		"mul": func(a, b float64) float64 {
 			return a * b
 		},
+		// deref devuelve el valor de un importe opcional (envío, importación) o 0 si no se conoce
+		"deref": func(value *float64) float64 {
+			if value == nil {
+				return 0
+			}
+			return *value
+		},
 		// Funciones de utilidad para paginación
 		"sub": func(a, b int) int {
 			return a - b
@@ -114,6 +146,15 @@ func (tb *TemplateBuilder) LoadTemplates() error {
 		"notifications.html",
 		"reset_password.html",
 		"forgot_password.html",
+		"store_health.html",
+		"exchange_rates.html",
+		"track_url.html",
+		"saved_searches.html",
+		"notification_channels.html",
+		"email_outbox.html",
+		"email_preferences.html",
+		"unsubscribe.html",
+		"price_preferences.html",
 	}
 
 	// Crear y compilar cada plantilla
@@ -209,6 +250,13 @@ func (tb *TemplateBuilder) Render(c *gin.Context, status int, name string, data
 	}
 }
 
+// formatter devuelve el formateador de precios de la moneda de un usuario
+func (tb *TemplateBuilder) formatter(currency string) model.PriceFormatter {
+	return func(amount float64) string {
+		return model.FormatPrice(tb.prices.FromComparison(amount, currency))
+	}
+}
+
 // readTemplateFile lee el contenido de un archivo de plantilla
 func (tb *TemplateBuilder) readTemplateFile(path string) (string, error) {
 	// En lugar de usar templates hardcoded, vamos a leer los archivos reales
@@ -220,8 +268,8 @@ func (tb *TemplateBuilder) readTemplateFile(path string) (string, error) {
 }
 
 // BuildTemplates configura todas las plantillas y devuelve un renderizador
-func BuildTemplates() (*TemplateBuilder, error) {
-	builder := NewTemplateBuilder()
+func BuildTemplates(prices PriceConverter) (*TemplateBuilder, error) {
+	builder := NewTemplateBuilder(prices)
 	if err := builder.LoadTemplates(); err != nil {
 		return nil, err
 	}
@@ -35,9 +35,22 @@ type CategoryViewModel struct {
 
 // PriceViewModel representa los datos de precio para las vistas
 type PriceViewModel struct {
-	Store string
-	Price float64
-	URL   string
+	Store         string
+	Price         float64 // Precio con el que se compara (el del producto o el coste total), en la moneda de visualización
+	OriginalPrice float64 // Precio en la moneda de la tienda
+	Currency      string  // Moneda de la tienda
+	URL           string
+
+	ShippingCost   *float64 // Gastos de envío en la moneda de la tienda (nil si no se conocen)
+	ImportCharges  *float64 // Cargos de importación en la moneda de la tienda (nil si no se conocen)
+	LandedCost     float64  // Coste total (precio + envío + importación) en la moneda de visualización
+	SellerLocation string
+	TaxNote        string
+
+	Condition      string // Estado del artículo para mostrar ("Usado", "Reacondicionado"...); vacío si no se conoce
+	IsAuction      bool
+	SellerName     string
+	SellerFeedback *int
 }
 
 // SimilarProductViewModel representa un producto similar para mostrar en "Productos similares"
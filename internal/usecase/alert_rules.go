@@ -0,0 +1,196 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"app/internal/domain/model"
+)
+
+// ruleMatch es el resultado de una regla de alerta que se cumple
+type ruleMatch struct {
+	price     *model.Price // Oferta con la que se avisa
+	reference float64      // Precio con el que se ha comparado (objetivo, precio inicial, mínimo, media...)
+}
+
+// evaluateRule evalúa la regla de la alerta con las ofertas actuales del producto y devuelve nil si
+// no se cumple. Si el estado de la regla cambia (primer precio de referencia, producto agotado) lo guarda
+func (uc *PriceAlertUseCase) evaluateRule(ctx context.Context, alert *model.PriceAlert) *ruleMatch {
+	if alert.RuleType == model.AlertRuleStorePrice {
+		price, err := uc.bestStoreOffer(ctx, alert)
+		if err != nil {
+			log.Printf("Error al obtener las ofertas del producto %d: %v", alert.ProductID, err)
+			return nil
+		}
+		if price == nil || alert.OfferFilter.PriceOf(price) > alert.TargetPrice {
+			uc.rearmTrigger(ctx, alert)
+			return nil
+		}
+		return &ruleMatch{price: price, reference: alert.TargetPrice}
+	}
+
+	best, err := uc.priceRepo.FindBestPriceByProductID(ctx, alert.ProductID, alert.OfferFilter)
+	if err != nil {
+		log.Printf("No se pudo obtener el mejor precio para producto %d: %v", alert.ProductID, err)
+		return nil
+	}
+
+	if alert.RuleType == model.AlertRuleBackInStock {
+		// FindBestPriceByProductID solo tiene en cuenta las ofertas disponibles
+		if best == nil {
+			if alert.LastAvailable {
+				alert.LastAvailable = false
+				uc.saveRuleState(ctx, alert)
+			}
+			return nil
+		}
+		if alert.LastAvailable {
+			return nil
+		}
+		return &ruleMatch{price: best, reference: alert.OfferFilter.PriceOf(best)}
+	}
+
+	if best == nil {
+		return nil
+	}
+	current := alert.OfferFilter.PriceOf(best)
+
+	switch alert.RuleType {
+	case model.AlertRulePercentDrop, model.AlertRuleAnyDrop:
+		if alert.BaselinePrice <= 0 {
+			// La alerta se creó cuando no había precios: el primero que se ve es la referencia
+			alert.BaselinePrice = current
+			uc.saveRuleState(ctx, alert)
+			return nil
+		}
+		if alert.RuleType == model.AlertRulePercentDrop {
+			if current > alert.BaselinePrice*(1-alert.RuleValue/100) {
+				uc.rearmTrigger(ctx, alert)
+				return nil
+			}
+		} else if current >= alert.BaselinePrice {
+			uc.rearmTrigger(ctx, alert)
+			return nil
+		}
+		return &ruleMatch{price: best, reference: alert.BaselinePrice}
+
+	case model.AlertRuleAllTimeLow:
+		// El histórico incluye la lectura actual; se compara con lo observado antes de ella. El límite se
+		// recorta a milisegundos porque la base de datos redondea así observed_at: sin recortarlo, una
+		// lectura redondeada hacia abajo quedaría antes del límite y contaría como anterior a sí misma
+		before := best.RetrievedAt.Truncate(time.Millisecond)
+		stats, err := uc.historyRepo.FindPriceStats(ctx, alert.ProductID, time.Time{}, before)
+		if err != nil {
+			log.Printf("Error al obtener el histórico del producto %d: %v", alert.ProductID, err)
+			return nil
+		}
+		if stats.Count == 0 || historyPrice(best) >= stats.Lowest {
+			return nil
+		}
+		return &ruleMatch{price: best, reference: stats.Lowest}
+
+	case model.AlertRuleBelowAverage:
+		now := time.Now()
+		from := now.AddDate(0, 0, -int(alert.RuleValue))
+		stats, err := uc.historyRepo.FindPriceStats(ctx, alert.ProductID, from, now)
+		if err != nil {
+			log.Printf("Error al obtener el histórico del producto %d: %v", alert.ProductID, err)
+			return nil
+		}
+		if stats.Count == 0 || historyPrice(best) >= stats.Average {
+			return nil
+		}
+		return &ruleMatch{price: best, reference: stats.Average}
+
+	default:
+		if current > alert.TargetPrice {
+			uc.rearmTrigger(ctx, alert)
+			return nil
+		}
+		return &ruleMatch{price: best, reference: alert.TargetPrice}
+	}
+}
+
+// initRuleState toma el estado inicial de la regla al crear o editar la alerta: el mejor precio
+// actual como referencia y si hay alguna oferta disponible
+func (uc *PriceAlertUseCase) initRuleState(ctx context.Context, alert *model.PriceAlert) {
+	alert.BaselinePrice = 0
+	alert.LastAvailable = false
+
+	best, err := uc.priceRepo.FindBestPriceByProductID(ctx, alert.ProductID, alert.OfferFilter)
+	if err != nil {
+		log.Printf("No se pudo obtener el mejor precio para producto %d: %v", alert.ProductID, err)
+		return
+	}
+	if best != nil {
+		alert.BaselinePrice = alert.OfferFilter.PriceOf(best)
+		alert.LastAvailable = true
+	}
+}
+
+// saveRuleState guarda el estado de la regla sin tocar el resto de la alerta
+func (uc *PriceAlertUseCase) saveRuleState(ctx context.Context, alert *model.PriceAlert) {
+	if alert.ID == 0 {
+		return
+	}
+	if err := uc.priceAlertRepo.UpdateRuleState(ctx, alert.ID, alert.BaselinePrice, alert.LastAvailable); err != nil {
+		log.Printf("Error al guardar el estado de la alerta %d: %v", alert.ID, err)
+	}
+}
+
+// bestStoreOffer devuelve la mejor oferta disponible de la tienda de la regla que pasa el filtro
+// de la alerta, o nil si la tienda no tiene ninguna
+func (uc *PriceAlertUseCase) bestStoreOffer(ctx context.Context, alert *model.PriceAlert) (*model.Price, error) {
+	prices, err := uc.priceRepo.FindByProductID(ctx, alert.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *model.Price
+	for _, price := range prices {
+		if !price.IsAvailable || !strings.EqualFold(price.Store, alert.RuleStore) || !alert.OfferFilter.Matches(price) {
+			continue
+		}
+		if best == nil || alert.OfferFilter.PriceOf(price) < alert.OfferFilter.PriceOf(best) {
+			best = price
+		}
+	}
+	return best, nil
+}
+
+// historyPrice devuelve el precio de la oferta comparable con el histórico, que guarda el precio
+// normalizado sin gastos de envío
+func historyPrice(price *model.Price) float64 {
+	if price.NormalizedPrice > 0 {
+		return price.NormalizedPrice
+	}
+	return price.Price
+}
+
+// alertMessage compone el texto de la notificación según la regla que se ha cumplido. format da
+// formato a los importes en la moneda del usuario
+func alertMessage(alert *model.PriceAlert, match *ruleMatch, format model.PriceFormatter) string {
+	price := match.price
+	switch alert.RuleType {
+	case model.AlertRulePercentDrop, model.AlertRuleAnyDrop:
+		return fmt.Sprintf("El precio actual es %s en %s, un %.0f%% menos que los %s de cuando creaste la alerta.",
+			format(alert.OfferFilter.PriceOf(price)), price.Store, (1-alert.OfferFilter.PriceOf(price)/match.reference)*100, format(match.reference))
+	case model.AlertRuleAllTimeLow:
+		return fmt.Sprintf("Nuevo mínimo histórico: %s en %s (el anterior era %s).",
+			format(historyPrice(price)), price.Store, format(match.reference))
+	case model.AlertRuleBackInStock:
+		return fmt.Sprintf("Vuelve a estar disponible en %s por %s.", price.Store, format(alert.OfferFilter.PriceOf(price)))
+	case model.AlertRuleStorePrice:
+		return fmt.Sprintf("El precio en %s es %s, por debajo de tu objetivo de %s.",
+			price.Store, format(alert.OfferFilter.PriceOf(price)), format(match.reference))
+	case model.AlertRuleBelowAverage:
+		return fmt.Sprintf("El precio actual es %s en %s, por debajo de la media de los últimos %.0f días (%s).",
+			format(historyPrice(price)), price.Store, alert.RuleValue, format(match.reference))
+	default:
+		return fmt.Sprintf("El precio actual es %s en %s, por debajo de tu objetivo de %s.",
+			format(alert.OfferFilter.PriceOf(price)), price.Store, format(match.reference))
+	}
+}
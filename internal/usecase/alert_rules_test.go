@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"app/internal/domain/model"
+	"app/internal/domain/repositories"
+)
+
+// fakeBestPriceRepo devuelve siempre la misma mejor oferta
+type fakeBestPriceRepo struct {
+	repositories.PriceRepository
+
+	best *model.Price
+}
+
+func (r *fakeBestPriceRepo) FindBestPriceByProductID(context.Context, uint, model.OfferFilter) (*model.Price, error) {
+	return r.best, nil
+}
+
+// fakeHistoryRepo calcula las estadísticas del histórico en memoria, con el mismo rango que la
+// consulta real: desde from (incluida) hasta to (excluida)
+type fakeHistoryRepo struct {
+	repositories.PriceHistoryRepository
+
+	observations []*model.PriceObservation
+}
+
+func (r *fakeHistoryRepo) FindPriceStats(_ context.Context, _ uint, from, to time.Time) (*model.PriceStats, error) {
+	stats := &model.PriceStats{}
+	var sum float64
+	for _, o := range r.observations {
+		if o.ObservedAt.Before(from) || !o.ObservedAt.Before(to) {
+			continue
+		}
+		if stats.Count == 0 || o.NormalizedPrice < stats.Lowest {
+			stats.Lowest = o.NormalizedPrice
+		}
+		sum += o.NormalizedPrice
+		stats.Count++
+	}
+	if stats.Count > 0 {
+		stats.Average = sum / float64(stats.Count)
+	}
+	return stats, nil
+}
+
+func TestAllTimeLowExcludesCurrentObservation(t *testing.T) {
+	base := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		retrievedAt time.Time
+	}{
+		{"sin fracciones de milisegundo", base.Add(123 * time.Millisecond)},
+		{"la base de datos redondea hacia abajo", base.Add(123*time.Millisecond + 400*time.Microsecond)},
+		{"la base de datos redondea hacia arriba", base.Add(123*time.Millisecond + 600*time.Microsecond)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			best := &model.Price{ProductID: 7, Store: "coolmod", Price: 80, NormalizedPrice: 80, IsAvailable: true, RetrievedAt: tt.retrievedAt}
+			history := &fakeHistoryRepo{observations: []*model.PriceObservation{
+				{ProductID: 7, Store: "coolmod", NormalizedPrice: 95, IsAvailable: true, ObservedAt: base.Add(-24 * time.Hour)},
+				{ProductID: 7, Store: "aussar", NormalizedPrice: 90, IsAvailable: true, ObservedAt: base.Add(-time.Hour)},
+				// La lectura actual, con observed_at redondeado a milisegundos como en DATETIME(3)
+				{ProductID: 7, Store: "coolmod", NormalizedPrice: 80, IsAvailable: true, ObservedAt: tt.retrievedAt.Round(time.Millisecond)},
+			}}
+			uc := &PriceAlertUseCase{priceRepo: &fakeBestPriceRepo{best: best}, historyRepo: history}
+
+			alert := &model.PriceAlert{ID: 1, ProductID: 7, IsActive: true, AlertRule: model.AlertRule{RuleType: model.AlertRuleAllTimeLow}}
+			match := uc.evaluateRule(context.Background(), alert)
+			if match == nil {
+				t.Fatal("un precio por debajo de todo el histórico anterior debería disparar la alerta")
+			}
+			if match.reference != 90 {
+				t.Errorf("mínimo anterior = %.2f, se esperaba 90.00", match.reference)
+			}
+		})
+	}
+}
@@ -0,0 +1,327 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"app/internal/domain/model"
+	"app/internal/domain/repositories"
+	"app/pkg/config"
+
+	"github.com/spf13/viper"
+)
+
+// rateFileEntry es una entrada del archivo de tipos de cambio
+type rateFileEntry struct {
+	Currency string  `mapstructure:"currency"`
+	Rate     float64 `mapstructure:"rate"`
+	Date     string  `mapstructure:"date"` // Formato AAAA-MM-DD
+}
+
+// CurrencyUseCase gestiona los tipos de cambio y convierte los precios a la moneda de visualización
+type CurrencyUseCase struct {
+	rateRepo  repositories.ExchangeRateRepository
+	priceRepo repositories.PriceRepository
+	base      string
+	display   string
+	landed    bool // Comparar por coste total a los usuarios que no eligen (currency.compare_landed_cost)
+
+	mu    sync.RWMutex
+	rates map[string]float64 // Unidades de la moneda base por unidad de cada moneda (tipo más reciente)
+}
+
+// NewCurrencyUseCase crea una nueva instancia del caso de uso de monedas
+func NewCurrencyUseCase(rateRepo repositories.ExchangeRateRepository, priceRepo repositories.PriceRepository, cfg config.CurrencyConfig) *CurrencyUseCase {
+	base := strings.ToUpper(cfg.Base)
+	if base == "" {
+		base = "EUR"
+	}
+	display := strings.ToUpper(cfg.Display)
+	if display == "" {
+		display = base
+	}
+
+	return &CurrencyUseCase{
+		rateRepo:  rateRepo,
+		priceRepo: priceRepo,
+		base:      base,
+		display:   display,
+		landed:    cfg.CompareLandedCost,
+		rates:     map[string]float64{base: 1},
+	}
+}
+
+// BaseCurrency devuelve la moneda respecto a la que se expresan los tipos de cambio
+func (uc *CurrencyUseCase) BaseCurrency() string {
+	return uc.base
+}
+
+// DisplayCurrency devuelve la moneda en la que se comparan y muestran los precios
+func (uc *CurrencyUseCase) DisplayCurrency() string {
+	return uc.display
+}
+
+// LoadRates carga en memoria el tipo de cambio más reciente de cada moneda
+func (uc *CurrencyUseCase) LoadRates(ctx context.Context) error {
+	latest, err := uc.rateRepo.FindLatest(ctx)
+	if err != nil {
+		return fmt.Errorf("error al cargar los tipos de cambio: %w", err)
+	}
+
+	rates := map[string]float64{uc.base: 1}
+	for _, rate := range latest {
+		if rate.Currency != uc.base && rate.Rate > 0 {
+			rates[rate.Currency] = rate.Rate
+		}
+	}
+
+	uc.mu.Lock()
+	uc.rates = rates
+	uc.mu.Unlock()
+
+	if _, ok := rates[uc.display]; !ok {
+		log.Printf("[MONEDAS] ⚠️ No hay tipo de cambio para la moneda de visualización %s; los precios se compararán sin convertir", uc.display)
+	}
+	return nil
+}
+
+// ImportRatesFile guarda los tipos de cambio de un archivo YAML y recarga los de memoria.
+// Si el archivo no existe no hace nada. Devuelve el número de tipos importados
+func (uc *CurrencyUseCase) ImportRatesFile(ctx context.Context, path string) (int, error) {
+	if path == "" {
+		return 0, nil
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return 0, fmt.Errorf("error al leer los tipos de cambio %s: %w", path, err)
+	}
+
+	var entries []rateFileEntry
+	if err := v.UnmarshalKey("rates", &entries); err != nil {
+		return 0, fmt.Errorf("error al interpretar los tipos de cambio %s: %w", path, err)
+	}
+
+	for i, entry := range entries {
+		date, err := time.Parse("2006-01-02", entry.Date)
+		if err != nil {
+			return i, fmt.Errorf("%s: fecha no válida para %s (%q), usa AAAA-MM-DD", path, entry.Currency, entry.Date)
+		}
+		if err := uc.saveRate(ctx, entry.Currency, entry.Rate, date, model.ExchangeRateSourceFile); err != nil {
+			return i, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	return len(entries), uc.LoadRates(ctx)
+}
+
+// SetRate guarda un tipo de cambio introducido por un administrador y recalcula los precios normalizados
+func (uc *CurrencyUseCase) SetRate(ctx context.Context, currency string, rate float64, date time.Time) error {
+	if err := uc.saveRate(ctx, currency, rate, date, model.ExchangeRateSourceAdmin); err != nil {
+		return err
+	}
+	if err := uc.LoadRates(ctx); err != nil {
+		return err
+	}
+
+	if _, err := uc.RenormalizePrices(ctx); err != nil {
+		return err
+	}
+	return nil
+}
+
+// saveRate valida y guarda un tipo de cambio
+func (uc *CurrencyUseCase) saveRate(ctx context.Context, currency string, rate float64, date time.Time, source string) error {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if len(currency) != 3 {
+		return fmt.Errorf("moneda no válida: %q (usa el código ISO de 3 letras)", currency)
+	}
+	if currency == uc.base {
+		return fmt.Errorf("%s es la moneda base: su tipo de cambio es siempre 1", currency)
+	}
+	if rate <= 0 {
+		return fmt.Errorf("tipo de cambio no válido para %s: %v", currency, rate)
+	}
+
+	exchangeRate := &model.ExchangeRate{
+		Currency: currency,
+		Rate:     rate,
+		Date:     date,
+		Source:   source,
+	}
+	if err := uc.rateRepo.Save(ctx, exchangeRate); err != nil {
+		return fmt.Errorf("error al guardar el tipo de cambio de %s: %w", currency, err)
+	}
+	return nil
+}
+
+// GetLatestRates devuelve el tipo de cambio vigente de cada moneda
+func (uc *CurrencyUseCase) GetLatestRates(ctx context.Context) ([]*model.ExchangeRate, error) {
+	rates, err := uc.rateRepo.FindLatest(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los tipos de cambio: %w", err)
+	}
+	return rates, nil
+}
+
+// Convert convierte un importe entre dos monedas con los tipos de cambio vigentes
+func (uc *CurrencyUseCase) Convert(amount float64, from, to string) (float64, error) {
+	factor, ok := uc.factor(strings.ToUpper(from), strings.ToUpper(to))
+	if !ok {
+		return 0, fmt.Errorf("no hay tipo de cambio de %s a %s", from, to)
+	}
+	return amount * factor, nil
+}
+
+// Currencies devuelve las monedas con tipo de cambio, que son las que puede elegir un usuario para ver los precios
+func (uc *CurrencyUseCase) Currencies() []string {
+	uc.mu.RLock()
+	currencies := make([]string, 0, len(uc.rates)+1)
+	for currency := range uc.rates {
+		currencies = append(currencies, currency)
+	}
+	uc.mu.RUnlock()
+
+	if !slices.Contains(currencies, uc.display) {
+		currencies = append(currencies, uc.display)
+	}
+	sort.Strings(currencies)
+	return currencies
+}
+
+// IsSupported indica si se pueden mostrar los precios en la moneda indicada
+func (uc *CurrencyUseCase) IsSupported(currency string) bool {
+	_, ok := uc.factor(uc.display, strings.ToUpper(currency))
+	return ok
+}
+
+// UserCurrency devuelve la moneda en la que ve los precios un usuario: la que ha elegido o, si no ha
+// elegido ninguna, no está logueado o su moneda ya no tiene tipo de cambio, la moneda de
+// visualización de la configuración
+func (uc *CurrencyUseCase) UserCurrency(user *model.User) string {
+	if user == nil || user.Currency == "" || !uc.IsSupported(user.Currency) {
+		return uc.display
+	}
+	return strings.ToUpper(user.Currency)
+}
+
+// CompareLandedCost indica si un usuario compara los precios por coste total (precio, envío e
+// importación): lo que ha elegido o, si no ha elegido o no está logueado, lo que indica la
+// configuración
+func (uc *CurrencyUseCase) CompareLandedCost(user *model.User) bool {
+	if user == nil || user.CompareLandedCost == nil {
+		return uc.landed
+	}
+	return *user.CompareLandedCost
+}
+
+// OfferFilter devuelve el filtro de ofertas con el que un usuario ve los listados y el mejor
+// precio de cada producto
+func (uc *CurrencyUseCase) OfferFilter(user *model.User) model.OfferFilter {
+	return model.OfferFilter{LandedCost: uc.CompareLandedCost(user)}
+}
+
+// FromComparison pasa un importe de la moneda de visualización, en la que se guardan y comparan los
+// precios, a la moneda del usuario. Si no hay tipo de cambio devuelve el importe sin convertir y la
+// moneda de visualización para que nunca se muestre un importe con una moneda que no es la suya
+func (uc *CurrencyUseCase) FromComparison(amount float64, currency string) (float64, string) {
+	currency = strings.ToUpper(currency)
+	factor, ok := uc.factor(uc.display, currency)
+	if !ok {
+		return amount, uc.display
+	}
+	return amount * factor, currency
+}
+
+// ToComparison pasa un importe introducido por el usuario en su moneda (precio objetivo, filtros de
+// precio) a la moneda de visualización, redondeado a céntimos
+func (uc *CurrencyUseCase) ToComparison(amount float64, currency string) float64 {
+	factor, ok := uc.factor(strings.ToUpper(currency), uc.display)
+	if !ok || factor == 1 {
+		return amount
+	}
+	return math.Round(amount*factor*100) / 100
+}
+
+// Format convierte un importe de la moneda de visualización a la moneda indicada y le da formato
+func (uc *CurrencyUseCase) Format(amount float64, currency string) string {
+	return model.FormatPrice(uc.FromComparison(amount, currency))
+}
+
+// Formatter devuelve el formateador de precios de una moneda para los textos de los avisos
+func (uc *CurrencyUseCase) Formatter(currency string) model.PriceFormatter {
+	return func(amount float64) string {
+		return uc.Format(amount, currency)
+	}
+}
+
+// Normalize calcula el precio y el coste total en la moneda de visualización. Si la moneda del
+// precio no tiene tipo de cambio se usan los importes originales
+func (uc *CurrencyUseCase) Normalize(price *model.Price) {
+	if price.Currency == "" {
+		price.Currency = uc.base
+	}
+
+	factor, ok := uc.factor(strings.ToUpper(price.Currency), uc.display)
+	if !ok {
+		log.Printf("[MONEDAS] No hay tipo de cambio de %s a %s: se compara %s sin convertir", price.Currency, uc.display, price.Store)
+		factor = 1
+	}
+	price.NormalizedPrice = price.Price * factor
+	price.NormalizedTotal = price.LandedCost() * factor
+}
+
+// RenormalizePrices recalcula el precio normalizado de todos los precios guardados con los
+// tipos de cambio vigentes. Se ejecuta al arrancar y cada vez que cambia un tipo
+func (uc *CurrencyUseCase) RenormalizePrices(ctx context.Context) (int, error) {
+	uc.mu.RLock()
+	currencies := make([]string, 0, len(uc.rates))
+	for currency := range uc.rates {
+		currencies = append(currencies, currency)
+	}
+	uc.mu.RUnlock()
+
+	factors := make(map[string]float64, len(currencies))
+	for _, currency := range currencies {
+		if factor, ok := uc.factor(currency, uc.display); ok {
+			factors[currency] = factor
+		}
+	}
+
+	updated, err := uc.priceRepo.NormalizePrices(ctx, factors)
+	if err != nil {
+		return 0, fmt.Errorf("error al recalcular los precios normalizados: %w", err)
+	}
+
+	log.Printf("[MONEDAS] %d precios normalizados a %s", updated, uc.display)
+	return updated, nil
+}
+
+// factor devuelve el multiplicador para pasar un importe de la moneda from a la moneda to
+func (uc *CurrencyUseCase) factor(from, to string) (float64, bool) {
+	if from == to {
+		return 1, true
+	}
+
+	uc.mu.RLock()
+	defer uc.mu.RUnlock()
+
+	fromRate, okFrom := uc.rates[from]
+	toRate, okTo := uc.rates[to]
+	if !okFrom || !okTo {
+		return 0, false
+	}
+	return fromRate / toRate, true
+}
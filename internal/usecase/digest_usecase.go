@@ -0,0 +1,237 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"app/internal/domain/model"
+	"app/internal/domain/repositories"
+	"app/internal/infrastructure/email"
+)
+
+// ErrInvalidDigestFrequency indica que la frecuencia de envío de los avisos no es válida
+var ErrInvalidDigestFrequency = errors.New("frecuencia de envío no válida")
+
+// digestMinPriceChange es la variación mínima de precio que se considera un movimiento en el resumen
+const digestMinPriceChange = 0.01
+
+// DigestUseCase envía a los usuarios que lo prefieren un resumen diario o semanal con sus avisos
+// en lugar de un correo por aviso. Las notificaciones de la aplicación son la fuente de los avisos:
+// las que se crean para estos usuarios quedan pendientes hasta el siguiente resumen. Además, el
+// resumen incluye los cambios de precio de la lista de seguimiento desde el resumen anterior
+type DigestUseCase struct {
+	userRepo          repositories.UserRepository
+	notificationRepo  repositories.NotificationRepository
+	watchlistItemRepo repositories.WatchlistItemRepository
+	priceRepo         repositories.PriceRepository
+	mailer            *email.Mailer
+	currency          *CurrencyUseCase // Pasa los importes de los resúmenes a la moneda de cada usuario
+}
+
+// NewDigestUseCase crea una nueva instancia del caso de uso de resúmenes por correo
+func NewDigestUseCase(
+	userRepo repositories.UserRepository,
+	notificationRepo repositories.NotificationRepository,
+	watchlistItemRepo repositories.WatchlistItemRepository,
+	priceRepo repositories.PriceRepository,
+	mailer *email.Mailer,
+	currency *CurrencyUseCase,
+) *DigestUseCase {
+	return &DigestUseCase{
+		userRepo:          userRepo,
+		notificationRepo:  notificationRepo,
+		watchlistItemRepo: watchlistItemRepo,
+		priceRepo:         priceRepo,
+		mailer:            mailer,
+		currency:          currency,
+	}
+}
+
+// DigestStats resume una pasada de envío de resúmenes
+type DigestStats struct {
+	Sent    int // Resúmenes enviados
+	Empty   int // Usuarios sin avisos ni cambios de precio desde el resumen anterior
+	Failed  int // Usuarios cuyo resumen no se ha podido preparar o guardar en la bandeja de salida
+	Skipped int // Usuarios dados de baja de los resúmenes
+}
+
+// SetDigestFrequency guarda cada cuánto recibe el usuario sus avisos por correo. Al volver al envío
+// inmediato, los avisos pendientes de resumen se descartan: siguen en sus notificaciones
+func (uc *DigestUseCase) SetDigestFrequency(ctx context.Context, userID uint, frequency string) error {
+	if !model.IsValidDigestFrequency(frequency) {
+		return ErrInvalidDigestFrequency
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("usuario no encontrado: %w", err)
+	}
+	if user.DigestFrequency == frequency {
+		return nil
+	}
+
+	user.DigestFrequency = frequency
+	user.UpdatedAt = time.Now()
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("error al guardar la frecuencia de envío: %w", err)
+	}
+
+	if !user.WantsDigest() {
+		if err := uc.notificationRepo.ClearDigestPendingByUserID(ctx, userID); err != nil {
+			return fmt.Errorf("error al descartar los avisos pendientes de resumen: %w", err)
+		}
+	}
+	return nil
+}
+
+// SendDigests envía el resumen a todos los usuarios con la frecuencia indicada (model.DigestDaily o
+// model.DigestWeekly). A los usuarios sin avisos ni cambios de precio no se les envía nada. Los
+// avisos solo llegan al resumen si el usuario acepta los correos de alertas; los cambios de precio
+// de la lista de seguimiento, siempre que acepte los resúmenes
+func (uc *DigestUseCase) SendDigests(ctx context.Context, frequency string) (DigestStats, error) {
+	var stats DigestStats
+	if frequency != model.DigestDaily && frequency != model.DigestWeekly {
+		return stats, ErrInvalidDigestFrequency
+	}
+
+	users, err := uc.userRepo.FindByDigestFrequency(ctx, frequency)
+	if err != nil {
+		return stats, fmt.Errorf("error al obtener los usuarios con resumen %s: %w", frequency, err)
+	}
+
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return stats, ctx.Err()
+		}
+
+		if !user.AcceptsEmail(model.EmailCategoryDigest) || user.Email == "" {
+			// Estos usuarios se han dado de baja de los resúmenes: sus avisos no deben acumularse
+			// para cuando vuelvan a activarlos
+			if err := uc.notificationRepo.ClearDigestPendingByUserID(ctx, user.ID); err != nil {
+				log.Printf("[RESUMEN] Error al descartar los avisos pendientes del usuario %d: %v", user.ID, err)
+			}
+			stats.Skipped++
+			continue
+		}
+
+		sent, err := uc.sendDigest(ctx, user, frequency)
+		switch {
+		case err != nil:
+			stats.Failed++
+			log.Printf("[RESUMEN] Error al enviar el resumen del usuario %d: %v", user.ID, err)
+		case sent:
+			stats.Sent++
+		default:
+			stats.Empty++
+		}
+	}
+
+	return stats, nil
+}
+
+// sendDigest prepara y guarda en la bandeja de salida el resumen de un usuario. Devuelve false si
+// no tenía nada que enviar
+func (uc *DigestUseCase) sendDigest(ctx context.Context, user *model.User, frequency string) (bool, error) {
+	notifications, err := uc.notificationRepo.FindDigestPendingByUserID(ctx, user.ID)
+	if err != nil {
+		return false, fmt.Errorf("error al obtener los avisos pendientes: %w", err)
+	}
+
+	movements, baselines, err := uc.watchlistMovements(ctx, user.ID, uc.currency.OfferFilter(user))
+	if err != nil {
+		return false, err
+	}
+
+	if len(notifications) > 0 || len(movements) > 0 {
+		// Los precios de referencia se guardan en la moneda de visualización; el correo los muestra
+		// en la del usuario
+		currency := uc.currency.UserCurrency(user)
+		for i := range movements {
+			movements[i].OldPrice, _ = uc.currency.FromComparison(movements[i].OldPrice, currency)
+			movements[i].NewPrice, _ = uc.currency.FromComparison(movements[i].NewPrice, currency)
+		}
+		digest := &email.Digest{
+			Frequency: frequency,
+			Since:     user.LastDigestAt,
+			Currency:  currency,
+			Movements: movements,
+		}
+		ids := make([]uint, 0, len(notifications))
+		for _, notification := range notifications {
+			ids = append(ids, notification.ID)
+			digest.Notifications = append(digest.Notifications, email.DigestNotification{
+				Title:     notification.Title,
+				Message:   notification.Message,
+				ProductID: notification.ProductID,
+				CreatedAt: notification.CreatedAt,
+			})
+		}
+
+		if err := uc.mailer.SendDigestEmail(user.Email, user.Username, digest); err != nil {
+			return false, err
+		}
+
+		// El correo ya está en la bandeja de salida: a partir de aquí los errores solo provocan que
+		// algún aviso se repita en el siguiente resumen
+		if err := uc.notificationRepo.ClearDigestPending(ctx, ids); err != nil {
+			log.Printf("[RESUMEN] Error al marcar los avisos enviados del usuario %d: %v", user.ID, err)
+		}
+		if err := uc.userRepo.UpdateLastDigestAt(ctx, user.ID, time.Now()); err != nil {
+			log.Printf("[RESUMEN] Error al guardar la fecha del resumen del usuario %d: %v", user.ID, err)
+		}
+	}
+
+	// Los precios de referencia se guardan también sin resumen, para que los productos añadidos a
+	// la lista tengan uno desde la primera pasada
+	for itemID, price := range baselines {
+		if err := uc.watchlistItemRepo.UpdateDigestPrice(ctx, itemID, price); err != nil {
+			log.Printf("[RESUMEN] Error al guardar el precio de referencia del elemento %d: %v", itemID, err)
+		}
+	}
+
+	return len(notifications) > 0 || len(movements) > 0, nil
+}
+
+// watchlistMovements compara el mejor precio actual de cada producto de la lista de seguimiento con
+// el del resumen anterior, comparando como indica el filtro del usuario (precio o coste total).
+// Devuelve los cambios y los nuevos precios de referencia por elemento
+func (uc *DigestUseCase) watchlistMovements(ctx context.Context, userID uint, filter model.OfferFilter) ([]email.DigestMovement, map[uint]float64, error) {
+	items, err := uc.watchlistItemRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener la lista de seguimiento: %w", err)
+	}
+
+	var movements []email.DigestMovement
+	baselines := make(map[uint]float64)
+	for _, item := range items {
+		best, err := uc.priceRepo.FindBestPriceByProductID(ctx, item.ProductID, filter)
+		if err != nil {
+			log.Printf("[RESUMEN] Error al obtener el mejor precio del producto %d: %v", item.ProductID, err)
+			continue
+		}
+		if best == nil || filter.PriceOf(best) <= 0 {
+			// Sin ofertas disponibles: se mantiene la referencia hasta que vuelva a haberlas
+			continue
+		}
+
+		price := filter.PriceOf(best)
+		if math.Abs(price-item.DigestPrice) < digestMinPriceChange {
+			continue
+		}
+		if item.DigestPrice > 0 {
+			movements = append(movements, email.DigestMovement{
+				ProductID:   item.ProductID,
+				ProductName: item.Product.Name,
+				OldPrice:    item.DigestPrice,
+				NewPrice:    price,
+			})
+		}
+		baselines[item.ID] = price
+	}
+
+	return movements, baselines, nil
+}
@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"app/internal/domain/model"
+	"app/internal/domain/repositories"
+	"app/internal/infrastructure/email"
+	"app/pkg/config"
+)
+
+// ErrOutboxEmailNotFailed indica que se ha pedido reenviar un correo que no está descartado
+var ErrOutboxEmailNotFailed = errors.New("el correo no está descartado")
+
+// outboxEmailsShown es el número de correos de cada estado que se muestran en la administración
+const outboxEmailsShown = 100
+
+// EmailOutboxUseCase envía los correos de la bandeja de salida. Los reintenta con espera
+// exponencial si el servidor SMTP falla y los descarta al agotar los intentos
+type EmailOutboxUseCase struct {
+	outboxRepo repositories.EmailOutboxRepository
+	mailer     *email.Mailer
+	cfg        config.EmailConfig
+}
+
+// NewEmailOutboxUseCase crea una nueva instancia del caso de uso de la bandeja de salida
+func NewEmailOutboxUseCase(outboxRepo repositories.EmailOutboxRepository, mailer *email.Mailer, cfg config.EmailConfig) *EmailOutboxUseCase {
+	return &EmailOutboxUseCase{
+		outboxRepo: outboxRepo,
+		mailer:     mailer,
+		cfg:        cfg,
+	}
+}
+
+// OutboxStats resume una pasada del worker por la bandeja de salida
+type OutboxStats struct {
+	Sent      int // Correos enviados
+	Retried   int // Correos que han fallado y se reintentarán
+	Discarded int // Correos que han agotado los intentos
+}
+
+// OutboxStatus es el estado de la bandeja de salida para la administración
+type OutboxStatus struct {
+	Counts  map[string]int64     // Correos de cada estado
+	Failed  []*model.OutboxEmail // Últimos correos descartados
+	Pending []*model.OutboxEmail // Correos pendientes que ya han fallado alguna vez
+}
+
+// ProcessOutbox envía los correos pendientes cuyo siguiente intento ya ha llegado
+func (uc *EmailOutboxUseCase) ProcessOutbox(ctx context.Context) (OutboxStats, error) {
+	var stats OutboxStats
+
+	due, err := uc.outboxRepo.FindDue(ctx, time.Now(), uc.cfg.OutboxBatchSize)
+	if err != nil {
+		return stats, fmt.Errorf("error al obtener los correos pendientes: %w", err)
+	}
+
+	for _, outboxEmail := range due {
+		if ctx.Err() != nil {
+			return stats, ctx.Err()
+		}
+
+		if err := uc.mailer.Deliver(outboxEmail); err != nil {
+			outboxEmail.RecordFailure(err, time.Now(), uc.cfg.OutboxMaxAttempts, uc.cfg.OutboxRetryDelay, uc.cfg.OutboxMaxDelay)
+			if outboxEmail.Status == model.OutboxFailed {
+				stats.Discarded++
+				log.Printf("[CORREO] Correo %d a %s descartado tras %d intentos: %v", outboxEmail.ID, outboxEmail.Recipient, outboxEmail.Attempts, err)
+			} else {
+				stats.Retried++
+				log.Printf("[CORREO] Correo %d a %s fallido (intento %d), se reintentará a las %s", outboxEmail.ID, outboxEmail.Recipient, outboxEmail.Attempts, outboxEmail.NextAttemptAt.Format("15:04:05"))
+			}
+		} else {
+			outboxEmail.RecordSent(time.Now())
+			stats.Sent++
+		}
+
+		if err := uc.outboxRepo.Update(ctx, outboxEmail); err != nil {
+			log.Printf("[CORREO] Error al guardar el estado del correo %d: %v", outboxEmail.ID, err)
+		}
+	}
+
+	return stats, nil
+}
+
+// PurgeSent elimina los correos enviados hace más tiempo que la retención configurada
+func (uc *EmailOutboxUseCase) PurgeSent(ctx context.Context) (int64, error) {
+	if uc.cfg.OutboxRetention <= 0 {
+		return 0, nil
+	}
+	return uc.outboxRepo.DeleteSentBefore(ctx, time.Now().Add(-uc.cfg.OutboxRetention))
+}
+
+// GetOutboxStatus devuelve el estado de la bandeja de salida: correos de cada estado, los últimos
+// descartados y los pendientes que ya han fallado alguna vez
+func (uc *EmailOutboxUseCase) GetOutboxStatus(ctx context.Context) (*OutboxStatus, error) {
+	counts, err := uc.outboxRepo.CountByStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error al contar los correos: %w", err)
+	}
+	failed, err := uc.outboxRepo.FindByStatus(ctx, model.OutboxFailed, outboxEmailsShown)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los correos descartados: %w", err)
+	}
+	pending, err := uc.outboxRepo.FindByStatus(ctx, model.OutboxPending, outboxEmailsShown)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los correos pendientes: %w", err)
+	}
+
+	retrying := make([]*model.OutboxEmail, 0, len(pending))
+	for _, outboxEmail := range pending {
+		if outboxEmail.Attempts > 0 {
+			retrying = append(retrying, outboxEmail)
+		}
+	}
+
+	return &OutboxStatus{Counts: counts, Failed: failed, Pending: retrying}, nil
+}
+
+// RetryEmail vuelve a poner en cola un correo descartado, con los intentos a cero
+func (uc *EmailOutboxUseCase) RetryEmail(ctx context.Context, id uint) error {
+	outboxEmail, err := uc.outboxRepo.FindByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("correo no encontrado: %w", err)
+	}
+	if outboxEmail.Status != model.OutboxFailed {
+		return ErrOutboxEmailNotFailed
+	}
+
+	outboxEmail.Status = model.OutboxPending
+	outboxEmail.Attempts = 0
+	outboxEmail.NextAttemptAt = time.Now()
+	if err := uc.outboxRepo.Update(ctx, outboxEmail); err != nil {
+		return fmt.Errorf("error al guardar el correo: %w", err)
+	}
+	return nil
+}
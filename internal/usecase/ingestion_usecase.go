@@ -0,0 +1,660 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"app/internal/domain/model"
+	"app/internal/domain/repositories"
+	"app/pkg/utils"
+
+	"github.com/corona10/goimagehash"
+)
+
+const (
+	// phashThreshold es la distancia máxima entre hashes de imagen para considerar dos productos iguales
+	phashThreshold = 5
+	// phashCandidates es el número de productos de la categoría con los que se compara el hash de imagen
+	phashCandidates = 200
+	// staleOfferAge es la antigüedad a partir de la cual la oferta de una tienda que ya no ha vuelto a
+	// aparecer se considera retirada y se elimina al terminar cada lote (sigue en el histórico)
+	staleOfferAge = 3 * 24 * time.Hour
+)
+
+// errProductDiscarded indica que un producto scrapeado no se ha guardado por no superar la validación
+// o no encajar en ninguna categoría. No es un fallo de la ingesta
+var errProductDiscarded = errors.New("producto descartado")
+
+// errOfferMismatch indica que la página vuelta a scrapear ya no corresponde a la oferta guardada
+// (la URL redirige a otro producto o sus identificadores no coinciden), por lo que no se registra
+var errOfferMismatch = errors.New("la página no corresponde a la oferta guardada")
+
+// PriceChangeHandler se llama cada vez que la ingesta registra una oferta nueva o con un precio,
+// coste total, disponibilidad, estado o tipo de anuncio distintos de los que tenía
+type PriceChangeHandler func(ctx context.Context, productID uint)
+
+// IngestionStats resume el resultado de guardar un lote de productos scrapeados
+type IngestionStats struct {
+	Found        int // Productos recibidos del scraper
+	Saved        int // Productos guardados con su precio (incluye los reclasificados)
+	Created      int // Productos nuevos en el catálogo
+	Reclassified int // Productos guardados en una categoría distinta a la scrapeada
+	Discarded    int // Productos no guardados (datos inválidos, sin categoría o error al guardar)
+}
+
+// ApplyTo copia los contadores en el registro de una ejecución del scraper
+func (s IngestionStats) ApplyTo(run *model.ScrapeRun) {
+	run.ItemsFound = s.Found
+	run.ItemsSaved = s.Saved
+	run.ItemsReclassified = s.Reclassified
+	run.ItemsDiscarded = s.Discarded
+}
+
+// IngestionUseCase es el único camino por el que los productos scrapeados entran en el catálogo.
+// Cada producto pasa por: validación → clasificación → búsqueda de uno existente (GTIN/MPN, hash
+// de imagen y slug) → alta o actualización del producto y sus identificadores → registro del
+// precio (normalizado a la moneda de visualización) y del histórico
+type IngestionUseCase struct {
+	categoryRepo   repositories.CategoryRepository
+	productRepo    repositories.ProductRepository
+	identifierRepo repositories.ProductIdentifierRepository
+	priceRepo      repositories.PriceRepository
+	historyRepo    repositories.PriceHistoryRepository
+	currency       *CurrencyUseCase
+	priceChanged   []PriceChangeHandler
+}
+
+// NewIngestionUseCase crea una nueva instancia del caso de uso de ingesta de productos
+func NewIngestionUseCase(
+	categoryRepo repositories.CategoryRepository,
+	productRepo repositories.ProductRepository,
+	identifierRepo repositories.ProductIdentifierRepository,
+	priceRepo repositories.PriceRepository,
+	historyRepo repositories.PriceHistoryRepository,
+	currency *CurrencyUseCase,
+) *IngestionUseCase {
+	return &IngestionUseCase{
+		categoryRepo:   categoryRepo,
+		productRepo:    productRepo,
+		identifierRepo: identifierRepo,
+		priceRepo:      priceRepo,
+		historyRepo:    historyRepo,
+		currency:       currency,
+	}
+}
+
+// OnPriceChange registra una función que se ejecuta, tras guardar el precio, cada vez que cambia
+// la oferta de una tienda para un producto (por ejemplo, para evaluar sus alertas de precio)
+func (uc *IngestionUseCase) OnPriceChange(handler PriceChangeHandler) {
+	uc.priceChanged = append(uc.priceChanged, handler)
+}
+
+// IngestProducts guarda un lote de productos scrapeados. Los productos que fallan se descartan
+// sin interrumpir el resto del lote
+func (uc *IngestionUseCase) IngestProducts(ctx context.Context, products []*model.Product) IngestionStats {
+	stats := IngestionStats{Found: len(products)}
+	if len(products) == 0 {
+		return stats
+	}
+
+	categories, err := uc.categoryRepo.GetAll(ctx)
+	if err != nil {
+		log.Printf("[INGESTA] Error al obtener categorías, no se podrán reclasificar productos: %v", err)
+	}
+
+	savedIDs := make(map[uint]bool)
+	for _, product := range products {
+		// Si se cancela la ejecución, los productos pendientes cuentan como descartados
+		if ctx.Err() != nil {
+			stats.Discarded = stats.Found - stats.Saved
+			break
+		}
+
+		outcome, err := uc.ingest(ctx, product, categories)
+		if err != nil {
+			if !errors.Is(err, errProductDiscarded) {
+				log.Printf("[INGESTA] Error al guardar '%s': %v", product.Name, err)
+			}
+			stats.Discarded++
+			continue
+		}
+
+		stats.Saved++
+		savedIDs[product.ID] = true
+		if outcome.created {
+			stats.Created++
+		}
+		if outcome.reclassified {
+			stats.Reclassified++
+		}
+	}
+
+	log.Printf("[INGESTA] Total: %d | Guardados: %d (nuevos: %d) | Reclasificados: %d | Descartados: %d",
+		stats.Found, stats.Saved, stats.Created, stats.Reclassified, stats.Discarded)
+
+	if ctx.Err() == nil {
+		uc.removeStaleOffers(ctx, savedIDs)
+	}
+
+	return stats
+}
+
+// removeStaleOffers elimina las ofertas de los productos guardados en el lote que llevan más de
+// staleOfferAge sin actualizarse: la tienda ya no lista el producto y su precio no debe seguir
+// contando como el mejor. Las lecturas anteriores se conservan en el histórico
+func (uc *IngestionUseCase) removeStaleOffers(ctx context.Context, productIDs map[uint]bool) {
+	minFreshTime := time.Now().Add(-staleOfferAge)
+
+	removed := 0
+	for productID := range productIDs {
+		prices, err := uc.priceRepo.FindByProductID(ctx, productID)
+		if err != nil {
+			log.Printf("[INGESTA] Error al obtener precios para limpiar antiguos del producto %d: %v", productID, err)
+			continue
+		}
+		for _, price := range prices {
+			if !price.RetrievedAt.Before(minFreshTime) {
+				continue
+			}
+			if err := uc.priceRepo.Delete(ctx, price.ID); err != nil {
+				log.Printf("[INGESTA] Error al eliminar el precio antiguo %d: %v", price.ID, err)
+				continue
+			}
+			removed++
+		}
+	}
+
+	if removed > 0 {
+		log.Printf("[LIMPIEZA] Se eliminaron %d ofertas que las tiendas ya no listan", removed)
+	}
+}
+
+// IngestProduct guarda un único producto scrapeado y lo devuelve con su ID en el catálogo
+func (uc *IngestionUseCase) IngestProduct(ctx context.Context, product *model.Product) (*model.Product, error) {
+	var categories []*model.Category
+	if !utils.ValidateProductCategory(product) {
+		var err error
+		if categories, err = uc.categoryRepo.GetAll(ctx); err != nil {
+			return nil, fmt.Errorf("error al obtener categorías: %w", err)
+		}
+	}
+
+	if _, err := uc.ingest(ctx, product, categories); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// RefreshOffer guarda la oferta obtenida al volver a scrapear la página de detalle de un producto
+// que ya está en el catálogo. No se clasifica ni se buscan duplicados: el precio se registra
+// directamente para productID, igual que en una ingesta normal, salvo que los identificadores o la
+// URL de la página ya no coincidan con los de la oferta guardada
+func (uc *IngestionUseCase) RefreshOffer(ctx context.Context, productID uint, scraped *model.Product) error {
+	if err := validateScrapedProduct(scraped); err != nil {
+		return fmt.Errorf("%w: %v", errProductDiscarded, err)
+	}
+	if err := uc.checkRefreshedOffer(ctx, productID, scraped); err != nil {
+		return err
+	}
+
+	if err := uc.identifierRepo.Save(ctx, productID, scraped.Identifiers); err != nil {
+		log.Printf("Error al guardar los identificadores del producto %d: %v", productID, err)
+	}
+	return uc.recordPrice(ctx, productID, scraped.Prices[0])
+}
+
+// ingestOutcome describe qué ha pasado con un producto guardado
+type ingestOutcome struct {
+	created      bool // Es nuevo en el catálogo
+	reclassified bool // Se ha guardado en una categoría distinta a la scrapeada
+}
+
+// ingest ejecuta el proceso completo para un producto. Al terminar, product.ID y product.CategoryID
+// son los del producto guardado
+func (uc *IngestionUseCase) ingest(ctx context.Context, product *model.Product, categories []*model.Category) (ingestOutcome, error) {
+	var outcome ingestOutcome
+
+	// 1. Validación
+	if err := validateScrapedProduct(product); err != nil {
+		log.Printf("[INGESTA] 🚫 Producto descartado: %v", err)
+		return outcome, fmt.Errorf("%w: %v", errProductDiscarded, err)
+	}
+
+	// 2. Clasificación
+	scrapedCategoryID := product.CategoryID
+	if !classifyProduct(product, categories) {
+		log.Printf("[CATEGORIZADOR] ❌ No se encontró categoría adecuada para '%s', producto descartado", product.Name)
+		return outcome, fmt.Errorf("%w: '%s' no encaja en ninguna categoría", errProductDiscarded, product.Name)
+	}
+	outcome.reclassified = product.CategoryID != scrapedCategoryID
+
+	// 3. Búsqueda de un producto existente
+	existing, err := uc.findExisting(ctx, product)
+	if err != nil {
+		return outcome, err
+	}
+
+	// 4. Alta o actualización del producto
+	outcome.created = existing == nil
+	if outcome.created {
+		err = uc.createProduct(ctx, product)
+	} else {
+		err = uc.updateProduct(ctx, existing, product)
+	}
+	if err != nil {
+		return outcome, err
+	}
+	if err := uc.identifierRepo.Save(ctx, product.ID, product.Identifiers); err != nil {
+		// El precio se guarda aunque fallen los identificadores
+		log.Printf("Error al guardar los identificadores del producto %d: %v", product.ID, err)
+	}
+
+	// 5. Precio vigente e histórico
+	if err := uc.recordPrice(ctx, product.ID, product.Prices[0]); err != nil {
+		return outcome, err
+	}
+
+	return outcome, nil
+}
+
+// validateScrapedProduct comprueba que el producto tiene los datos mínimos para guardarse
+func validateScrapedProduct(product *model.Product) error {
+	if strings.TrimSpace(product.Name) == "" {
+		return errors.New("producto sin nombre")
+	}
+	if len(product.Prices) == 0 {
+		return fmt.Errorf("'%s' no tiene precio", product.Name)
+	}
+
+	price := product.Prices[0]
+	if price.Price <= 0 {
+		return fmt.Errorf("'%s' tiene un precio no válido (%.2f)", product.Name, price.Price)
+	}
+	if price.Store == "" || price.URL == "" {
+		return fmt.Errorf("'%s' no indica la tienda o la URL de la oferta", product.Name)
+	}
+	return nil
+}
+
+// classifyProduct comprueba que el producto pertenece a su categoría y, si no, lo asigna a la primera
+// categoría válida. Devuelve false si no encaja en ninguna
+func classifyProduct(product *model.Product, categories []*model.Category) bool {
+	if utils.ValidateProductCategory(product) {
+		return true
+	}
+
+	originalCategoryID := product.CategoryID
+	for _, category := range categories {
+		// Probar con una copia para no modificar el producto si no es válido
+		candidate := *product
+		candidate.CategoryID = category.ID
+		if utils.ValidateProductCategory(&candidate) {
+			product.CategoryID = category.ID
+			log.Printf("[CATEGORIZADOR] ✅ Producto '%s' reclasificado de categoría %d a categoría: %s (ID: %d)",
+				product.Name, originalCategoryID, category.Name, category.ID)
+			return true
+		}
+	}
+	return false
+}
+
+// findExisting busca en el catálogo el mismo producto: primero por GTIN o MPN, después por hash
+// de imagen dentro de la categoría y, si no hay coincidencia, por slug. Las coincidencias por
+// imagen o slug se descartan si los dos productos tienen GTIN y no comparten ninguno.
+// Devuelve nil si el producto es nuevo
+func (uc *IngestionUseCase) findExisting(ctx context.Context, product *model.Product) (*model.Product, error) {
+	existing, err := uc.findByIdentifiers(ctx, product)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
+	if imageHash := uc.computeImageHash(product); imageHash != nil {
+		candidates, err := uc.productRepo.FindByCategory(ctx, product.CategoryID, phashCandidates, 0, "")
+		if err != nil {
+			log.Printf("Error al buscar productos existentes por categoría para deduplicación por pHash: %v", err)
+		}
+
+		for _, candidate := range candidates {
+			if candidate.ImageHash == nil {
+				continue
+			}
+
+			candidateHash := goimagehash.NewImageHash(*candidate.ImageHash, goimagehash.PHash)
+			isSimilar, err := utils.ComparePerceptionHashes(candidateHash, imageHash, phashThreshold)
+			if err != nil {
+				log.Printf("Error al comparar hashes para productos '%s' vs '%s': %v", product.Name, candidate.Name, err)
+				continue
+			}
+			if isSimilar && !uc.hasConflictingGTIN(ctx, candidate, product) {
+				log.Printf("✅ Producto similar encontrado por pHash: '%s' es similar a '%s'", product.Name, candidate.Name)
+				return candidate, nil
+			}
+		}
+	}
+
+	if product.Slug == "" {
+		product.Slug = utils.GenerateSlug(product.Name)
+	}
+	existing, err = uc.productRepo.FindBySlug(ctx, product.Slug)
+	if err != nil {
+		if errors.Is(err, repositories.ErrProductNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error al buscar producto existente por slug: %w", err)
+	}
+	if uc.hasConflictingGTIN(ctx, existing, product) {
+		log.Printf("El slug de '%s' coincide con '%s' pero su GTIN es distinto: se crea un producto nuevo", product.Name, existing.Name)
+		return nil, nil
+	}
+
+	log.Printf("✅ Producto existente encontrado por slug: '%s'", existing.Name)
+	return existing, nil
+}
+
+// findByIdentifiers busca el producto por sus identificadores, dando prioridad al GTIN. Un GTIN
+// identifica el producto en cualquier categoría; un MPN solo se acepta dentro de la misma
+// categoría, porque fabricantes distintos pueden repetir referencias
+func (uc *IngestionUseCase) findByIdentifiers(ctx context.Context, product *model.Product) (*model.Product, error) {
+	if len(product.Identifiers) == 0 {
+		return nil, nil
+	}
+
+	matches, err := uc.identifierRepo.FindMatching(ctx, product.Identifiers)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar producto existente por identificador: %w", err)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Type == model.IdentifierGTIN && matches[j].Type != model.IdentifierGTIN
+	})
+
+	for _, match := range matches {
+		existing, err := uc.productRepo.FindByID(ctx, match.ProductID)
+		if err != nil {
+			log.Printf("Error al obtener el producto %d del identificador %s %s: %v", match.ProductID, match.Type, match.Value, err)
+			continue
+		}
+		if match.Type == model.IdentifierMPN && existing.CategoryID != product.CategoryID {
+			continue
+		}
+
+		log.Printf("✅ Producto existente encontrado por %s %s: '%s'", strings.ToUpper(match.Type), match.Value, existing.Name)
+		return existing, nil
+	}
+	return nil, nil
+}
+
+// checkRefreshedOffer comprueba que el producto vuelto a scrapear es el mismo que el guardado. Si
+// ambos tienen GTIN o referencia del fabricante, basta con que coincida uno; si no hay identificadores
+// que comparar, la URL de la oferta scrapeada debe ser la de la oferta guardada de esa tienda
+func (uc *IngestionUseCase) checkRefreshedOffer(ctx context.Context, productID uint, scraped *model.Product) error {
+	stored, err := uc.identifierRepo.FindByProductID(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("error al obtener los identificadores del producto %d: %w", productID, err)
+	}
+
+	switch identifiersMatch(stored, scraped.Identifiers) {
+	case identifierMatch:
+		return nil
+	case identifierConflict:
+		return fmt.Errorf("%w: los identificadores de '%s' no coinciden con los del producto %d", errOfferMismatch, scraped.Name, productID)
+	}
+
+	offer := scraped.Prices[0]
+	prices, err := uc.priceRepo.FindByProductID(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("error al buscar precios existentes para producto %d: %w", productID, err)
+	}
+	for _, current := range prices {
+		if current.Store == offer.Store && !sameOfferURL(current.URL, offer.URL) {
+			return fmt.Errorf("%w: %s ya no es la oferta %s del producto %d", errOfferMismatch, offer.URL, current.URL, productID)
+		}
+	}
+	return nil
+}
+
+// identifierComparison es el resultado de comparar los identificadores de dos productos
+type identifierComparison int
+
+const (
+	identifierUnknown  identifierComparison = iota // No comparten ningún tipo de identificador
+	identifierMatch                                // Coinciden en al menos un identificador
+	identifierConflict                             // Tienen identificadores del mismo tipo y ninguno coincide
+)
+
+// identifiersMatch compara los identificadores guardados de un producto con los scrapeados
+func identifiersMatch(stored []*model.ProductIdentifier, scraped []model.ProductIdentifier) identifierComparison {
+	storedValues := make(map[string]map[string]bool)
+	for _, identifier := range stored {
+		if storedValues[identifier.Type] == nil {
+			storedValues[identifier.Type] = make(map[string]bool)
+		}
+		storedValues[identifier.Type][identifier.Value] = true
+	}
+
+	result := identifierUnknown
+	for _, identifier := range scraped {
+		values, ok := storedValues[identifier.Type]
+		if !ok {
+			continue
+		}
+		if values[identifier.Value] {
+			return identifierMatch
+		}
+		result = identifierConflict
+	}
+	return result
+}
+
+// sameOfferURL indica si dos URLs apuntan a la misma oferta, sin tener en cuenta el esquema, el
+// prefijo www, la barra final ni el fragmento
+func sameOfferURL(a, b string) bool {
+	return offerURLKey(a) == offerURLKey(b)
+}
+
+func offerURLKey(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	key := host + strings.TrimSuffix(u.EscapedPath(), "/")
+	if u.RawQuery != "" {
+		key += "?" + u.RawQuery
+	}
+	return key
+}
+
+// hasConflictingGTIN indica si el producto scrapeado y el candidato tienen GTIN y ninguno
+// coincide, es decir, si son variantes distintas aunque se parezcan en nombre o imagen
+func (uc *IngestionUseCase) hasConflictingGTIN(ctx context.Context, candidate, product *model.Product) bool {
+	scraped := make(map[string]bool)
+	for _, identifier := range product.Identifiers {
+		if identifier.Type == model.IdentifierGTIN {
+			scraped[identifier.Value] = true
+		}
+	}
+	if len(scraped) == 0 {
+		return false
+	}
+
+	stored, err := uc.identifierRepo.FindByProductID(ctx, candidate.ID)
+	if err != nil {
+		log.Printf("Error al obtener los identificadores del producto %d: %v", candidate.ID, err)
+		return false
+	}
+
+	hasGTIN := false
+	for _, identifier := range stored {
+		if identifier.Type != model.IdentifierGTIN {
+			continue
+		}
+		if scraped[identifier.Value] {
+			return false
+		}
+		hasGTIN = true
+	}
+	return hasGTIN
+}
+
+// computeImageHash descarga la imagen del producto y calcula su hash de percepción, que también
+// se asigna al producto. Devuelve nil si no tiene imagen o no se puede procesar
+func (uc *IngestionUseCase) computeImageHash(product *model.Product) *goimagehash.ImageHash {
+	if product.ImageURL == "" || utils.IsPlaceholderImage(product.ImageURL) {
+		return nil
+	}
+
+	img, err := utils.DownloadImage(product.ImageURL)
+	if err != nil {
+		log.Printf("Error al descargar o decodificar imagen para '%s': %v", product.Name, err)
+		return nil
+	}
+
+	hash, err := utils.CalculatePerceptionHash(img)
+	if err != nil {
+		log.Printf("Error al calcular pHash para '%s': %v", product.Name, err)
+		return nil
+	}
+
+	value := hash.GetHash()
+	product.ImageHash = &value
+	return hash
+}
+
+// createProduct da de alta un producto nuevo con un slug único. El precio se guarda aparte
+func (uc *IngestionUseCase) createProduct(ctx context.Context, product *model.Product) error {
+	log.Printf("✨ Creando nuevo producto: '%s'", product.Name)
+
+	product.Slug = utils.GenerateUniqueSlug(product.Name, func(slug string) bool {
+		exists, err := uc.productRepo.ExistsBySlug(ctx, slug)
+		if err != nil {
+			log.Printf("Error verificando existencia de slug '%s': %v", slug, err)
+			// Ante un error asumimos que existe para no duplicar slugs
+			return true
+		}
+		return exists
+	})
+
+	// Los precios y los identificadores no se crean con el producto para que se gestionen igual
+	// que en una actualización
+	prices, identifiers := product.Prices, product.Identifiers
+	product.Prices, product.Identifiers = nil, nil
+	err := uc.productRepo.Create(ctx, product)
+	product.Prices, product.Identifiers = prices, identifiers
+	if err != nil {
+		return fmt.Errorf("error al crear producto '%s': %w", product.Name, err)
+	}
+
+	log.Printf("Nuevo producto '%s' creado con ID: %d", product.Name, product.ID)
+	return nil
+}
+
+// updateProduct completa el producto existente con los datos nuevos que le falten y deja
+// en product el ID, slug y categoría del existente
+func (uc *IngestionUseCase) updateProduct(ctx context.Context, existing, product *model.Product) error {
+	log.Printf("Actualizando producto existente '%s' (ID: %d)", existing.Name, existing.ID)
+
+	// Priorizar una imagen real frente a una vacía o de relleno
+	if utils.IsPlaceholderImage(existing.ImageURL) && !utils.IsPlaceholderImage(product.ImageURL) {
+		existing.ImageURL = product.ImageURL
+	}
+	// Productos guardados antes de calcular el hash de imagen
+	if existing.ImageHash == nil && product.ImageHash != nil {
+		existing.ImageHash = product.ImageHash
+	}
+	if existing.Description == "" {
+		existing.Description = product.Description
+	}
+
+	if err := uc.productRepo.Update(ctx, existing); err != nil {
+		// El precio se guarda aunque falle la actualización del producto
+		log.Printf("Error al actualizar producto existente %d: %v", existing.ID, err)
+	}
+
+	product.ID = existing.ID
+	product.Slug = existing.Slug
+	product.CategoryID = existing.CategoryID
+	return nil
+}
+
+// recordPrice actualiza (o crea) la oferta vigente del producto en la tienda y añade la lectura al histórico
+func (uc *IngestionUseCase) recordPrice(ctx context.Context, productID uint, price model.Price) error {
+	price.ID = 0
+	price.ProductID = productID
+	if price.RetrievedAt.IsZero() {
+		price.RetrievedAt = time.Now()
+	}
+	// La base de datos guarda milisegundos y redondea el resto: se recorta antes para que la oferta y
+	// su lectura del histórico tengan exactamente el mismo instante
+	price.RetrievedAt = price.RetrievedAt.Truncate(time.Millisecond)
+	uc.currency.Normalize(&price)
+
+	existingPrices, err := uc.priceRepo.FindByProductID(ctx, productID)
+	if err != nil {
+		log.Printf("Error al buscar precios existentes para producto %d: %v", productID, err)
+	}
+
+	var current *model.Price
+	for _, ep := range existingPrices {
+		if ep.Store == price.Store {
+			current = ep
+			break
+		}
+	}
+
+	changed := current == nil || priceChanged(current, &price)
+
+	if current != nil {
+		current.Price = price.Price
+		current.Currency = price.Currency
+		current.NormalizedPrice = price.NormalizedPrice
+		current.ShippingCost = price.ShippingCost
+		current.ImportCharges = price.ImportCharges
+		current.TaxNote = price.TaxNote
+		current.SellerLocation = price.SellerLocation
+		current.NormalizedTotal = price.NormalizedTotal
+		current.Condition = price.Condition
+		current.ListingType = price.ListingType
+		current.SellerName = price.SellerName
+		current.SellerFeedback = price.SellerFeedback
+		current.URL = price.URL
+		current.IsAvailable = price.IsAvailable
+		current.RetrievedAt = price.RetrievedAt
+		if err := uc.priceRepo.Update(ctx, current); err != nil {
+			return fmt.Errorf("error al actualizar precio para producto %d tienda %s: %w", productID, price.Store, err)
+		}
+	} else if err := uc.priceRepo.Create(ctx, &price); err != nil {
+		return fmt.Errorf("error al crear precio para producto %d tienda %s: %w", productID, price.Store, err)
+	}
+
+	// El histórico nunca se sobrescribe; un fallo aquí no invalida el precio guardado
+	if err := uc.historyRepo.Create(ctx, model.NewPriceObservation(price)); err != nil {
+		log.Printf("Error al registrar histórico de precio para producto %d tienda %s: %v", productID, price.Store, err)
+	}
+
+	if changed {
+		for _, handler := range uc.priceChanged {
+			handler(ctx, productID)
+		}
+	}
+
+	return nil
+}
+
+// priceChanged indica si la oferta nueva cambia algo que afecte a la comparación de precios:
+// el precio, el coste total (normalizados), la disponibilidad, el estado o el tipo de anuncio, ya
+// que estos dos últimos deciden si la oferta pasa los filtros de las alertas
+func priceChanged(current, price *model.Price) bool {
+	return current.Price != price.Price ||
+		current.Currency != price.Currency ||
+		current.NormalizedPrice != price.NormalizedPrice ||
+		current.NormalizedTotal != price.NormalizedTotal ||
+		current.IsAvailable != price.IsAvailable ||
+		current.Condition != price.Condition ||
+		current.ListingType != price.ListingType
+}
@@ -0,0 +1,319 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"app/internal/domain/model"
+	"app/internal/domain/repositories"
+	"app/internal/infrastructure/email"
+	"app/internal/infrastructure/notifier"
+	"app/internal/infrastructure/realtime"
+	"app/pkg/config"
+)
+
+// ErrInvalidWebhook indica que la URL del webhook no es válida o no está permitida
+var ErrInvalidWebhook = errors.New("webhook no válido")
+
+// NotificationChannelUseCase reparte los avisos de los usuarios: los guarda siempre como
+// notificación de la aplicación, la publica en las pestañas abiertas del usuario y los envía por
+// los canales externos que cada usuario tiene activos. Los envíos externos los hace un número fijo
+// de workers que leen de una cola limitada
+type NotificationChannelUseCase struct {
+	notificationRepo     repositories.NotificationRepository
+	channelRepo          repositories.NotificationChannelRepository
+	broker               *realtime.Broker // Notificaciones en tiempo real (nil para no publicarlas)
+	channels             []notifier.Channel
+	allowPrivateWebhooks bool
+
+	deliveries   chan delivery
+	workers      sync.WaitGroup
+	drainTimeout time.Duration
+	closeMu      sync.RWMutex // Evita encolar envíos después de cerrar la cola
+	closed       bool
+	shutdown     context.Context // Se cancela si los envíos pendientes no terminan a tiempo al apagar
+	abort        context.CancelFunc
+}
+
+// delivery es un envío pendiente de un aviso por un canal externo
+type delivery struct {
+	ctx      context.Context // Contexto del aviso, sin su cancelación
+	channel  notifier.Channel
+	user     *model.User
+	settings *model.NotificationChannel
+	msg      *notifier.Message
+}
+
+// NewNotificationChannelUseCase crea una nueva instancia del caso de uso de canales de notificación
+// y arranca los workers de envío. Close los detiene
+func NewNotificationChannelUseCase(
+	notificationRepo repositories.NotificationRepository,
+	channelRepo repositories.NotificationChannelRepository,
+	broker *realtime.Broker,
+	cfg config.NotifyConfig,
+	channels ...notifier.Channel,
+) *NotificationChannelUseCase {
+	workers := cfg.DeliveryWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+	queueSize := cfg.DeliveryQueueSize
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	shutdown, abort := context.WithCancel(context.Background())
+	uc := &NotificationChannelUseCase{
+		notificationRepo:     notificationRepo,
+		channelRepo:          channelRepo,
+		broker:               broker,
+		channels:             channels,
+		allowPrivateWebhooks: cfg.WebhookAllowPrivateNetworks,
+		deliveries:           make(chan delivery, queueSize),
+		drainTimeout:         cfg.DeliveryDrainTimeout,
+		shutdown:             shutdown,
+		abort:                abort,
+	}
+	for i := 0; i < workers; i++ {
+		uc.workers.Add(1)
+		go uc.deliver()
+	}
+	return uc
+}
+
+// Close deja de aceptar envíos y espera a que se hagan los que están en la cola. Si no terminan en
+// notify.delivery_drain_timeout, cancela los que quedan. Se llama al apagar la aplicación
+func (uc *NotificationChannelUseCase) Close() {
+	uc.closeMu.Lock()
+	if uc.closed {
+		uc.closeMu.Unlock()
+		return
+	}
+	uc.closed = true
+	close(uc.deliveries)
+	uc.closeMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		uc.workers.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(uc.drainTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		log.Printf("[NOTIFICACIONES] Los envíos pendientes no han terminado en %s: se cancelan", uc.drainTimeout)
+		uc.abort()
+		<-done
+	}
+	uc.abort()
+}
+
+// Dispatch guarda el aviso como notificación de la aplicación, la publica en las pestañas abiertas
+// del usuario y lo envía por los canales externos activos del usuario. withEmail es false cuando la
+// alerta o búsqueda que origina el aviso no pide correo. Si el usuario recibe los correos en un
+// resumen, la notificación queda pendiente para el próximo resumen en lugar de enviarse por correo
+// (y sin correo si se ha dado de baja de los resúmenes). Los envíos externos los hacen los workers
+// de envío para no retrasar la ingesta con los reintentos del webhook
+func (uc *NotificationChannelUseCase) Dispatch(ctx context.Context, user *model.User, msg *notifier.Message, withEmail bool) {
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now()
+	}
+	digestMode := user.WantsDigest()
+	digest := withEmail && digestMode && user.AcceptsEmail(model.EmailCategoryDigest) &&
+		channelEnabled(user, model.ChannelEmail, nil)
+
+	notification := &model.Notification{
+		UserID:    user.ID,
+		ProductID: msg.ProductID,
+		AlertID:   msg.AlertID,
+		SearchID:  msg.SearchID,
+		Title:     msg.Title,
+		Message:   msg.Body,
+		IsRead:    false,
+		CreatedAt: msg.CreatedAt,
+
+		DigestPending: digest,
+	}
+	if err := uc.notificationRepo.Create(ctx, notification); err != nil {
+		log.Printf("[NOTIFICACIONES] Error al crear la notificación del usuario %d: %v", user.ID, err)
+		// Continuamos para intentar enviarla por los canales externos de todas formas
+	} else if uc.broker != nil {
+		// Solo se publican las notificaciones guardadas: las pestañas enlazan a ellas por su ID
+		uc.broker.Publish(notification)
+	}
+
+	prefs, err := uc.preferences(ctx, user.ID)
+	if err != nil {
+		log.Printf("[NOTIFICACIONES] Error al obtener los canales del usuario %d: %v", user.ID, err)
+		return
+	}
+
+	for _, channel := range uc.channels {
+		name := channel.Name()
+		if name == model.ChannelEmail && (!withEmail || digestMode) {
+			continue
+		}
+		settings := prefs[name]
+		if !channelEnabled(user, name, settings) {
+			continue
+		}
+
+		uc.enqueue(ctx, delivery{
+			ctx:      context.WithoutCancel(ctx),
+			channel:  channel,
+			user:     user,
+			settings: settings,
+			msg:      msg,
+		})
+	}
+}
+
+// enqueue deja el envío en la cola de los workers. Si la cola está llena espera a que haya hueco,
+// para frenar a quien avisa en lugar de acumular envíos sin límite
+func (uc *NotificationChannelUseCase) enqueue(ctx context.Context, d delivery) {
+	uc.closeMu.RLock()
+	defer uc.closeMu.RUnlock()
+	if uc.closed {
+		log.Printf("[NOTIFICACIONES] Aviso del usuario %d por %s descartado: la aplicación se está apagando", d.user.ID, d.channel.Name())
+		return
+	}
+
+	select {
+	case uc.deliveries <- d:
+	case <-ctx.Done():
+		log.Printf("[NOTIFICACIONES] Aviso del usuario %d por %s descartado: %v", d.user.ID, d.channel.Name(), ctx.Err())
+	}
+}
+
+// deliver es cada worker de envío: procesa la cola hasta que se cierra. Los correos que el usuario
+// no acepta (email.ErrOptedOut) se descartan sin anotarlos como error
+func (uc *NotificationChannelUseCase) deliver() {
+	defer uc.workers.Done()
+	for d := range uc.deliveries {
+		ctx, cancel := context.WithCancel(d.ctx)
+		stop := context.AfterFunc(uc.shutdown, cancel)
+		err := d.channel.Send(ctx, d.user, d.settings, d.msg)
+		stop()
+		cancel()
+
+		if errors.Is(err, email.ErrOptedOut) {
+			// El usuario se ha dado de baja de ese tipo de correo: no es un error y el Mailer ya lo anota
+			continue
+		}
+		if err != nil {
+			log.Printf("[NOTIFICACIONES] Error al enviar por %s el aviso del usuario %d: %v", d.channel.Name(), d.user.ID, err)
+			continue
+		}
+		log.Printf("[NOTIFICACIONES] Aviso %q enviado por %s al usuario %d", d.msg.Event, d.channel.Name(), d.user.ID)
+	}
+}
+
+// GetWebhook devuelve el webhook del usuario, o uno vacío y desactivado si no lo ha configurado
+func (uc *NotificationChannelUseCase) GetWebhook(ctx context.Context, userID uint) (*model.NotificationChannel, error) {
+	pref, err := uc.channelRepo.FindByUserAndChannel(ctx, userID, model.ChannelWebhook)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el webhook: %w", err)
+	}
+	if pref == nil {
+		pref = &model.NotificationChannel{UserID: userID, Channel: model.ChannelWebhook}
+	}
+	return pref, nil
+}
+
+// SaveWebhook guarda la URL del webhook del usuario y si está activo. La clave de firma se genera
+// la primera vez y cuando el usuario pide regenerarla
+func (uc *NotificationChannelUseCase) SaveWebhook(ctx context.Context, userID uint, webhookURL string, enabled, regenerateSecret bool) (*model.NotificationChannel, error) {
+	webhookURL = strings.TrimSpace(webhookURL)
+	if webhookURL == "" && enabled {
+		return nil, fmt.Errorf("%w: indica la URL del webhook", ErrInvalidWebhook)
+	}
+	if webhookURL != "" {
+		if err := notifier.ValidateWebhookURL(webhookURL, uc.allowPrivateWebhooks); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidWebhook, err)
+		}
+	}
+
+	pref, err := uc.channelRepo.FindByUserAndChannel(ctx, userID, model.ChannelWebhook)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el webhook: %w", err)
+	}
+	if pref == nil {
+		pref = &model.NotificationChannel{UserID: userID, Channel: model.ChannelWebhook}
+	}
+	pref.WebhookURL = webhookURL
+	pref.Enabled = enabled
+
+	if pref.WebhookSecret == "" || regenerateSecret {
+		secret, err := notifier.GenerateSecret()
+		if err != nil {
+			return nil, err
+		}
+		pref.WebhookSecret = secret
+	}
+
+	if err := uc.channelRepo.Save(ctx, pref); err != nil {
+		return nil, fmt.Errorf("error al guardar el webhook: %w", err)
+	}
+	return pref, nil
+}
+
+// SendTestWebhook envía al webhook del usuario un aviso de prueba y espera el resultado, para que
+// el usuario pueda comprobar la URL y la verificación de la firma
+func (uc *NotificationChannelUseCase) SendTestWebhook(ctx context.Context, user *model.User) error {
+	pref, err := uc.channelRepo.FindByUserAndChannel(ctx, user.ID, model.ChannelWebhook)
+	if err != nil {
+		return fmt.Errorf("error al obtener el webhook: %w", err)
+	}
+	if pref == nil || pref.WebhookURL == "" {
+		return fmt.Errorf("%w: no tienes ningún webhook configurado", ErrInvalidWebhook)
+	}
+
+	for _, channel := range uc.channels {
+		if channel.Name() != model.ChannelWebhook {
+			continue
+		}
+		return channel.Send(ctx, user, pref, &notifier.Message{
+			Event:     notifier.EventTest,
+			Title:     "Aviso de prueba",
+			Body:      "Si recibes este aviso, tu webhook está bien configurado.",
+			CreatedAt: time.Now(),
+		})
+	}
+	return fmt.Errorf("el canal de webhook no está disponible")
+}
+
+// preferences devuelve las preferencias guardadas del usuario por nombre de canal
+func (uc *NotificationChannelUseCase) preferences(ctx context.Context, userID uint) (map[string]*model.NotificationChannel, error) {
+	saved, err := uc.channelRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	prefs := make(map[string]*model.NotificationChannel, len(saved))
+	for _, pref := range saved {
+		prefs[pref.Channel] = pref
+	}
+	return prefs, nil
+}
+
+// channelEnabled indica si un canal está activo con la preferencia del usuario (nil si no la tiene).
+// El correo se activa o desactiva con la preferencia de correos de alertas (model.EmailCategoryAlerts)
+func channelEnabled(user *model.User, channel string, settings *model.NotificationChannel) bool {
+	if channel == model.ChannelEmail {
+		return user.AcceptsEmail(model.EmailCategoryAlerts) && user.Email != ""
+	}
+	if settings == nil {
+		return model.ChannelEnabledByDefault(channel)
+	}
+	if channel == model.ChannelWebhook && settings.WebhookURL == "" {
+		return false
+	}
+	return settings.Enabled
+}
@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"app/internal/domain/model"
+	"app/internal/infrastructure/email"
+	"app/internal/infrastructure/notifier"
+	"app/pkg/config"
+)
+
+// slowChannel simula un canal externo lento y cuenta los envíos y los que seguían en curso a la vez
+type slowChannel struct {
+	delay time.Duration
+
+	mu       sync.Mutex
+	sent     int
+	inFlight int
+	maxSeen  int
+}
+
+func (c *slowChannel) Name() string { return model.ChannelWebhook }
+
+func (c *slowChannel) Send(ctx context.Context, _ *model.User, _ *model.NotificationChannel, _ *notifier.Message) error {
+	c.mu.Lock()
+	c.inFlight++
+	c.maxSeen = max(c.maxSeen, c.inFlight)
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.inFlight--
+		c.mu.Unlock()
+	}()
+
+	select {
+	case <-time.After(c.delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	c.mu.Lock()
+	c.sent++
+	c.mu.Unlock()
+	return nil
+}
+
+// failingChannel devuelve siempre el mismo error
+type failingChannel struct {
+	err error
+}
+
+func (c failingChannel) Name() string { return model.ChannelEmail }
+
+func (c failingChannel) Send(context.Context, *model.User, *model.NotificationChannel, *notifier.Message) error {
+	return c.err
+}
+
+func TestDeliverSkipsOptedOutEmails(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantError bool
+	}{
+		{"usuario dado de baja", email.ErrOptedOut, false},
+		{"fallo del envío", errors.New("smtp caído"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+
+			uc := NewNotificationChannelUseCase(nil, nil, nil, config.NotifyConfig{
+				DeliveryWorkers:      1,
+				DeliveryQueueSize:    1,
+				DeliveryDrainTimeout: 5 * time.Second,
+			})
+			uc.enqueue(context.Background(), delivery{
+				ctx:     context.Background(),
+				channel: failingChannel{err: tt.err},
+				user:    &model.User{ID: 1},
+				msg:     &notifier.Message{Event: notifier.EventPriceAlert},
+			})
+			uc.Close()
+
+			if got := strings.Contains(logs.String(), "Error al enviar"); got != tt.wantError {
+				t.Errorf("error anotado = %t, se esperaba %t. Log:\n%s", got, tt.wantError, logs.String())
+			}
+			if strings.Contains(logs.String(), "enviado por") {
+				t.Errorf("el aviso se anotó como enviado. Log:\n%s", logs.String())
+			}
+		})
+	}
+}
+
+func TestDeliveriesAreBoundedAndDrainedOnClose(t *testing.T) {
+	channel := &slowChannel{delay: 10 * time.Millisecond}
+	uc := NewNotificationChannelUseCase(nil, nil, nil, config.NotifyConfig{
+		DeliveryWorkers:      2,
+		DeliveryQueueSize:    4,
+		DeliveryDrainTimeout: 5 * time.Second,
+	})
+
+	const deliveries = 12
+	for i := 0; i < deliveries; i++ {
+		uc.enqueue(context.Background(), delivery{
+			ctx:     context.Background(),
+			channel: channel,
+			user:    &model.User{ID: 1},
+			msg:     &notifier.Message{Event: "test"},
+		})
+	}
+	uc.Close()
+
+	if channel.sent != deliveries {
+		t.Errorf("se enviaron %d avisos antes de terminar Close, se esperaban %d", channel.sent, deliveries)
+	}
+	if channel.maxSeen > 2 {
+		t.Errorf("hubo %d envíos simultáneos, se esperaban 2 como mucho", channel.maxSeen)
+	}
+
+	// Después de cerrar, los avisos se descartan en lugar de bloquear o provocar un pánico
+	uc.enqueue(context.Background(), delivery{ctx: context.Background(), channel: channel, user: &model.User{ID: 1}, msg: &notifier.Message{}})
+	if channel.sent != deliveries {
+		t.Errorf("se envió un aviso después de Close")
+	}
+}
+
+func TestCloseCancelsDeliveriesAfterDrainTimeout(t *testing.T) {
+	channel := &slowChannel{delay: time.Minute}
+	uc := NewNotificationChannelUseCase(nil, nil, nil, config.NotifyConfig{
+		DeliveryWorkers:      1,
+		DeliveryQueueSize:    1,
+		DeliveryDrainTimeout: 20 * time.Millisecond,
+	})
+	uc.enqueue(context.Background(), delivery{ctx: context.Background(), channel: channel, user: &model.User{ID: 1}, msg: &notifier.Message{}})
+
+	start := time.Now()
+	uc.Close()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Close tardó %s con un envío bloqueado", elapsed)
+	}
+	if channel.sent != 0 {
+		t.Errorf("el envío bloqueado no se canceló")
+	}
+}
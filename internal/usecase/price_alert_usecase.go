@@ -2,46 +2,75 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"app/internal/domain/model"
 	"app/internal/domain/repositories"
-	"app/internal/infrastructure/email"
+	"app/internal/infrastructure/notifier"
 )
 
+// ErrInvalidAlertRule indica que la regla de la alerta no tiene los datos que necesita
+var ErrInvalidAlertRule = errors.New("regla de alerta no válida")
+
 // PriceAlertUseCase gestiona las alertas de precio
 type PriceAlertUseCase struct {
 	priceAlertRepo   repositories.PriceAlertRepository
+	alertTriggerRepo repositories.AlertTriggerRepository
 	notificationRepo repositories.NotificationRepository
 	productRepo      repositories.ProductRepository
 	priceRepo        repositories.PriceRepository
+	historyRepo      repositories.PriceHistoryRepository
 	userRepo         repositories.UserRepository
-	mailer           *email.Mailer
+	notifications    *NotificationChannelUseCase
+	currency         *CurrencyUseCase // Pasa los importes de los avisos a la moneda de cada usuario
+
+	// Serializa la decisión de disparar una alerta, porque la misma alerta puede evaluarse a la vez
+	// desde varias tiendas (cambios de precio) y desde la verificación completa, y las ediciones del
+	// usuario, que leen y guardan la alerta completa
+	triggerMu sync.Mutex
 }
 
 // NewPriceAlertUseCase crea una nueva instancia del caso de uso de alertas de precio
 func NewPriceAlertUseCase(
 	priceAlertRepo repositories.PriceAlertRepository,
+	alertTriggerRepo repositories.AlertTriggerRepository,
 	notificationRepo repositories.NotificationRepository,
 	productRepo repositories.ProductRepository,
 	priceRepo repositories.PriceRepository,
+	historyRepo repositories.PriceHistoryRepository,
 	userRepo repositories.UserRepository,
-	mailer *email.Mailer,
+	notifications *NotificationChannelUseCase,
+	currency *CurrencyUseCase,
 ) *PriceAlertUseCase {
 	return &PriceAlertUseCase{
 		priceAlertRepo:   priceAlertRepo,
+		alertTriggerRepo: alertTriggerRepo,
 		notificationRepo: notificationRepo,
 		productRepo:      productRepo,
 		priceRepo:        priceRepo,
+		historyRepo:      historyRepo,
 		userRepo:         userRepo,
-		mailer:           mailer,
+		notifications:    notifications,
+		currency:         currency,
 	}
 }
 
-// CreateAlert crea una nueva alerta de precio
-func (uc *PriceAlertUseCase) CreateAlert(ctx context.Context, userID, productID uint, targetPrice float64, notifyByEmail bool) (*model.PriceAlert, error) {
+// CreateAlert crea una nueva alerta de precio. rule es la condición que la dispara (targetPrice
+// solo lo usan las reglas de precio objetivo), filter indica qué ofertas cuentan para la alerta y
+// policy cuándo se vuelve a notificar mientras la regla se siga cumpliendo
+func (uc *PriceAlertUseCase) CreateAlert(ctx context.Context, userID, productID uint, targetPrice float64, notifyByEmail bool, filter model.OfferFilter, policy model.NotifyPolicy, rule model.AlertRule) (*model.PriceAlert, error) {
+	rule = rule.Normalize()
+	if err := rule.Validate(targetPrice); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAlertRule, err)
+	}
+	if !rule.UsesTargetPrice() {
+		targetPrice = 0
+	}
+
 	// Verificar que el usuario existe
 	user, err := uc.userRepo.FindByID(ctx, userID)
 	if err != nil {
@@ -54,37 +83,65 @@ func (uc *PriceAlertUseCase) CreateAlert(ctx context.Context, userID, productID
 		return nil, fmt.Errorf("producto no encontrado: %w", err)
 	}
 
-	// Crear la alerta
+	// Crear la alerta, con el estado inicial de la regla (precio de referencia y disponibilidad)
 	alert := &model.PriceAlert{
 		UserID:        userID,
 		ProductID:     productID,
 		TargetPrice:   targetPrice,
+		AlertRule:     rule,
 		NotifyByEmail: notifyByEmail,
 		IsActive:      true,
+		OfferFilter:   filter,
+		NotifyPolicy:  policy.Normalize(),
 	}
+	uc.initRuleState(ctx, alert)
 
 	// Guardar la alerta en la base de datos
 	if err := uc.priceAlertRepo.Create(ctx, alert); err != nil {
 		return nil, fmt.Errorf("error al crear alerta: %w", err)
 	}
 
-	// Verificar inmediatamente si el precio actual ya cumple con la alerta
-	bestPrice, err := uc.priceRepo.FindBestPriceByProductID(ctx, productID)
+	// Verificar inmediatamente si los precios actuales ya cumplen la regla
+	uc.checkAlert(ctx, alert, product, user)
+
+	return alert, nil
+}
+
+// UpdateAlert actualiza una alerta existente. Si notifyByEmail, filter, policy o rule son nil se
+// mantienen los actuales. Si cambia el objetivo, la regla, el filtro o la política, o se reactiva la alerta, se
+// olvida el último disparo y se toma de nuevo el precio de referencia
+func (uc *PriceAlertUseCase) UpdateAlert(ctx context.Context, alertID, userID uint, targetPrice float64, notifyByEmail *bool, isActive bool, filter *model.OfferFilter, policy *model.NotifyPolicy, rule *model.AlertRule) (*model.PriceAlert, error) {
+	alert, err := uc.saveAlertChanges(ctx, alertID, userID, targetPrice, notifyByEmail, isActive, filter, policy, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verificar inmediatamente si los precios actuales ya cumplen la regla
+	// Obtener el producto para la notificación
+	product, err := uc.productRepo.FindByID(ctx, alert.ProductID)
 	if err != nil {
-		log.Printf("Error al obtener mejor precio para verificación inicial: %v", err)
+		log.Printf("Error al obtener producto para verificación inmediata: %v", err)
 		return alert, nil
 	}
 
-	// Si ya existe un precio menor o igual al objetivo, notificar inmediatamente
-	if bestPrice != nil && bestPrice.Price <= targetPrice {
-		uc.createNotification(ctx, alert, product, user, bestPrice)
+	// Obtener el usuario para la notificación
+	user, err := uc.userRepo.FindByID(ctx, alert.UserID)
+	if err != nil {
+		log.Printf("Error al obtener usuario para verificación inmediata: %v", err)
+		return alert, nil
 	}
 
+	uc.checkAlert(ctx, alert, product, user)
+
 	return alert, nil
 }
 
-// UpdateAlert actualiza una alerta existente
-func (uc *PriceAlertUseCase) UpdateAlert(ctx context.Context, alertID, userID uint, targetPrice float64, notifyByEmail bool, isActive bool) (*model.PriceAlert, error) {
+// saveAlertChanges aplica y guarda los cambios de UpdateAlert. Lee y guarda la alerta bajo el mismo
+// mutex que los disparos, para que una edición y un disparo simultáneos no se pisen
+func (uc *PriceAlertUseCase) saveAlertChanges(ctx context.Context, alertID, userID uint, targetPrice float64, notifyByEmail *bool, isActive bool, filter *model.OfferFilter, policy *model.NotifyPolicy, rule *model.AlertRule) (*model.PriceAlert, error) {
+	uc.triggerMu.Lock()
+	defer uc.triggerMu.Unlock()
+
 	// Buscar la alerta
 	alert, err := uc.priceAlertRepo.FindByID(ctx, alertID)
 	if err != nil {
@@ -96,44 +153,45 @@ func (uc *PriceAlertUseCase) UpdateAlert(ctx context.Context, alertID, userID ui
 		return nil, fmt.Errorf("no tienes permiso para modificar esta alerta")
 	}
 
+	newRule := alert.AlertRule
+	if rule != nil {
+		newRule = rule.Normalize()
+	}
+	if err := newRule.Validate(targetPrice); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAlertRule, err)
+	}
+	if !newRule.UsesTargetPrice() {
+		targetPrice = 0
+	}
+
 	// Actualizar los campos
+	rearm := alert.TargetPrice != targetPrice || (!alert.IsActive && isActive) || !alert.AlertRule.SameRule(newRule)
 	alert.TargetPrice = targetPrice
-	alert.NotifyByEmail = notifyByEmail
+	if notifyByEmail != nil {
+		alert.NotifyByEmail = *notifyByEmail
+	}
 	alert.IsActive = isActive
-
-	// Guardar los cambios
-	if err := uc.priceAlertRepo.Update(ctx, alert); err != nil {
-		return nil, fmt.Errorf("error al actualizar alerta: %w", err)
+	if rule != nil {
+		alert.RuleType, alert.RuleValue, alert.RuleStore = newRule.RuleType, newRule.RuleValue, newRule.RuleStore
 	}
-
-	// Verificar inmediatamente si el precio actual ya cumple con la alerta
-	// Obtener el producto para la notificación
-	product, err := uc.productRepo.FindByID(ctx, alert.ProductID)
-	if err != nil {
-		log.Printf("Error al obtener producto para verificación inmediata: %v", err)
-		return alert, nil
+	if filter != nil {
+		rearm = rearm || alert.OfferFilter != *filter
+		alert.OfferFilter = *filter
 	}
-
-	// Obtener el usuario para la notificación
-	user, err := uc.userRepo.FindByID(ctx, alert.UserID)
-	if err != nil {
-		log.Printf("Error al obtener usuario para verificación inmediata: %v", err)
-		return alert, nil
+	if policy != nil {
+		normalized := policy.Normalize()
+		rearm = rearm || alert.NotifyPolicy != normalized
+		alert.NotifyPolicy = normalized
 	}
-
-	// Verificar el precio actual
-	bestPrice, err := uc.priceRepo.FindBestPriceByProductID(ctx, alert.ProductID)
-	if err != nil {
-		log.Printf("Error al obtener mejor precio para verificación inmediata: %v", err)
-		return alert, nil
+	if rearm {
+		alert.ResetTrigger()
+		uc.initRuleState(ctx, alert)
 	}
 
-	// Si ya existe un precio menor o igual al objetivo, notificar inmediatamente
-	if bestPrice != nil && bestPrice.Price <= targetPrice {
-		log.Printf("El precio actual (%.2f) ya está por debajo del objetivo (%.2f), notificando", bestPrice.Price, targetPrice)
-		uc.createNotification(ctx, alert, product, user, bestPrice)
+	// Guardar los cambios
+	if err := uc.priceAlertRepo.Update(ctx, alert); err != nil {
+		return nil, fmt.Errorf("error al actualizar alerta: %w", err)
 	}
-
 	return alert, nil
 }
 
@@ -158,67 +216,105 @@ func (uc *PriceAlertUseCase) DeleteAlert(ctx context.Context, alertID, userID ui
 	return nil
 }
 
+// GetAlertTriggers devuelve los últimos disparos de una alerta (cuándo y con qué precio se notificó)
+func (uc *PriceAlertUseCase) GetAlertTriggers(ctx context.Context, alertID uint, limit int) ([]*model.AlertTrigger, error) {
+	return uc.alertTriggerRepo.FindByAlertID(ctx, alertID, limit)
+}
+
 // GetUserAlerts obtiene todas las alertas de un usuario
 func (uc *PriceAlertUseCase) GetUserAlerts(ctx context.Context, userID uint) ([]*model.PriceAlert, error) {
 	return uc.priceAlertRepo.FindByUserID(ctx, userID)
 }
 
-// CheckPriceAlerts verifica todas las alertas activas contra los precios actuales
-// Este método es el que debería ser llamado por un scheduler periódicamente
+// alertSweepPageSize es el número de productos con alertas activas que se procesan por página en
+// la verificación completa
+const alertSweepPageSize = 200
+
+// CheckPriceAlerts recorre, página a página, todos los productos con alertas activas y evalúa sus
+// alertas contra los precios actuales. Las alertas se evalúan también al registrar cada cambio de
+// precio (HandlePriceChange); esta verificación completa, llamada por el scheduler, cubre lo que
+// no pasa por la ingesta, como las alertas editadas o los cambios de tipo de cambio
 func (uc *PriceAlertUseCase) CheckPriceAlerts(ctx context.Context) error {
-	// Este proceso puede ser pesado, así que usamos una hora límite
 	startTime := time.Now()
 	log.Printf("Iniciando verificación de alertas de precio a las %s", startTime.Format("15:04:05"))
 
-	// Obtener todos los productos que tienen al menos un precio
-	// En un sistema real, podríamos querer hacer esto más eficientemente filtrando
-	// solo a productos con cambios de precio recientes
+	var afterID uint
+	checked := 0
+	for {
+		productIDs, err := uc.priceAlertRepo.FindActiveProductIDs(ctx, afterID, alertSweepPageSize)
+		if err != nil {
+			return fmt.Errorf("error al obtener productos con alertas activas: %w", err)
+		}
+
+		for _, productID := range productIDs {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := uc.CheckProductAlerts(ctx, productID); err != nil {
+				log.Printf("Error al verificar alertas del producto %d: %v", productID, err)
+			}
+		}
+		checked += len(productIDs)
+
+		if len(productIDs) < alertSweepPageSize {
+			break
+		}
+		afterID = productIDs[len(productIDs)-1]
+	}
+
+	log.Printf("Verificación de alertas de precio completada en %v (%d productos)", time.Since(startTime), checked)
+	return nil
+}
 
-	// Para cada producto, buscar el mejor precio actual
-	// Para cada precio que ha cambiado, buscar alertas que se activen con este precio
-	// Esto se puede optimizar de muchas maneras según el volumen de datos
+// HandlePriceChange evalúa las alertas de un producto cuya oferta acaba de cambiar. Se registra en
+// IngestionUseCase.OnPriceChange para que las alertas se comprueben en cuanto se guarda el precio
+func (uc *PriceAlertUseCase) HandlePriceChange(ctx context.Context, productID uint) {
+	if err := uc.CheckProductAlerts(ctx, productID); err != nil {
+		log.Printf("Error al verificar alertas tras el cambio de precio del producto %d: %v", productID, err)
+	}
+}
 
-	// Ejecutamos la búsqueda de alertas activadas para cada producto con su mejor precio
-	// En un sistema real, esta lógica podría implementarse con una consulta SQL más eficiente
+// CheckProductAlerts verifica todas las alertas activas de un producto contra su mejor precio actual
+func (uc *PriceAlertUseCase) CheckProductAlerts(ctx context.Context, productID uint) error {
+	product, err := uc.productRepo.FindByID(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("producto %d no encontrado: %w", productID, err)
+	}
+	uc.checkProductAlerts(ctx, product)
+	return nil
+}
 
-	// Vamos a buscar los precios más bajos primero
-	// Esto es una simplificación, en producción podríamos necesitar paginar y procesar por lotes
-	bestDeals, err := uc.productRepo.FindBestDeals(ctx, 100) // Limitamos a 100 productos
+// checkProductAlerts evalúa la regla de cada alerta activa del producto y notifica las que se cumplen
+func (uc *PriceAlertUseCase) checkProductAlerts(ctx context.Context, product *model.Product) {
+	alerts, err := uc.priceAlertRepo.FindByProductID(ctx, product.ID)
 	if err != nil {
-		return fmt.Errorf("error al obtener productos con mejores precios: %w", err)
+		log.Printf("Error al buscar alertas para producto %d: %v", product.ID, err)
+		return
 	}
 
-	for _, product := range bestDeals {
-		// Obtener el mejor precio actual para el producto
-		bestPrice, err := uc.priceRepo.FindBestPriceByProductID(ctx, product.ID)
-		if err != nil || bestPrice == nil {
-			log.Printf("No se pudo obtener el mejor precio para producto %d: %v", product.ID, err)
+	for _, alert := range alerts {
+		match := uc.evaluateRule(ctx, alert)
+		if match == nil {
 			continue
 		}
 
-		// Buscar alertas que se activen con este precio
-		alertsToNotify, err := uc.priceAlertRepo.FindActiveAlertsForPrice(ctx, product.ID, bestPrice.Price)
+		// Obtener el usuario para la notificación
+		user, err := uc.userRepo.FindByID(ctx, alert.UserID)
 		if err != nil {
-			log.Printf("Error al buscar alertas para producto %d: %v", product.ID, err)
+			log.Printf("Error al obtener usuario %d para notificación: %v", alert.UserID, err)
 			continue
 		}
 
-		// Si hay alertas, crear notificaciones
-		for _, alert := range alertsToNotify {
-			// Obtener el usuario para la notificación
-			user, err := uc.userRepo.FindByID(ctx, alert.UserID)
-			if err != nil {
-				log.Printf("Error al obtener usuario %d para notificación: %v", alert.UserID, err)
-				continue
-			}
-
-			// Crear notificación
-			uc.createNotification(ctx, alert, product, user, bestPrice)
-		}
+		// Crear notificación si el modo de la alerta lo permite
+		uc.notifyIfDue(ctx, alert, product, user, match)
 	}
+}
 
-	log.Printf("Verificación de alertas de precio completada en %v por favor espere un momento en breves se estara haciendo el scraping", time.Since(startTime))
-	return nil
+// checkAlert evalúa una sola alerta (al crearla o editarla) y la notifica si se cumple su regla
+func (uc *PriceAlertUseCase) checkAlert(ctx context.Context, alert *model.PriceAlert, product *model.Product, user *model.User) {
+	if match := uc.evaluateRule(ctx, alert); match != nil {
+		uc.notifyIfDue(ctx, alert, product, user, match)
+	}
 }
 
 // GetUserNotifications obtiene todas las notificaciones de un usuario
@@ -272,57 +368,113 @@ func (uc *PriceAlertUseCase) MarkAllNotificationsAsRead(ctx context.Context, use
 	return nil
 }
 
-// createNotification crea una notificación y envía correo si está configurado
-func (uc *PriceAlertUseCase) createNotification(ctx context.Context, alert *model.PriceAlert, product *model.Product, user *model.User, price *model.Price) {
-	// Crear texto para la notificación
-	title := fmt.Sprintf("¡Alerta de precio para %s!", product.Name)
-	message := fmt.Sprintf("El precio actual es %.2f€ en %s, por debajo de tu objetivo de %.2f€.",
-		price.Price, price.Store, alert.TargetPrice)
-
-	// Crear notificación en la base de datos
-	notification := &model.Notification{
-		UserID:    user.ID,
-		ProductID: product.ID,
-		AlertID:   &alert.ID,
-		Title:     title,
-		Message:   message,
-		IsRead:    false,
-		CreatedAt: time.Now(),
-	}
-
-	if err := uc.notificationRepo.Create(ctx, notification); err != nil {
-		log.Printf("Error al crear notificación: %v", err)
-		// Continuamos para intentar enviar el correo de todas formas
-	}
-
-	// Si está configurado el envío de correo, enviar
-	if alert.NotifyByEmail && user.Email != "" {
-		if err := uc.mailer.SendPriceAlertEmail(
-			user.Email,
-			user.Username,
-			product.Name,
-			product.ID,
-			alert.TargetPrice,
-			price.Price,
-			price.Store,
-			price.URL,
-		); err != nil {
-			log.Printf("Error al enviar correo de alerta de precio: %v", err)
-		} else {
-			log.Printf("Correo de alerta enviado con éxito a %s para producto %s",
-				user.Email, product.Name)
-		}
+// notifyIfDue notifica la alerta si su modo lo permite y registra el disparo. Evita repetir el mismo
+// aviso cada vez que se evalúa la alerta mientras el precio sigue por debajo del objetivo
+func (uc *PriceAlertUseCase) notifyIfDue(ctx context.Context, alert *model.PriceAlert, product *model.Product, user *model.User, match *ruleMatch) {
+	if !uc.claimTrigger(ctx, alert, match.price) {
+		return
+	}
+	uc.createNotification(ctx, alert, product, user, match)
+}
+
+// claimTrigger decide, con el estado guardado de la alerta, si debe dispararse con este precio. Si
+// es así guarda el disparo (último precio y fecha, desactivación en modo one_shot) y lo añade al
+// historial antes de notificar, para que otra evaluación simultánea no lo repita
+func (uc *PriceAlertUseCase) claimTrigger(ctx context.Context, alert *model.PriceAlert, price *model.Price) bool {
+	uc.triggerMu.Lock()
+	defer uc.triggerMu.Unlock()
+
+	current, err := uc.priceAlertRepo.FindByID(ctx, alert.ID)
+	if err != nil {
+		log.Printf("Error al obtener el estado de la alerta %d: %v", alert.ID, err)
+		return false
 	}
 
-	// Desactivar la alerta después de notificar (opcional)
-	// Si queremos que la alerta sea de un solo uso y se desactive
-	// después de notificar, descomentar las siguientes líneas:
-	/*
-		alert.IsActive = false
-		if err := uc.priceAlertRepo.Update(ctx, alert); err != nil {
-			log.Printf("Error al desactivar alerta: %v", err)
+	now := time.Now()
+	comparablePrice := alert.OfferFilter.PriceOf(price)
+	if !current.ShouldNotify(comparablePrice, now) {
+		if current.RuleType == model.AlertRuleBackInStock && !current.LastAvailable {
+			// La reposición no se avisa (periodo de espera o aviso único), pero ya se ha visto
+			if err := uc.priceAlertRepo.UpdateRuleState(ctx, current.ID, current.BaselinePrice, true); err != nil {
+				log.Printf("Error al guardar el estado de la alerta %d: %v", alert.ID, err)
+			}
+			alert.LastAvailable = true
 		}
-	*/
+		return false
+	}
+
+	current.RecordTrigger(comparablePrice, now)
+	if err := uc.priceAlertRepo.Update(ctx, current); err != nil {
+		log.Printf("Error al guardar el disparo de la alerta %d: %v", alert.ID, err)
+		return false
+	}
+	alert.IsActive = current.IsActive
+	alert.LastNotifiedPrice = current.LastNotifiedPrice
+	alert.LastNotifiedAt = current.LastNotifiedAt
+	alert.LastAvailable = current.LastAvailable
+
+	trigger := &model.AlertTrigger{
+		AlertID:     alert.ID,
+		ProductID:   alert.ProductID,
+		Price:       comparablePrice,
+		Store:       price.Store,
+		URL:         price.URL,
+		TriggeredAt: now,
+	}
+	if err := uc.alertTriggerRepo.Create(ctx, trigger); err != nil {
+		log.Printf("Error al registrar el historial de la alerta %d: %v", alert.ID, err)
+	}
+	return true
+}
+
+// rearmTrigger olvida el último disparo de una alerta en modo on_drop cuyo precio ha vuelto a
+// superar la regla, para que avise de nuevo en la siguiente bajada
+func (uc *PriceAlertUseCase) rearmTrigger(ctx context.Context, alert *model.PriceAlert) {
+	if alert.LastNotifiedAt == nil {
+		return
+	}
+
+	uc.triggerMu.Lock()
+	defer uc.triggerMu.Unlock()
+
+	current, err := uc.priceAlertRepo.FindByID(ctx, alert.ID)
+	if err != nil {
+		log.Printf("Error al obtener el estado de la alerta %d: %v", alert.ID, err)
+		return
+	}
+	if !current.Rearm() {
+		return
+	}
+	if err := uc.priceAlertRepo.Update(ctx, current); err != nil {
+		log.Printf("Error al rearmar la alerta %d: %v", alert.ID, err)
+		return
+	}
+	alert.LastNotifiedPrice = current.LastNotifiedPrice
+	alert.LastNotifiedAt = current.LastNotifiedAt
+}
+
+// createNotification crea la notificación de la alerta y la envía por los canales del usuario. El
+// correo solo se envía si la alerta lo pide
+func (uc *PriceAlertUseCase) createNotification(ctx context.Context, alert *model.PriceAlert, product *model.Product, user *model.User, match *ruleMatch) {
+	price := match.price
+	currency := uc.currency.UserCurrency(user)
+	amount, _ := uc.currency.FromComparison(alert.OfferFilter.PriceOf(price), currency)
+	reference, _ := uc.currency.FromComparison(match.reference, currency)
+
+	uc.notifications.Dispatch(ctx, user, &notifier.Message{
+		Event:          notifier.EventPriceAlert,
+		Title:          fmt.Sprintf("¡Alerta de precio para %s!", product.Name),
+		Body:           alertMessage(alert, match, uc.currency.Formatter(currency)),
+		ProductID:      product.ID,
+		ProductName:    product.Name,
+		Price:          amount,
+		ReferencePrice: reference,
+		Currency:       currency,
+		Store:          price.Store,
+		OfferURL:       price.URL,
+		AlertID:        &alert.ID,
+		CreatedAt:      time.Now(),
+	}, alert.NotifyByEmail)
 }
 
 // GetUnreadNotificationsCount obtiene el número de notificaciones no leídas para un usuario.
@@ -0,0 +1,276 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"app/internal/domain/model"
+	"app/internal/domain/repositories"
+)
+
+// fakePriceAlertRepo guarda las alertas en memoria. Los métodos que no usan los tests se quedan
+// sin implementar
+type fakePriceAlertRepo struct {
+	repositories.PriceAlertRepository
+
+	mu         sync.Mutex
+	alerts     map[uint]model.PriceAlert
+	productIDs []uint // Productos con alertas activas, ordenados
+	pages      []uint // afterID de cada página pedida en la verificación completa
+	afterFind  func() // Si no es nil, se llama (una vez) después de leer la alerta en FindByID
+}
+
+func (r *fakePriceAlertRepo) FindByID(_ context.Context, alertID uint) (*model.PriceAlert, error) {
+	r.mu.Lock()
+	alert, ok := r.alerts[alertID]
+	hook := r.afterFind
+	r.afterFind = nil
+	r.mu.Unlock()
+
+	if hook != nil {
+		hook()
+	}
+	if !ok {
+		return nil, errors.New("alerta no encontrada")
+	}
+	return &alert, nil
+}
+
+func (r *fakePriceAlertRepo) Update(_ context.Context, alert *model.PriceAlert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alerts[alert.ID] = *alert
+	return nil
+}
+
+func (r *fakePriceAlertRepo) UpdateRuleState(_ context.Context, alertID uint, baselinePrice float64, lastAvailable bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	alert := r.alerts[alertID]
+	alert.BaselinePrice = baselinePrice
+	alert.LastAvailable = lastAvailable
+	r.alerts[alertID] = alert
+	return nil
+}
+
+func (r *fakePriceAlertRepo) FindByProductID(context.Context, uint) ([]*model.PriceAlert, error) {
+	return nil, nil
+}
+
+func (r *fakePriceAlertRepo) FindActiveProductIDs(_ context.Context, afterID uint, limit int) ([]uint, error) {
+	r.pages = append(r.pages, afterID)
+	var page []uint
+	for _, id := range r.productIDs {
+		if id > afterID && len(page) < limit {
+			page = append(page, id)
+		}
+	}
+	return page, nil
+}
+
+// fakeAlertTriggerRepo guarda en memoria el historial de disparos
+type fakeAlertTriggerRepo struct {
+	repositories.AlertTriggerRepository
+
+	mu       sync.Mutex
+	triggers []*model.AlertTrigger
+}
+
+func (r *fakeAlertTriggerRepo) Create(_ context.Context, trigger *model.AlertTrigger) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.triggers = append(r.triggers, trigger)
+	return nil
+}
+
+// fakeProductRepo devuelve un producto vacío para cualquier ID y anota los que se han pedido
+type fakeProductRepo struct {
+	repositories.ProductRepository
+
+	visited []uint
+}
+
+func (r *fakeProductRepo) FindByID(_ context.Context, id uint) (*model.Product, error) {
+	r.visited = append(r.visited, id)
+	return &model.Product{ID: id}, nil
+}
+
+// fakeUserRepo no encuentra a ningún usuario
+type fakeUserRepo struct {
+	repositories.UserRepository
+}
+
+func (fakeUserRepo) FindByID(context.Context, uint) (*model.User, error) {
+	return nil, errors.New("usuario no encontrado")
+}
+
+func TestUpdateAlertDoesNotUndoConcurrentTrigger(t *testing.T) {
+	alert := model.PriceAlert{ID: 1, UserID: 3, ProductID: 7, TargetPrice: 100, IsActive: true, NotifyByEmail: true,
+		NotifyPolicy: model.NotifyPolicy{NotifyMode: model.NotifyModeOnDrop}}
+	alertRepo := &fakePriceAlertRepo{alerts: map[uint]model.PriceAlert{alert.ID: alert}}
+	uc := &PriceAlertUseCase{
+		priceAlertRepo:   alertRepo,
+		alertTriggerRepo: &fakeAlertTriggerRepo{},
+		productRepo:      &fakeProductRepo{},
+		userRepo:         fakeUserRepo{},
+	}
+
+	// El disparo llega mientras la edición ya ha leído la alerta y aún no la ha guardado
+	triggered := make(chan bool)
+	alertRepo.afterFind = func() {
+		go func() {
+			evaluated := alert
+			triggered <- uc.claimTrigger(context.Background(), &evaluated, &model.Price{Price: 90, Store: "coolmod"})
+		}()
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	noEmail := false
+	if _, err := uc.UpdateAlert(context.Background(), alert.ID, alert.UserID, alert.TargetPrice, &noEmail, true, nil, nil, nil); err != nil {
+		t.Fatalf("UpdateAlert() error = %v", err)
+	}
+	if !<-triggered {
+		t.Fatal("la alerta debería haberse disparado")
+	}
+
+	stored, _ := alertRepo.FindByID(context.Background(), alert.ID)
+	if stored.NotifyByEmail {
+		t.Error("se perdió la edición del usuario")
+	}
+	if stored.LastNotifiedAt == nil || stored.LastNotifiedPrice != 90 {
+		t.Error("la edición deshizo el disparo simultáneo")
+	}
+}
+
+func TestCheckPriceAlertsSweepsAllPages(t *testing.T) {
+	tests := []struct {
+		name     string
+		products int
+		pages    []uint // afterID de cada página
+	}{
+		{"sin alertas", 0, []uint{0}},
+		{"una página incompleta", 3, []uint{0}},
+		{"página exacta", alertSweepPageSize, []uint{0, alertSweepPageSize}},
+		{"varias páginas", 2*alertSweepPageSize + 50, []uint{0, alertSweepPageSize, 2 * alertSweepPageSize}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alertRepo := &fakePriceAlertRepo{}
+			for id := 1; id <= tt.products; id++ {
+				alertRepo.productIDs = append(alertRepo.productIDs, uint(id))
+			}
+			productRepo := &fakeProductRepo{}
+			uc := &PriceAlertUseCase{priceAlertRepo: alertRepo, productRepo: productRepo}
+
+			if err := uc.CheckPriceAlerts(context.Background()); err != nil {
+				t.Fatalf("CheckPriceAlerts() error = %v", err)
+			}
+
+			if len(productRepo.visited) != tt.products {
+				t.Fatalf("se verificaron %d productos, se esperaban %d", len(productRepo.visited), tt.products)
+			}
+			for i, id := range productRepo.visited {
+				if id != uint(i+1) {
+					t.Fatalf("el producto %d se verificó en la posición %d", id, i)
+				}
+			}
+			if len(alertRepo.pages) != len(tt.pages) {
+				t.Fatalf("páginas pedidas = %v, se esperaban %v", alertRepo.pages, tt.pages)
+			}
+			for i := range tt.pages {
+				if alertRepo.pages[i] != tt.pages[i] {
+					t.Fatalf("páginas pedidas = %v, se esperaban %v", alertRepo.pages, tt.pages)
+				}
+			}
+		})
+	}
+}
+
+func TestClaimTrigger(t *testing.T) {
+	// Cada paso intenta disparar la alerta con un precio o, si rise es true, simula que el precio ha
+	// vuelto a superar la regla
+	type step struct {
+		price float64
+		rise  bool
+		want  bool
+	}
+
+	tests := []struct {
+		name  string
+		mode  string
+		steps []step
+	}{
+		{"on_drop no repite el mismo precio", model.NotifyModeOnDrop, []step{{price: 90, want: true}, {price: 90, want: false}, {price: 85, want: true}, {price: 88, want: false}}},
+		{"on_drop se rearma tras una subida", model.NotifyModeOnDrop, []step{{price: 90, want: true}, {rise: true}, {price: 95, want: true}}},
+		{"one_shot solo avisa una vez", model.NotifyModeOneShot, []step{{price: 90, want: true}, {price: 80, want: false}, {rise: true}, {price: 70, want: false}}},
+		{"cooldown no se rearma", model.NotifyModeCooldown, []step{{price: 90, want: true}, {rise: true}, {price: 80, want: false}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := model.PriceAlert{ID: 1, ProductID: 7, IsActive: true, NotifyPolicy: model.NotifyPolicy{NotifyMode: tt.mode}}
+			alertRepo := &fakePriceAlertRepo{alerts: map[uint]model.PriceAlert{alert.ID: alert}}
+			triggerRepo := &fakeAlertTriggerRepo{}
+			uc := &PriceAlertUseCase{priceAlertRepo: alertRepo, alertTriggerRepo: triggerRepo}
+
+			claimed := 0
+			for i, s := range tt.steps {
+				if s.rise {
+					uc.rearmTrigger(context.Background(), &alert)
+					continue
+				}
+				price := &model.Price{Price: s.price, Store: "coolmod", URL: "https://www.coolmod.com/p"}
+				if got := uc.claimTrigger(context.Background(), &alert, price); got != s.want {
+					t.Fatalf("paso %d: claimTrigger(%.2f) = %t, se esperaba %t", i, s.price, got, s.want)
+				}
+				if s.want {
+					claimed++
+				}
+			}
+
+			if len(triggerRepo.triggers) != claimed {
+				t.Errorf("se registraron %d disparos, se esperaban %d", len(triggerRepo.triggers), claimed)
+			}
+		})
+	}
+}
+
+func TestClaimTriggerConcurrentEvaluations(t *testing.T) {
+	alert := model.PriceAlert{ID: 1, ProductID: 7, IsActive: true, NotifyPolicy: model.NotifyPolicy{NotifyMode: model.NotifyModeOnDrop}}
+	alertRepo := &fakePriceAlertRepo{alerts: map[uint]model.PriceAlert{alert.ID: alert}}
+	triggerRepo := &fakeAlertTriggerRepo{}
+	uc := &PriceAlertUseCase{priceAlertRepo: alertRepo, alertTriggerRepo: triggerRepo}
+
+	// La misma bajada llega a la vez desde varias tiendas y desde la verificación completa
+	const evaluations = 20
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		claimed int
+	)
+	for i := 0; i < evaluations; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			evaluated := alert
+			price := &model.Price{Price: 90, Store: "coolmod", URL: "https://www.coolmod.com/p"}
+			if uc.claimTrigger(context.Background(), &evaluated, price) {
+				mu.Lock()
+				claimed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if claimed != 1 {
+		t.Errorf("la alerta se disparó %d veces, se esperaba 1", claimed)
+	}
+	if len(triggerRepo.triggers) != 1 {
+		t.Errorf("se registraron %d disparos, se esperaba 1", len(triggerRepo.triggers))
+	}
+}
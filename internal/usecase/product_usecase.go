@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"time"
 
 	"app/internal/domain/model"
 	"app/internal/domain/repositories"
@@ -14,6 +15,7 @@ type ProductUseCase struct {
 	productRepo  repositories.ProductRepository
 	categoryRepo repositories.CategoryRepository
 	priceRepo    repositories.PriceRepository
+	historyRepo  repositories.PriceHistoryRepository
 }
 
 // NewProductUseCase crea una nueva instancia del caso de uso para productos
@@ -21,24 +23,26 @@ func NewProductUseCase(
 	productRepo repositories.ProductRepository,
 	categoryRepo repositories.CategoryRepository,
 	priceRepo repositories.PriceRepository,
+	historyRepo repositories.PriceHistoryRepository,
 ) *ProductUseCase {
 	return &ProductUseCase{
 		productRepo:  productRepo,
 		categoryRepo: categoryRepo,
 		priceRepo:    priceRepo,
+		historyRepo:  historyRepo,
 	}
 }
 
-// GetBestDeals obtiene los productos con las mejores ofertas
-func (uc *ProductUseCase) GetBestDeals(ctx context.Context, limit int) ([]*model.Product, error) {
-	products, err := uc.productRepo.FindBestDeals(ctx, limit)
+// GetBestDeals obtiene los productos con las mejores ofertas según el filtro
+func (uc *ProductUseCase) GetBestDeals(ctx context.Context, limit int, filter model.OfferFilter) ([]*model.Product, error) {
+	products, err := uc.productRepo.FindBestDeals(ctx, limit, filter)
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener las mejores ofertas: %w", err)
 	}
 
 	// Enriquecemos cada producto con su mejor precio
 	for _, product := range products {
-		bestPrice, err := uc.priceRepo.FindBestPriceByProductID(ctx, product.ID)
+		bestPrice, err := uc.priceRepo.FindBestPriceByProductID(ctx, product.ID, filter)
 		if err != nil {
 			return nil, fmt.Errorf("error al obtener el mejor precio del producto %d: %w", product.ID, err)
 		}
@@ -51,8 +55,8 @@ func (uc *ProductUseCase) GetBestDeals(ctx context.Context, limit int) ([]*model
 	return products, nil
 }
 
-// GetProductsByCategory obtiene productos por categoría
-func (uc *ProductUseCase) GetProductsByCategory(ctx context.Context, categorySlug string, limit, offset int, storeFilter string) ([]*model.Product, error) {
+// GetProductsByCategory obtiene productos por categoría con su mejor precio según el filtro
+func (uc *ProductUseCase) GetProductsByCategory(ctx context.Context, categorySlug string, limit, offset int, storeFilter string, filter model.OfferFilter) ([]*model.Product, error) {
 	category, err := uc.categoryRepo.FindBySlug(ctx, categorySlug)
 	if err != nil {
 		return nil, fmt.Errorf("error al buscar categoría %s: %w", categorySlug, err)
@@ -65,7 +69,7 @@ func (uc *ProductUseCase) GetProductsByCategory(ctx context.Context, categorySlu
 
 	// Enriquecemos cada producto con su mejor precio
 	for _, product := range products {
-		bestPrice, err := uc.priceRepo.FindBestPriceByProductID(ctx, product.ID)
+		bestPrice, err := uc.priceRepo.FindBestPriceByProductID(ctx, product.ID, filter)
 		if err != nil {
 			return nil, fmt.Errorf("error al obtener el mejor precio del producto %d: %w", product.ID, err)
 		}
@@ -78,15 +82,15 @@ func (uc *ProductUseCase) GetProductsByCategory(ctx context.Context, categorySlu
 	return products, nil
 }
 
-// GetProductDetail obtiene el detalle de un producto con sus mejores ofertas
-func (uc *ProductUseCase) GetProductDetail(ctx context.Context, productID uint) (*model.Product, error) {
+// GetProductDetail obtiene el detalle de un producto con sus mejores ofertas según el filtro
+func (uc *ProductUseCase) GetProductDetail(ctx context.Context, productID uint, filter model.OfferFilter) (*model.Product, error) {
 	product, err := uc.productRepo.FindByID(ctx, productID)
 	if err != nil {
 		return nil, fmt.Errorf("error al buscar producto %d: %w", productID, err)
 	}
 
 	// Obtenemos las mejores ofertas para este producto
-	bestPrices, err := uc.priceRepo.FindTopOffersByProductID(ctx, productID, 3)
+	bestPrices, err := uc.priceRepo.FindTopOffersByProductID(ctx, productID, 3, filter)
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener mejores ofertas para el producto %d: %w", productID, err)
 	}
@@ -102,8 +106,8 @@ func (uc *ProductUseCase) GetProductDetail(ctx context.Context, productID uint)
 	return product, nil
 }
 
-// GetSimilarProducts obtiene productos similares a un producto dado
-func (uc *ProductUseCase) GetSimilarProducts(ctx context.Context, productID uint, limit int) ([]*model.Product, error) {
+// GetSimilarProducts obtiene productos similares a un producto dado con su mejor precio según el filtro
+func (uc *ProductUseCase) GetSimilarProducts(ctx context.Context, productID uint, limit int, filter model.OfferFilter) ([]*model.Product, error) {
 	// Obtener productos similares
 	similarProducts, err := uc.productRepo.FindSimilarProducts(ctx, productID, limit)
 	if err != nil {
@@ -112,7 +116,7 @@ func (uc *ProductUseCase) GetSimilarProducts(ctx context.Context, productID uint
 
 	// Para cada producto similar, obtenemos su mejor precio
 	for _, similarProduct := range similarProducts {
-		bestPrice, err := uc.priceRepo.FindBestPriceByProductID(ctx, similarProduct.ID)
+		bestPrice, err := uc.priceRepo.FindBestPriceByProductID(ctx, similarProduct.ID, filter)
 		if err == nil && bestPrice != nil {
 			similarProduct.Prices = []model.Price{*bestPrice}
 		}
@@ -138,11 +142,11 @@ func (uc *ProductUseCase) CountProductsByCategory(ctx context.Context, categoryI
 	return len(products), nil
 }
 
-// GetFeaturedProducts obtiene productos destacados
-func (uc *ProductUseCase) GetFeaturedProducts(ctx context.Context, limit int) ([]model.Product, error) {
+// GetFeaturedProducts obtiene productos destacados según el filtro
+func (uc *ProductUseCase) GetFeaturedProducts(ctx context.Context, limit int, filter model.OfferFilter) ([]model.Product, error) {
 	// Por ahora, simplemente reutilizamos el método GetBestDeals
 	// En el futuro, podríamos tener un criterio diferente para productos destacados
-	productsPtr, err := uc.GetBestDeals(ctx, limit)
+	productsPtr, err := uc.GetBestDeals(ctx, limit, filter)
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener productos destacados: %w", err)
 	}
@@ -200,7 +204,7 @@ func (uc *ProductUseCase) GetFilteredProductsByCategory(ctx context.Context, opt
 		}
 
 		// Obtener el mejor precio para este producto
-		bestPrice, err := uc.priceRepo.FindBestPriceByProductID(ctx, product.ID)
+		bestPrice, err := uc.priceRepo.FindBestPriceByProductID(ctx, product.ID, options.Offers)
 		if err == nil && bestPrice != nil {
 			product.Prices = []model.Price{*bestPrice}
 		}
@@ -220,8 +224,8 @@ func (uc *ProductUseCase) GetTotalFilteredProductsInCategory(ctx context.Context
 	return count, nil
 }
 
-// GetProductWithPrices obtiene un producto con sus precios asociados
-func (uc *ProductUseCase) GetProductWithPrices(ctx context.Context, productID uint) (*model.Product, error) {
+// GetProductWithPrices obtiene un producto con sus precios asociados, ordenados según el filtro
+func (uc *ProductUseCase) GetProductWithPrices(ctx context.Context, productID uint, filter model.OfferFilter) (*model.Product, error) {
 	product, err := uc.productRepo.FindByID(ctx, productID)
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener producto: %v", err)
@@ -241,9 +245,35 @@ func (uc *ProductUseCase) GetProductWithPrices(ctx context.Context, productID ui
 
 	// Ordenar precios por valor ascendente (el más bajo primero)
 	sort.Slice(prices, func(i, j int) bool {
-		return prices[i].Price < prices[j].Price
+		return filter.PriceOf(&prices[i]) < filter.PriceOf(&prices[j])
 	})
 
 	product.Prices = prices
 	return product, nil
 }
+
+// GetPriceHistory obtiene las observaciones de precio de un producto entre dos fechas.
+// Si store no está vacío solo se devuelven las observaciones de esa tienda
+func (uc *ProductUseCase) GetPriceHistory(ctx context.Context, productID uint, store string, from, to time.Time) ([]*model.PriceObservation, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.After(to) {
+		return nil, fmt.Errorf("rango de fechas inválido: %s > %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+
+	var (
+		observations []*model.PriceObservation
+		err          error
+	)
+	if store != "" {
+		observations, err = uc.historyRepo.FindByProductAndStore(ctx, productID, store, from, to)
+	} else {
+		observations, err = uc.historyRepo.FindByProductID(ctx, productID, from, to)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el histórico de precios del producto %d: %w", productID, err)
+	}
+
+	return observations, nil
+}
@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"app/internal/domain/repositories"
+	"app/internal/infrastructure/scraper"
+)
+
+// RefreshStats resume una ejecución del refresco prioritario
+type RefreshStats struct {
+	Products  int // Productos seguidos o con alertas activas
+	Offers    int // Ofertas (precio de una tienda) que se han intentado refrescar
+	Refreshed int // Ofertas actualizadas correctamente
+	Failed    int // Ofertas que no se pudieron scrapear o guardar
+}
+
+// RefreshUseCase vuelve a scrapear las páginas de detalle de los productos que interesan a los
+// usuarios (en alguna lista de seguimiento o con alertas activas), sin esperar al scraping
+// completo de las categorías. Los precios se guardan con IngestionUseCase, que evalúa las alertas
+// de cada producto cuyo precio cambie
+type RefreshUseCase struct {
+	priceAlertRepo    repositories.PriceAlertRepository
+	watchlistItemRepo repositories.WatchlistItemRepository
+	priceRepo         repositories.PriceRepository
+	ingestion         *IngestionUseCase
+	stores            *scraper.Registry
+}
+
+// NewRefreshUseCase crea una nueva instancia del caso de uso de refresco prioritario
+func NewRefreshUseCase(
+	priceAlertRepo repositories.PriceAlertRepository,
+	watchlistItemRepo repositories.WatchlistItemRepository,
+	priceRepo repositories.PriceRepository,
+	ingestion *IngestionUseCase,
+	stores *scraper.Registry,
+) *RefreshUseCase {
+	return &RefreshUseCase{
+		priceAlertRepo:    priceAlertRepo,
+		watchlistItemRepo: watchlistItemRepo,
+		priceRepo:         priceRepo,
+		ingestion:         ingestion,
+		stores:            stores,
+	}
+}
+
+// refreshTask es una oferta de un producto que hay que volver a scrapear
+type refreshTask struct {
+	productID uint
+	url       string
+	scraper   scraper.StoreScraper
+}
+
+// RefreshWatchedProducts refresca todas las ofertas de los productos seguidos o con alertas activas.
+// Las ofertas de cada tienda se piden de una en una (las tiendas en paralelo), de modo que el
+// límite de peticiones por dominio del Fetcher se aplica igual que en el scraping de categorías
+func (uc *RefreshUseCase) RefreshWatchedProducts(ctx context.Context) (RefreshStats, error) {
+	var stats RefreshStats
+
+	productIDs, err := uc.watchedProductIDs(ctx)
+	if err != nil {
+		return stats, err
+	}
+	stats.Products = len(productIDs)
+
+	// Agrupar las ofertas por tienda (las del scraper genérico llevan el dominio como tienda)
+	tasksByStore := make(map[string][]refreshTask)
+	for _, productID := range productIDs {
+		prices, err := uc.priceRepo.FindByProductID(ctx, productID)
+		if err != nil {
+			log.Printf("[REFRESCO] Error al obtener las ofertas del producto %d: %v", productID, err)
+			continue
+		}
+		for _, price := range prices {
+			if price.URL == "" {
+				continue
+			}
+			store := uc.stores.ForURL(price.URL)
+			if store == nil {
+				continue
+			}
+			tasksByStore[price.Store] = append(tasksByStore[price.Store], refreshTask{productID: productID, url: price.URL, scraper: store})
+			stats.Offers++
+		}
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, tasks := range tasksByStore {
+		wg.Add(1)
+		go func(tasks []refreshTask) {
+			defer wg.Done()
+			for _, task := range tasks {
+				if ctx.Err() != nil {
+					return
+				}
+				err := uc.refreshOffer(ctx, task)
+
+				mu.Lock()
+				if err != nil {
+					log.Printf("[REFRESCO] Error al refrescar %s (producto %d): %v", task.url, task.productID, err)
+					stats.Failed++
+				} else {
+					stats.Refreshed++
+				}
+				mu.Unlock()
+			}
+		}(tasks)
+	}
+	wg.Wait()
+
+	log.Printf("[REFRESCO] Productos: %d | Ofertas: %d | Actualizadas: %d | Fallidas: %d",
+		stats.Products, stats.Offers, stats.Refreshed, stats.Failed)
+	return stats, ctx.Err()
+}
+
+// refreshOffer scrapea la página de detalle de una oferta y registra el precio obtenido
+func (uc *RefreshUseCase) refreshOffer(ctx context.Context, task refreshTask) error {
+	product, err := task.scraper.ScrapProductDetails(ctx, task.url)
+	if err != nil {
+		return err
+	}
+	return uc.ingestion.RefreshOffer(ctx, task.productID, product)
+}
+
+// watchedProductIDs devuelve, sin repetir y ordenados, los productos que están en alguna lista de
+// seguimiento o tienen alertas activas
+func (uc *RefreshUseCase) watchedProductIDs(ctx context.Context) ([]uint, error) {
+	alerted, err := uc.priceAlertRepo.FindActiveProductIDs(ctx, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los productos con alertas activas: %w", err)
+	}
+	watched, err := uc.watchlistItemRepo.FindProductIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los productos seguidos: %w", err)
+	}
+
+	seen := make(map[uint]bool, len(alerted)+len(watched))
+	var productIDs []uint
+	for _, id := range append(alerted, watched...) {
+		if !seen[id] {
+			seen[id] = true
+			productIDs = append(productIDs, id)
+		}
+	}
+	sort.Slice(productIDs, func(i, j int) bool { return productIDs[i] < productIDs[j] })
+	return productIDs, nil
+}
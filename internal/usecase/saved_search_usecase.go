@@ -0,0 +1,308 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"app/internal/domain/model"
+	"app/internal/domain/repositories"
+	"app/internal/infrastructure/notifier"
+	"app/pkg/utils"
+)
+
+// ErrInvalidSavedSearch indica que los criterios de la búsqueda guardada no son válidos
+var ErrInvalidSavedSearch = errors.New("búsqueda guardada no válida")
+
+// savedSearchSeedPageSize es el número de productos que se leen por página al registrar los
+// productos que ya cumplen una búsqueda nueva
+const savedSearchSeedPageSize = 500
+
+// SavedSearchUseCase gestiona las búsquedas guardadas y avisa a sus usuarios cuando un producto
+// nuevo o que cambia de precio las cumple por primera vez
+type SavedSearchUseCase struct {
+	savedSearchRepo repositories.SavedSearchRepository
+	categoryRepo    repositories.CategoryRepository
+	productRepo     repositories.ProductRepository
+	priceRepo       repositories.PriceRepository
+	userRepo        repositories.UserRepository
+	notifications   *NotificationChannelUseCase
+	currency        *CurrencyUseCase // Pasa los importes de los nombres y avisos a la moneda de cada usuario
+
+	// Registros en segundo plano de los productos que ya cumplen una búsqueda nueva o reanudada
+	seeding sync.WaitGroup
+}
+
+// NewSavedSearchUseCase crea una nueva instancia del caso de uso de búsquedas guardadas
+func NewSavedSearchUseCase(
+	savedSearchRepo repositories.SavedSearchRepository,
+	categoryRepo repositories.CategoryRepository,
+	productRepo repositories.ProductRepository,
+	priceRepo repositories.PriceRepository,
+	userRepo repositories.UserRepository,
+	notifications *NotificationChannelUseCase,
+	currency *CurrencyUseCase,
+) *SavedSearchUseCase {
+	return &SavedSearchUseCase{
+		savedSearchRepo: savedSearchRepo,
+		categoryRepo:    categoryRepo,
+		productRepo:     productRepo,
+		priceRepo:       priceRepo,
+		userRepo:        userRepo,
+		notifications:   notifications,
+		currency:        currency,
+	}
+}
+
+// CreateSearch guarda una búsqueda del usuario. categorySlug vacío equivale a cualquier categoría y
+// los precios de la búsqueda están en la moneda de visualización. Los productos que ya la cumplen se
+// registran sin avisar y en segundo plano: solo se notifican los que la cumplan a partir de ahora
+func (uc *SavedSearchUseCase) CreateSearch(ctx context.Context, userID uint, categorySlug string, search model.SavedSearch) (*model.SavedSearch, error) {
+	search.ID = 0
+	search.UserID = userID
+	search.IsActive = true
+	search.CategoryID = nil
+	search.Category = nil
+
+	if categorySlug != "" {
+		category, err := uc.categoryRepo.FindBySlug(ctx, categorySlug)
+		if err != nil {
+			return nil, fmt.Errorf("%w: categoría %q no encontrada", ErrInvalidSavedSearch, categorySlug)
+		}
+		search.CategoryID = &category.ID
+		search.Category = category
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el usuario %d: %w", userID, err)
+	}
+	search.Normalize(uc.currency.Formatter(uc.currency.UserCurrency(user)))
+	if err := search.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSavedSearch, err)
+	}
+
+	if err := uc.savedSearchRepo.Create(ctx, &search); err != nil {
+		return nil, fmt.Errorf("error al guardar la búsqueda: %w", err)
+	}
+
+	uc.startSeeding(ctx, &search)
+	return &search, nil
+}
+
+// SetSearchActive pausa o reanuda una búsqueda del usuario. Al reanudarla se registran sin avisar,
+// en segundo plano, los productos que la cumplieron mientras estaba pausada
+func (uc *SavedSearchUseCase) SetSearchActive(ctx context.Context, searchID, userID uint, active bool) error {
+	search, err := uc.findUserSearch(ctx, searchID, userID)
+	if err != nil {
+		return err
+	}
+	if search.IsActive == active {
+		return nil
+	}
+
+	search.IsActive = active
+	if err := uc.savedSearchRepo.Update(ctx, search); err != nil {
+		return fmt.Errorf("error al actualizar la búsqueda: %w", err)
+	}
+	if active {
+		uc.startSeeding(ctx, search)
+	}
+	return nil
+}
+
+// DeleteSearch elimina una búsqueda del usuario
+func (uc *SavedSearchUseCase) DeleteSearch(ctx context.Context, searchID, userID uint) error {
+	if _, err := uc.findUserSearch(ctx, searchID, userID); err != nil {
+		return err
+	}
+	if err := uc.savedSearchRepo.Delete(ctx, searchID); err != nil {
+		return fmt.Errorf("error al eliminar la búsqueda: %w", err)
+	}
+	return nil
+}
+
+// GetUserSearches devuelve las búsquedas guardadas de un usuario
+func (uc *SavedSearchUseCase) GetUserSearches(ctx context.Context, userID uint) ([]*model.SavedSearch, error) {
+	return uc.savedSearchRepo.FindByUserID(ctx, userID)
+}
+
+// GetSearchMatches devuelve los últimos productos que han cumplido una búsqueda
+func (uc *SavedSearchUseCase) GetSearchMatches(ctx context.Context, searchID uint, limit int) ([]*model.SavedSearchMatch, error) {
+	return uc.savedSearchRepo.FindMatchesBySearchID(ctx, searchID, limit)
+}
+
+// HandlePriceChange evalúa las búsquedas guardadas con un producto que se acaba de dar de alta o
+// cuyo precio ha cambiado. Se registra en IngestionUseCase.OnPriceChange
+func (uc *SavedSearchUseCase) HandlePriceChange(ctx context.Context, productID uint) {
+	product, err := uc.productRepo.FindByID(ctx, productID)
+	if err != nil {
+		log.Printf("[BUSQUEDAS] Producto %d no encontrado: %v", productID, err)
+		return
+	}
+
+	searches, err := uc.savedSearchRepo.FindActiveForCategory(ctx, product.CategoryID)
+	if err != nil {
+		log.Printf("[BUSQUEDAS] Error al obtener las búsquedas de la categoría %d: %v", product.CategoryID, err)
+		return
+	}
+	if len(searches) == 0 {
+		return
+	}
+
+	prices, err := uc.priceRepo.FindByProductID(ctx, productID)
+	if err != nil {
+		log.Printf("[BUSQUEDAS] Error al obtener las ofertas del producto %d: %v", productID, err)
+		return
+	}
+
+	for _, search := range searches {
+		offer := matchingOffer(search, product, prices)
+		if offer == nil {
+			continue
+		}
+		if uc.recordMatch(ctx, search, product.ID, offer, true) {
+			uc.notifyMatch(ctx, search, product, offer)
+		}
+	}
+}
+
+// Wait espera a que terminen los registros en segundo plano de las búsquedas nuevas o reanudadas.
+// Se llama al apagar la aplicación
+func (uc *SavedSearchUseCase) Wait() {
+	uc.seeding.Wait()
+}
+
+// startSeeding lanza seedMatches en segundo plano con una copia de la búsqueda, para no recorrer el
+// catálogo durante la petición que la crea o la reanuda. No se cancela al terminar la petición
+func (uc *SavedSearchUseCase) startSeeding(ctx context.Context, search *model.SavedSearch) {
+	seeded := *search
+	uc.seeding.Add(1)
+	go func() {
+		defer uc.seeding.Done()
+		uc.seedMatches(context.WithoutCancel(ctx), &seeded)
+	}()
+}
+
+// seedMatches registra, sin avisar, los productos que ya cumplen la búsqueda
+func (uc *SavedSearchUseCase) seedMatches(ctx context.Context, search *model.SavedSearch) {
+	var afterID uint
+	seeded := 0
+	for {
+		products, err := uc.savedSearchRepo.FindCandidateProducts(ctx, search.CategoryID, afterID, savedSearchSeedPageSize)
+		if err != nil {
+			log.Printf("[BUSQUEDAS] Error al obtener los productos de la búsqueda %d: %v", search.ID, err)
+			return
+		}
+
+		for _, product := range products {
+			if !utils.ContainsAllTerms(product.Name, search.Keywords) {
+				continue
+			}
+			prices, err := uc.priceRepo.FindByProductID(ctx, product.ID)
+			if err != nil {
+				log.Printf("[BUSQUEDAS] Error al obtener las ofertas del producto %d: %v", product.ID, err)
+				continue
+			}
+			if offer := matchingOffer(search, product, prices); offer != nil && uc.recordMatch(ctx, search, product.ID, offer, false) {
+				seeded++
+			}
+		}
+
+		if len(products) < savedSearchSeedPageSize {
+			break
+		}
+		afterID = products[len(products)-1].ID
+	}
+
+	log.Printf("[BUSQUEDAS] Búsqueda %d: %d productos ya la cumplían", search.ID, seeded)
+}
+
+// recordMatch registra la coincidencia y devuelve true si es la primera vez que el producto cumple
+// la búsqueda
+func (uc *SavedSearchUseCase) recordMatch(ctx context.Context, search *model.SavedSearch, productID uint, offer *model.Price, notified bool) bool {
+	created, err := uc.savedSearchRepo.CreateMatch(ctx, &model.SavedSearchMatch{
+		SearchID:  search.ID,
+		ProductID: productID,
+		Price:     search.OfferFilter.PriceOf(offer),
+		Store:     offer.Store,
+		URL:       offer.URL,
+		Notified:  notified,
+		MatchedAt: time.Now(),
+	})
+	if err != nil {
+		log.Printf("[BUSQUEDAS] Error al registrar el producto %d en la búsqueda %d: %v", productID, search.ID, err)
+		return false
+	}
+	return created
+}
+
+// notifyMatch crea la notificación del producto que cumple la búsqueda y la envía por los canales
+// del usuario. El correo solo se envía si la búsqueda lo pide
+func (uc *SavedSearchUseCase) notifyMatch(ctx context.Context, search *model.SavedSearch, product *model.Product, offer *model.Price) {
+	user, err := uc.userRepo.FindByID(ctx, search.UserID)
+	if err != nil {
+		log.Printf("[BUSQUEDAS] Error al obtener el usuario %d: %v", search.UserID, err)
+		return
+	}
+
+	currency := uc.currency.UserCurrency(user)
+	amount, _ := uc.currency.FromComparison(search.OfferFilter.PriceOf(offer), currency)
+
+	uc.notifications.Dispatch(ctx, user, &notifier.Message{
+		Event: notifier.EventSavedSearch,
+		Title: fmt.Sprintf("Nuevo resultado para «%s»", search.Name),
+		Body: fmt.Sprintf("%s cumple tu búsqueda guardada: %s en %s.",
+			product.Name, model.FormatPrice(amount, currency), offer.Store),
+		ProductID:   product.ID,
+		ProductName: product.Name,
+		Price:       amount,
+		Currency:    currency,
+		Store:       offer.Store,
+		OfferURL:    offer.URL,
+		SearchID:    &search.ID,
+		SearchName:  search.Name,
+		CreatedAt:   time.Now(),
+	}, search.NotifyByEmail)
+}
+
+// findUserSearch busca una búsqueda y comprueba que pertenece al usuario
+func (uc *SavedSearchUseCase) findUserSearch(ctx context.Context, searchID, userID uint) (*model.SavedSearch, error) {
+	search, err := uc.savedSearchRepo.FindByID(ctx, searchID)
+	if err != nil {
+		return nil, err
+	}
+	if search.UserID != userID {
+		return nil, fmt.Errorf("no tienes permiso para modificar esta búsqueda")
+	}
+	return search, nil
+}
+
+// matchingOffer devuelve la mejor oferta del producto que cuenta para la búsqueda, o nil si el
+// producto no la cumple. Como en el listado de la categoría, el rango de precios se aplica al mejor
+// precio de las ofertas que pasan los filtros
+func matchingOffer(search *model.SavedSearch, product *model.Product, prices []*model.Price) *model.Price {
+	if search.CategoryID != nil && *search.CategoryID != product.CategoryID {
+		return nil
+	}
+	if !utils.ContainsAllTerms(product.Name, search.Keywords) {
+		return nil
+	}
+
+	var best *model.Price
+	for _, price := range prices {
+		if !search.MatchesOffer(price) {
+			continue
+		}
+		if best == nil || search.OfferFilter.PriceOf(price) < search.OfferFilter.PriceOf(best) {
+			best = price
+		}
+	}
+	if best == nil || !search.InPriceRange(search.OfferFilter.PriceOf(best)) {
+		return nil
+	}
+	return best
+}
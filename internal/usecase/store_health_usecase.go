@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"app/internal/domain/model"
+	"app/internal/domain/repositories"
+)
+
+// storeHealthWindow es el periodo de ejecuciones que se analiza para calcular la salud de las tiendas
+const storeHealthWindow = 7 * 24 * time.Hour
+
+// StoreHealthUseCase calcula el estado del scraping de cada tienda a partir de sus ejecuciones
+type StoreHealthUseCase struct {
+	scrapeRunRepo repositories.ScrapeRunRepository
+	stores        []string
+}
+
+// NewStoreHealthUseCase crea una nueva instancia del caso de uso de salud de tiendas.
+// stores son los nombres de las tiendas habilitadas, para mostrarlas aunque no tengan ejecuciones
+func NewStoreHealthUseCase(scrapeRunRepo repositories.ScrapeRunRepository, stores []string) *StoreHealthUseCase {
+	return &StoreHealthUseCase{
+		scrapeRunRepo: scrapeRunRepo,
+		stores:        stores,
+	}
+}
+
+// GetStoreHealth devuelve la salud de cada tienda en los últimos días. Una categoría se marca
+// cuando su última ejecución no devolvió productos pero alguna anterior sí, que suele indicar
+// que la tienda ha cambiado su HTML
+func (uc *StoreHealthUseCase) GetStoreHealth(ctx context.Context) ([]*model.StoreHealth, error) {
+	runs, err := uc.scrapeRunRepo.FindSince(ctx, time.Now().Add(-storeHealthWindow))
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener las ejecuciones del scraper: %w", err)
+	}
+
+	// Agrupar por tienda (las ejecuciones vienen de la más reciente a la más antigua)
+	runsByStore := make(map[string][]*model.ScrapeRun)
+	order := append([]string(nil), uc.stores...)
+	for _, run := range runs {
+		if _, seen := runsByStore[run.Store]; !seen && !containsString(order, run.Store) {
+			order = append(order, run.Store)
+		}
+		runsByStore[run.Store] = append(runsByStore[run.Store], run)
+	}
+
+	health := make([]*model.StoreHealth, 0, len(order))
+	for _, store := range order {
+		health = append(health, buildStoreHealth(store, runsByStore[store]))
+	}
+
+	return health, nil
+}
+
+// GetStoreRuns devuelve las últimas ejecuciones de una tienda
+func (uc *StoreHealthUseCase) GetStoreRuns(ctx context.Context, store string, limit int) ([]*model.ScrapeRun, error) {
+	runs, err := uc.scrapeRunRepo.FindByStore(ctx, store, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener las ejecuciones de %s: %w", store, err)
+	}
+	return runs, nil
+}
+
+// buildStoreHealth calcula la salud de una tienda a partir de sus ejecuciones (de más reciente a más antigua)
+func buildStoreHealth(store string, runs []*model.ScrapeRun) *model.StoreHealth {
+	health := &model.StoreHealth{
+		Store:  store,
+		Status: model.StoreHealthUnknown,
+		Runs:   len(runs),
+	}
+	if len(runs) == 0 {
+		return health
+	}
+
+	lastRunAt := runs[0].StartedAt
+	health.LastRunAt = &lastRunAt
+
+	totalItems := 0
+	latestByCategory := make(map[uint]*model.ScrapeRun)
+	hadProducts := make(map[uint]bool)
+	for _, run := range runs {
+		totalItems += run.ItemsFound
+
+		// Las ejecuciones en curso no cuentan como última ejecución
+		if run.FinishedAt == nil {
+			continue
+		}
+		if _, ok := latestByCategory[run.CategoryID]; !ok {
+			latestByCategory[run.CategoryID] = run
+			health.LatestRuns = append(health.LatestRuns, run)
+			continue
+		}
+		if run.ItemsFound > 0 {
+			hadProducts[run.CategoryID] = true
+		}
+	}
+	health.AverageItemsFound = float64(totalItems) / float64(len(runs))
+
+	for _, run := range health.LatestRuns {
+		health.LastItemsFound += run.ItemsFound
+		health.LastHTTPErrors += run.HTTPErrors
+		if run.ItemsFound == 0 && hadProducts[run.CategoryID] {
+			health.ZeroCategories = append(health.ZeroCategories, run.CategoryName)
+		}
+	}
+
+	switch {
+	case len(health.LatestRuns) == 0:
+		health.Status = model.StoreHealthUnknown
+	case health.LastItemsFound == 0:
+		health.Status = model.StoreHealthError
+	case len(health.ZeroCategories) > 0:
+		health.Status = model.StoreHealthWarning
+	default:
+		health.Status = model.StoreHealthOK
+	}
+
+	return health
+}
+
+// containsString indica si una lista de textos contiene uno dado
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"app/internal/domain/model"
+	"app/internal/domain/repositories"
+	"app/pkg/utils"
+)
+
+var (
+	// ErrInvalidTrackURL indica que la URL enviada no es una URL http(s) pública
+	ErrInvalidTrackURL = errors.New("URL de producto no válida")
+
+	// ErrProductNotTrackable indica que la página no se pudo scrapear o el producto no encaja en
+	// ninguna categoría del catálogo
+	ErrProductNotTrackable = errors.New("no se pudo obtener el producto de la URL")
+)
+
+// TrackResult resume el resultado de seguir una URL
+type TrackResult struct {
+	Product      *model.Product    // Producto del catálogo al que se ha vinculado la URL
+	Alert        *model.PriceAlert // Alerta creada o actualizada (nil si no se pidió)
+	AlertUpdated bool              // El usuario ya tenía una alerta para el producto y se ha actualizado
+}
+
+// TrackingUseCase permite a los usuarios seguir el precio de cualquier URL de producto: la
+// scrapea, la clasifica y la vincula a un producto del catálogo (o lo crea), la añade a su
+// lista de seguimiento y, opcionalmente, crea una alerta de precio
+type TrackingUseCase struct {
+	scraper           *ScraperUseCase
+	priceAlerts       *PriceAlertUseCase
+	watchlistRepo     repositories.WatchlistRepository
+	watchlistItemRepo repositories.WatchlistItemRepository
+}
+
+// NewTrackingUseCase crea una nueva instancia del caso de uso de seguimiento de URLs
+func NewTrackingUseCase(
+	scraper *ScraperUseCase,
+	priceAlerts *PriceAlertUseCase,
+	watchlistRepo repositories.WatchlistRepository,
+	watchlistItemRepo repositories.WatchlistItemRepository,
+) *TrackingUseCase {
+	return &TrackingUseCase{
+		scraper:           scraper,
+		priceAlerts:       priceAlerts,
+		watchlistRepo:     watchlistRepo,
+		watchlistItemRepo: watchlistItemRepo,
+	}
+}
+
+// TrackURL scrapea la URL y la vincula al catálogo clasificando el producto automáticamente.
+// Si targetPrice es mayor que 0 crea (o actualiza) la alerta de precio del usuario con el filtro
+// de ofertas indicado
+func (uc *TrackingUseCase) TrackURL(ctx context.Context, userID uint, productURL string, targetPrice float64, filter model.OfferFilter) (*TrackResult, error) {
+	productURL, err := validateTrackURL(productURL)
+	if err != nil {
+		return nil, err
+	}
+	if targetPrice < 0 {
+		return nil, fmt.Errorf("el precio objetivo no puede ser negativo")
+	}
+
+	product, err := uc.scraper.ScrapeProductDetails(ctx, productURL, 0)
+	if err != nil {
+		log.Printf("[SEGUIMIENTO] Usuario %d no pudo seguir %s: %v", userID, productURL, err)
+		return nil, fmt.Errorf("%w: %v", ErrProductNotTrackable, err)
+	}
+	log.Printf("[SEGUIMIENTO] Usuario %d sigue '%s' (ID: %d) desde %s", userID, product.Name, product.ID, productURL)
+
+	result := &TrackResult{Product: product}
+	uc.addToWatchlist(ctx, userID, product.ID, targetPrice)
+
+	if targetPrice == 0 {
+		return result, nil
+	}
+
+	// Con un precio objetivo la alerta es siempre de tipo target_price
+	rule := model.AlertRule{RuleType: model.AlertRuleTargetPrice}
+	alerts, err := uc.priceAlerts.GetUserAlerts(ctx, userID)
+	if err != nil {
+		return result, fmt.Errorf("error al obtener las alertas del usuario: %w", err)
+	}
+	for _, alert := range alerts {
+		if alert.ProductID == product.ID {
+			result.AlertUpdated = true
+			result.Alert, err = uc.priceAlerts.UpdateAlert(ctx, alert.ID, userID, targetPrice, nil, true, &filter, nil, &rule)
+			break
+		}
+	}
+	if !result.AlertUpdated {
+		result.Alert, err = uc.priceAlerts.CreateAlert(ctx, userID, product.ID, targetPrice, true, filter, model.NotifyPolicy{}, rule)
+	}
+	if err != nil {
+		return result, fmt.Errorf("error al guardar la alerta de precio: %w", err)
+	}
+
+	return result, nil
+}
+
+// addToWatchlist añade el producto a la lista de seguimiento del usuario, creándola si no existe.
+// Los errores solo se registran: el producto ya está en el catálogo aunque falle este paso
+func (uc *TrackingUseCase) addToWatchlist(ctx context.Context, userID, productID uint, targetPrice float64) {
+	if _, err := uc.watchlistRepo.FindByUserID(ctx, userID); err != nil {
+		watchlist := &model.Watchlist{UserID: userID, Name: "Mi lista de seguimiento"}
+		if err := uc.watchlistRepo.Create(ctx, watchlist); err != nil {
+			log.Printf("[Watchlist] Error al crear watchlist para usuario=%d: %v", userID, err)
+		}
+	}
+
+	exists, err := uc.watchlistItemRepo.IsProductInWatchlist(ctx, userID, productID)
+	if err != nil {
+		log.Printf("[Watchlist] Error al comprobar item usuario=%d producto=%d: %v", userID, productID, err)
+		return
+	}
+	if exists {
+		return
+	}
+
+	item := &model.WatchlistItem{UserID: userID, ProductID: productID, TargetPrice: targetPrice}
+	if err := uc.watchlistItemRepo.Create(ctx, item); err != nil {
+		log.Printf("[Watchlist] Error al insertar item usuario=%d producto=%d: %v", userID, productID, err)
+	}
+}
+
+// validateTrackURL normaliza la URL enviada por el usuario y comprueba que es http(s) y no apunta
+// a la propia máquina ni a una red privada
+func validateTrackURL(rawURL string) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return "", fmt.Errorf("%w: %s", ErrInvalidTrackURL, rawURL)
+	}
+
+	if utils.IsLocalHost(parsed.Hostname()) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidTrackURL, rawURL)
+	}
+
+	// El fragmento no forma parte de la página del producto
+	parsed.Fragment = ""
+	return parsed.String(), nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"app/internal/domain/model"
@@ -21,6 +22,7 @@ import (
 type UserUseCase struct {
 	userRepo     repositories.UserRepository
 	emailService EmailService
+	currency     *CurrencyUseCase // Monedas que puede elegir el usuario para ver los precios
 }
 
 // EmailService es la interfaz del servicio de envío de correos (Mailer)
@@ -28,13 +30,32 @@ type UserUseCase struct {
 type EmailService interface {
 	SendVerificationEmail(to, token, username string) error
 	SendPasswordResetEmail(to, token, username string) error
+	SendPasswordChangedEmail(to, username string) error
+	ParseUnsubscribeToken(token string) (uint, string, error)
+}
+
+// ErrInvalidUnsubscribeLink indica que el enlace para darse de baja no es válido o que su usuario ya no existe
+var ErrInvalidUnsubscribeLink = errors.New("el enlace para darse de baja no es válido")
+
+// EmailPreferences son los tipos de correo que acepta un usuario (ver model.EmailCategory*)
+type EmailPreferences struct {
+	Alerts  bool // Alertas de precio y búsquedas guardadas
+	Digest  bool // Resúmenes diarios o semanales
+	Account bool // Avisos de cuenta y seguridad
+}
+
+// PricePreferences son las preferencias de un usuario sobre cómo se le muestran los precios
+type PricePreferences struct {
+	Currency          string // Moneda en la que ve los precios (código ISO); vacía usa currency.display
+	CompareLandedCost bool   // Compara los precios por coste total (precio, envío e importación)
 }
 
 // NewUserUseCase devuelve una nueva instancia del caso de uso de usuarios.
-func NewUserUseCase(userRepo repositories.UserRepository, emailSvc EmailService) *UserUseCase {
+func NewUserUseCase(userRepo repositories.UserRepository, emailSvc EmailService, currency *CurrencyUseCase) *UserUseCase {
 	return &UserUseCase{
 		userRepo:     userRepo,
 		emailService: emailSvc,
+		currency:     currency,
 	}
 }
 
@@ -118,26 +139,97 @@ func (uc *UserUseCase) GetUserByEmail(ctx context.Context, email string) (*model
 	return uc.userRepo.FindByEmail(ctx, email)
 }
 
-// UpdateUserSettings actualiza las configuraciones del usuario
-func (uc *UserUseCase) UpdateUserSettings(ctx context.Context, userID uint, emailNotifications bool) error {
+// UpdateEmailPreferences guarda los tipos de correo que acepta el usuario
+func (uc *UserUseCase) UpdateEmailPreferences(ctx context.Context, userID uint, prefs EmailPreferences) error {
 	// Buscar el usuario
 	user, err := uc.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("usuario no encontrado: %w", err)
 	}
 
-	// Actualizar el campo de notificaciones por email
-	user.EmailNotifications = emailNotifications
+	user.SetEmailCategory(model.EmailCategoryAlerts, prefs.Alerts)
+	user.SetEmailCategory(model.EmailCategoryDigest, prefs.Digest)
+	user.SetEmailCategory(model.EmailCategoryAccount, prefs.Account)
 	user.UpdatedAt = time.Now()
 
 	// Guardar los cambios
 	if err := uc.userRepo.Update(ctx, user); err != nil {
-		return fmt.Errorf("error al actualizar configuración: %w", err)
+		return fmt.Errorf("error al actualizar las preferencias de correo: %w", err)
 	}
 
 	return nil
 }
 
+// UpdatePricePreferences guarda la moneda en la que el usuario ve los precios y si los compara por
+// coste total
+func (uc *UserUseCase) UpdatePricePreferences(ctx context.Context, userID uint, prefs PricePreferences) error {
+	currency := strings.ToUpper(strings.TrimSpace(prefs.Currency))
+	if currency != "" && !uc.currency.IsSupported(currency) {
+		return fmt.Errorf("no hay tipo de cambio para la moneda %q", currency)
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("usuario no encontrado: %w", err)
+	}
+
+	user.Currency = currency
+	user.CompareLandedCost = &prefs.CompareLandedCost
+	user.UpdatedAt = time.Now()
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("error al actualizar las preferencias de precios: %w", err)
+	}
+
+	return nil
+}
+
+// CheckUnsubscribeLink comprueba un enlace para darse de baja sin aplicarlo. Devuelve el usuario y
+// el tipo de correo del enlace
+func (uc *UserUseCase) CheckUnsubscribeLink(ctx context.Context, token string) (*model.User, string, error) {
+	userID, category, err := uc.emailService.ParseUnsubscribeToken(token)
+	if err != nil {
+		return nil, "", ErrInvalidUnsubscribeLink
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, "", ErrInvalidUnsubscribeLink
+	}
+	return user, category, nil
+}
+
+// Unsubscribe da de baja al usuario del tipo de correo de un enlace firmado. No hace falta iniciar
+// sesión: la firma del enlace identifica al usuario. Darse de baja dos veces no es un error
+func (uc *UserUseCase) Unsubscribe(ctx context.Context, token string) (*model.User, string, error) {
+	user, category, err := uc.CheckUnsubscribeLink(ctx, token)
+	if err != nil {
+		return nil, "", err
+	}
+	if !user.AcceptsEmail(category) {
+		return user, category, nil
+	}
+
+	user.SetEmailCategory(category, false)
+	user.UpdatedAt = time.Now()
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return nil, "", fmt.Errorf("error al guardar la baja: %w", err)
+	}
+
+	log.Printf("[INFO] Usuario ID=%d dado de baja de los correos de tipo %s", user.ID, category)
+	return user, category, nil
+}
+
+// notifyPasswordChanged avisa por correo al usuario de que su contraseña ha cambiado, si acepta
+// los avisos de cuenta. El cambio ya está guardado, así que un error solo se registra
+func (uc *UserUseCase) notifyPasswordChanged(user *model.User) {
+	if !user.AcceptsEmail(model.EmailCategoryAccount) {
+		return
+	}
+	if err := uc.emailService.SendPasswordChangedEmail(user.Email, user.Username); err != nil {
+		log.Printf("[ERROR] No se pudo enviar el aviso de cambio de contraseña a usuario ID=%d: %v", user.ID, err)
+	}
+}
+
 // InitiatePasswordReset genera token y envía correo de restablecimiento
 func (uc *UserUseCase) InitiatePasswordReset(ctx context.Context, userID uint) error {
 	user, err := uc.userRepo.FindByID(ctx, userID)
@@ -187,6 +279,7 @@ func (uc *UserUseCase) ResetPassword(ctx context.Context, token, newPassword str
 		return nil, fmt.Errorf("error al actualizar contraseña: %w", err)
 	}
 
+	uc.notifyPasswordChanged(user)
 	return user, nil
 }
 
@@ -230,6 +323,7 @@ func (uc *UserUseCase) ChangePassword(ctx context.Context, userID uint, currentP
 	}
 
 	log.Printf("[INFO] UserUseCase.ChangePassword - Contraseña actualizada exitosamente para userID: %d", userID)
+	uc.notifyPasswordChanged(user)
 	return nil
 }
 
@@ -266,6 +360,8 @@ func (uc *UserUseCase) Register(ctx context.Context, username, email, password s
 		VerifyToken:        verifyToken,
 		Verified:           false,
 		EmailNotifications: notifyByEmail,
+		EmailDigest:        true,
+		EmailAccount:       true,
 		CreatedAt:          time.Now(),
 		UpdatedAt:          time.Now(),
 	}
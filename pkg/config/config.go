@@ -4,6 +4,7 @@ package config
 import (
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -19,7 +20,10 @@ type Configuration struct {
 	App      AppConfig
 	Database DatabaseConfig
 	Scraper  ScraperConfig
+	Currency CurrencyConfig
 	Email    EmailConfig
+	Notify   NotifyConfig
+	Stores   []StoreConfig
 }
 
 // AppConfig contiene la configuración general de la aplicación
@@ -48,10 +52,50 @@ type DatabaseConfig struct {
 
 // ScraperConfig contiene la configuración para los scrapers
 type ScraperConfig struct {
-	UpdateInterval time.Duration
-	UserAgent      string
-	MaxRetries     int
-	RetryDelay     time.Duration
+	UpdateInterval   time.Duration
+	UserAgent        string
+	MaxRetries       int           // Reintentos ante errores de red, 429 y 5xx
+	RetryDelay       time.Duration // Espera base entre reintentos (se duplica en cada intento)
+	RequestDelay     time.Duration // Intervalo mínimo entre peticiones al mismo dominio
+	RequestTimeout   time.Duration
+	RespectRobotsTxt bool
+	StoresDir        string
+	MaxPages         int
+	StopWhenNoNew    bool
+	FixturesMode     string // "record", "replay" o vacío (peticiones reales)
+	FixturesDir      string
+	// AllowPrivateNetworks permite que los scrapers se conecten a la propia máquina o a redes
+	// privadas (solo para tiendas de prueba en local: las URLs las pueden enviar los usuarios)
+	AllowPrivateNetworks bool
+	// PriorityRefreshInterval es cada cuánto se refrescan las páginas de detalle de los productos
+	// seguidos o con alertas activas (0 desactiva el refresco prioritario)
+	PriorityRefreshInterval time.Duration
+}
+
+// CurrencyConfig contiene la configuración de monedas y tipos de cambio
+type CurrencyConfig struct {
+	Base      string // Moneda respecto a la que se expresan los tipos de cambio
+	Display   string // Moneda en la que se comparan los precios y que ven los usuarios que no eligen otra
+	RatesFile string // Archivo YAML con tipos de cambio que se importa al arrancar
+	// CompareLandedCost hace que los listados, el mejor precio y las alertas usen el coste total
+	// (precio + envío + importación) en lugar del precio del producto para los visitantes y los
+	// usuarios que no lo eligen en su perfil
+	CompareLandedCost bool
+}
+
+// StoreConfig contiene la configuración de una tienda (sección "stores")
+type StoreConfig struct {
+	ID       string `mapstructure:"id"`
+	Name     string `mapstructure:"name"`
+	BaseURL  string `mapstructure:"base_url"`
+	Enabled  *bool  `mapstructure:"enabled"` // nil si la entrada no lo indica: la tienda está habilitada
+	MaxPages int    `mapstructure:"max_pages"`
+}
+
+// IsEnabled indica si la tienda está habilitada. Una entrada sin "enabled" lo está, para que añadir
+// una tienda a la configuración no la desactive sin avisar
+func (s StoreConfig) IsEnabled() bool {
+	return s.Enabled == nil || *s.Enabled
 }
 
 // EmailConfig contiene la configuración para el servicio de correo electrónico
@@ -61,6 +105,44 @@ type EmailConfig struct {
 	SMTPUser string
 	SMTPPass string
 	SMTPFrom string
+
+	// Clave con la que se firman los enlaces para darse de baja de los correos. Es obligatoria: sin
+	// ella la aplicación no arranca, para que los enlaces de los correos enviados sigan valiendo
+	UnsubscribeSecret string
+	// Tiempo durante el que vale un enlace para darse de baja desde que se envió el correo
+	UnsubscribeMaxAge time.Duration
+
+	// Bandeja de salida: todos los correos se guardan antes de enviarse y un worker los envía
+	OutboxPollInterval time.Duration // Cada cuánto se buscan correos pendientes
+	OutboxBatchSize    int           // Correos enviados como mucho en cada pasada
+	OutboxMaxAttempts  int           // Intentos antes de descartar un correo
+	OutboxRetryDelay   time.Duration // Espera tras el primer fallo (se duplica en cada intento)
+	OutboxMaxDelay     time.Duration // Espera máxima entre intentos
+	OutboxRetention    time.Duration // Tiempo que se conservan los correos enviados
+}
+
+// NotifyConfig contiene la configuración de los canales de notificación externos
+type NotifyConfig struct {
+	WebhookTimeout    time.Duration // Tiempo máximo de cada petición al webhook
+	WebhookMaxRetries int           // Reintentos ante errores de red, 429 y 5xx
+	WebhookRetryDelay time.Duration // Espera base entre reintentos (se duplica en cada intento)
+	// WebhookAllowPrivateNetworks permite webhooks en localhost o en redes privadas (por ejemplo,
+	// un relay local hacia herramientas de chat o domótica). Desactivado, se rechazan para que los
+	// usuarios no puedan hacer peticiones a servicios internos
+	WebhookAllowPrivateNetworks bool
+
+	// Envíos a los canales externos (correo y webhooks), que se hacen fuera de la ingesta
+	DeliveryWorkers      int           // Envíos simultáneos como mucho
+	DeliveryQueueSize    int           // Envíos que pueden esperar en la cola antes de frenar a quien avisa
+	DeliveryDrainTimeout time.Duration // Tiempo que se espera al apagar a que terminen los envíos pendientes
+
+	DigestDailySchedule  string // Expresión cron con la hora de envío de los resúmenes diarios
+	DigestWeeklySchedule string // Expresión cron con el día y la hora de envío de los resúmenes semanales
+
+	// Notificaciones en tiempo real (Server-Sent Events) en las pestañas abiertas del usuario
+	StreamHeartbeat  time.Duration // Cada cuánto se envía un comentario para mantener viva la conexión
+	StreamBufferSize int           // Notificaciones que puede acumular una pestaña lenta antes de perder alguna
+	StreamMaxPerUser int           // Conexiones abiertas como mucho por usuario
 }
 
 // InitConfig inicializa la configuración global de la aplicación
@@ -85,12 +167,47 @@ func InitConfig() {
 	viper.SetDefault("scraper.user_agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
 	viper.SetDefault("scraper.max_retries", 3)
 	viper.SetDefault("scraper.retry_delay", "5s")
+	viper.SetDefault("scraper.request_delay", "1s")
+	viper.SetDefault("scraper.request_timeout", "30s")
+	viper.SetDefault("scraper.respect_robots_txt", true)
+	viper.SetDefault("scraper.stores_dir", "./configs/stores")
+	viper.SetDefault("scraper.max_pages", 5)
+	viper.SetDefault("scraper.stop_when_no_new", true)
+	viper.SetDefault("scraper.fixtures_mode", "")
+	viper.SetDefault("scraper.fixtures_dir", "./fixtures")
+	viper.SetDefault("scraper.allow_private_networks", false)
+	viper.SetDefault("scraper.priority_refresh_interval", "6h")
+
+	viper.SetDefault("currency.base", "EUR")
+	viper.SetDefault("currency.display", "EUR")
+	viper.SetDefault("currency.rates_file", "./configs/exchange_rates.yaml")
+	viper.SetDefault("currency.compare_landed_cost", false)
 
 	viper.SetDefault("email.smtp_host", "smtp.gmail.com")
 	viper.SetDefault("email.smtp_port", 587)
 	viper.SetDefault("email.smtp_user", "")
 	viper.SetDefault("email.smtp_pass", "")
 	viper.SetDefault("email.smtp_from", "")
+	viper.SetDefault("email.outbox_poll_interval", "15s")
+	viper.SetDefault("email.outbox_batch_size", 50)
+	viper.SetDefault("email.outbox_max_attempts", 8)
+	viper.SetDefault("email.outbox_retry_delay", "1m")
+	viper.SetDefault("email.outbox_max_delay", "6h")
+	viper.SetDefault("email.outbox_retention", "720h")
+	viper.SetDefault("email.unsubscribe_max_age", "4320h")
+
+	viper.SetDefault("notify.webhook_timeout", "10s")
+	viper.SetDefault("notify.webhook_max_retries", 3)
+	viper.SetDefault("notify.webhook_retry_delay", "2s")
+	viper.SetDefault("notify.webhook_allow_private_networks", false)
+	viper.SetDefault("notify.delivery_workers", 4)
+	viper.SetDefault("notify.delivery_queue_size", 256)
+	viper.SetDefault("notify.delivery_drain_timeout", "30s")
+	viper.SetDefault("notify.digest_daily_schedule", "0 8 * * *")
+	viper.SetDefault("notify.digest_weekly_schedule", "0 8 * * 1")
+	viper.SetDefault("notify.stream_heartbeat", "25s")
+	viper.SetDefault("notify.stream_buffer_size", 16)
+	viper.SetDefault("notify.stream_max_per_user", 10)
 
 	// Configurar Viper para leer del archivo
 	viper.SetConfigName("config")
@@ -153,6 +270,17 @@ func InitConfig() {
 		smtpFrom = viper.GetString("email.smtp_from")
 	}
 
+	unsubscribeSecret := os.Getenv("UNSUBSCRIBE_SECRET")
+	if unsubscribeSecret == "" {
+		unsubscribeSecret = viper.GetString("email.unsubscribe_secret")
+	}
+
+	// Tiendas configuradas (si la sección no existe se usan las tiendas registradas por defecto)
+	var stores []StoreConfig
+	if err := viper.UnmarshalKey("stores", &stores); err != nil {
+		log.Printf("Error al leer la sección 'stores' de la configuración: %s", err)
+	}
+
 	// Parsear la configuración
 	Config = &Configuration{
 		App: AppConfig{
@@ -176,10 +304,28 @@ func InitConfig() {
 			ConnMaxLifetime: viper.GetDuration("database.conn_max_lifetime"),
 		},
 		Scraper: ScraperConfig{
-			UpdateInterval: viper.GetDuration("scraper.update_interval"),
-			UserAgent:      viper.GetString("scraper.user_agent"),
-			MaxRetries:     viper.GetInt("scraper.max_retries"),
-			RetryDelay:     viper.GetDuration("scraper.retry_delay"),
+			UpdateInterval:   viper.GetDuration("scraper.update_interval"),
+			UserAgent:        viper.GetString("scraper.user_agent"),
+			MaxRetries:       viper.GetInt("scraper.max_retries"),
+			RetryDelay:       viper.GetDuration("scraper.retry_delay"),
+			RequestDelay:     viper.GetDuration("scraper.request_delay"),
+			RequestTimeout:   viper.GetDuration("scraper.request_timeout"),
+			RespectRobotsTxt: viper.GetBool("scraper.respect_robots_txt"),
+			StoresDir:        viper.GetString("scraper.stores_dir"),
+			MaxPages:         viper.GetInt("scraper.max_pages"),
+			StopWhenNoNew:    viper.GetBool("scraper.stop_when_no_new"),
+			FixturesMode:     viper.GetString("scraper.fixtures_mode"),
+			FixturesDir:      viper.GetString("scraper.fixtures_dir"),
+
+			AllowPrivateNetworks:    viper.GetBool("scraper.allow_private_networks"),
+			PriorityRefreshInterval: viper.GetDuration("scraper.priority_refresh_interval"),
+		},
+		Currency: CurrencyConfig{
+			Base:      strings.ToUpper(viper.GetString("currency.base")),
+			Display:   strings.ToUpper(viper.GetString("currency.display")),
+			RatesFile: viper.GetString("currency.rates_file"),
+
+			CompareLandedCost: viper.GetBool("currency.compare_landed_cost"),
 		},
 		Email: EmailConfig{
 			SMTPHost: smtpHost,
@@ -187,7 +333,36 @@ func InitConfig() {
 			SMTPUser: smtpUser,
 			SMTPPass: smtpPass,
 			SMTPFrom: smtpFrom,
+
+			UnsubscribeSecret: unsubscribeSecret,
+			UnsubscribeMaxAge: viper.GetDuration("email.unsubscribe_max_age"),
+
+			OutboxPollInterval: viper.GetDuration("email.outbox_poll_interval"),
+			OutboxBatchSize:    viper.GetInt("email.outbox_batch_size"),
+			OutboxMaxAttempts:  viper.GetInt("email.outbox_max_attempts"),
+			OutboxRetryDelay:   viper.GetDuration("email.outbox_retry_delay"),
+			OutboxMaxDelay:     viper.GetDuration("email.outbox_max_delay"),
+			OutboxRetention:    viper.GetDuration("email.outbox_retention"),
+		},
+		Notify: NotifyConfig{
+			WebhookTimeout:    viper.GetDuration("notify.webhook_timeout"),
+			WebhookMaxRetries: viper.GetInt("notify.webhook_max_retries"),
+			WebhookRetryDelay: viper.GetDuration("notify.webhook_retry_delay"),
+
+			WebhookAllowPrivateNetworks: viper.GetBool("notify.webhook_allow_private_networks"),
+
+			DeliveryWorkers:      viper.GetInt("notify.delivery_workers"),
+			DeliveryQueueSize:    viper.GetInt("notify.delivery_queue_size"),
+			DeliveryDrainTimeout: viper.GetDuration("notify.delivery_drain_timeout"),
+
+			DigestDailySchedule:  viper.GetString("notify.digest_daily_schedule"),
+			DigestWeeklySchedule: viper.GetString("notify.digest_weekly_schedule"),
+
+			StreamHeartbeat:  viper.GetDuration("notify.stream_heartbeat"),
+			StreamBufferSize: viper.GetInt("notify.stream_buffer_size"),
+			StreamMaxPerUser: viper.GetInt("notify.stream_max_per_user"),
 		},
+		Stores: stores,
 	}
 
 	log.Printf("Configuración cargada correctamente. Ambiente: %s", Config.App.Environment)
@@ -45,6 +45,60 @@ func ExtractPrice(s string) (float64, error) {
 	return price, nil
 }
 
+// ExtractPriceWithLocale extrae un precio de un texto usando el formato numérico indicado.
+// Con locale "es" el punto separa miles y la coma decimales ("1.349,95"); con "en" es al revés
+// ("1,349.95"). Con cualquier otro valor se usa la detección automática de ExtractPrice
+func ExtractPriceWithLocale(s string, locale string) (float64, error) {
+	switch strings.ToLower(locale) {
+	case "es", "eu":
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.ReplaceAll(s, ",", ".")
+	case "en", "us":
+		s = strings.ReplaceAll(s, ",", "")
+	default:
+		return ExtractPrice(s)
+	}
+
+	re := regexp.MustCompile(`\d+(\.\d+)?`)
+	match := re.FindString(s)
+	if match == "" {
+		return 0, fmt.Errorf("no se encontró un precio válido en: %s", s)
+	}
+
+	price, err := strconv.ParseFloat(match, 64)
+	if err != nil {
+		return 0, fmt.Errorf("error al convertir '%s' a float: %v", match, err)
+	}
+
+	return price, nil
+}
+
+// freeCostTexts son los textos que indican que un coste adicional (envío, aduanas) es gratuito
+var freeCostTexts = []string{"free", "gratis", "gratuito", "sin coste", "sin costes"}
+
+// ExtractAdditionalCost interpreta el texto de un coste adicional de una oferta, como los gastos
+// de envío ("+$25.00 shipping", "Envío gratis", "+4,99 € envío") o los cargos de importación.
+// Devuelve nil si el texto no indica un importe, y 0 si el coste es gratuito
+func ExtractAdditionalCost(s string, locale string) *float64 {
+	text := strings.ToLower(strings.TrimSpace(s))
+	if text == "" {
+		return nil
+	}
+
+	for _, free := range freeCostTexts {
+		if strings.Contains(text, free) {
+			zero := 0.0
+			return &zero
+		}
+	}
+
+	cost, err := ExtractPriceWithLocale(text, locale)
+	if err != nil {
+		return nil
+	}
+	return &cost
+}
+
 // WriteDebugFile escribe datos binarios a un archivo para depuración
 func WriteDebugFile(filename string, data []byte) error {
 	return os.WriteFile(filename, data, 0644)
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"app/internal/domain/model"
+)
+
+// NormalizeGTIN limpia un código EAN/UPC/GTIN, comprueba su dígito de control y lo devuelve
+// con 14 dígitos (rellenando con ceros a la izquierda), de forma que el mismo producto tenga el
+// mismo código aunque una tienda muestre el UPC de 12 dígitos y otra el EAN de 13
+func NormalizeGTIN(s string) (string, bool) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		if unicode.IsSpace(r) || r == '-' {
+			return -1
+		}
+		return 'x'
+	}, s)
+	if strings.Contains(digits, "x") {
+		return "", false
+	}
+
+	switch len(digits) {
+	case 8, 12, 13, 14:
+	default:
+		return "", false
+	}
+	if strings.Trim(digits, "0") == "" || !validGTINCheckDigit(digits) {
+		return "", false
+	}
+
+	return strings.Repeat("0", 14-len(digits)) + digits, true
+}
+
+// validGTINCheckDigit comprueba el dígito de control de un GTIN (algoritmo módulo 10 de GS1)
+func validGTINCheckDigit(digits string) bool {
+	sum := 0
+	for i := len(digits) - 2; i >= 0; i-- {
+		digit := int(digits[i] - '0')
+		// Desde la derecha, excluyendo el dígito de control, las posiciones impares pesan 3
+		if (len(digits)-2-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	check := (10 - sum%10) % 10
+	return check == int(digits[len(digits)-1]-'0')
+}
+
+// NormalizeMPN limpia una referencia de fabricante: mayúsculas y sin espacios, guiones ni puntos.
+// Se descartan las referencias demasiado cortas o sin dígitos, que suelen ser textos genéricos
+// ("N/A", "Does not apply")
+func NormalizeMPN(s string) (string, bool) {
+	var b strings.Builder
+	hasDigit := false
+	for _, r := range strings.ToUpper(s) {
+		switch {
+		case r >= '0' && r <= '9':
+			hasDigit = true
+			b.WriteRune(r)
+		case r >= 'A' && r <= 'Z', r == '/':
+			b.WriteRune(r)
+		}
+	}
+
+	mpn := b.String()
+	if len(mpn) < 4 || len(mpn) > 64 || !hasDigit {
+		return "", false
+	}
+	return mpn, true
+}
+
+// AddProductIdentifier normaliza un código del tipo indicado (model.IdentifierGTIN o
+// model.IdentifierMPN) y lo añade al producto si es válido y no lo tenía ya
+func AddProductIdentifier(product *model.Product, kind, raw, source string) bool {
+	var value string
+	var ok bool
+	switch kind {
+	case model.IdentifierGTIN:
+		value, ok = NormalizeGTIN(raw)
+	case model.IdentifierMPN:
+		value, ok = NormalizeMPN(raw)
+	}
+	if !ok {
+		return false
+	}
+
+	for _, identifier := range product.Identifiers {
+		if identifier.Type == kind && identifier.Value == value {
+			return false
+		}
+	}
+	product.Identifiers = append(product.Identifiers, model.ProductIdentifier{
+		Type:   kind,
+		Value:  value,
+		Source: source,
+	})
+	return true
+}
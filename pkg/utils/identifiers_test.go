@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeGTIN(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		ok    bool
+	}{
+		{"EAN-13", "4006381333931", "04006381333931", true},
+		{"UPC-A", "036000291452", "00036000291452", true},
+		{"EAN-8", "96385074", "00000096385074", true},
+		{"GTIN-14", "10036000291459", "10036000291459", true},
+		{"UPC y EAN del mismo producto", "0036000291452", "00036000291452", true},
+		{"espacios y guiones", " 400-6381 333931 ", "04006381333931", true},
+		{"dígito de control incorrecto", "4006381333932", "", false},
+		{"longitud no admitida", "40063813339", "", false},
+		{"solo ceros", "0000000000000", "", false},
+		{"letras", "40063813339X1", "", false},
+		{"vacío", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NormalizeGTIN(tt.input)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("NormalizeGTIN(%q) = (%q, %t), se esperaba (%q, %t)", tt.input, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNormalizeMPN(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		ok    bool
+	}{
+		{"referencia limpia", "MZ-V9P2T0BW", "MZV9P2T0BW", true},
+		{"minúsculas, espacios y puntos", "kf 552c40.bbk2-32", "KF552C40BBK232", true},
+		{"conserva la barra", "90NR0GW1-M00/A", "90NR0GW1M00/A", true},
+		{"sin dígitos", "Does not apply", "", false},
+		{"texto genérico", "N/A", "", false},
+		{"demasiado corta", "A-1", "", false},
+		{"límite de longitud", strings.Repeat("AB1", 21) + "C", strings.Repeat("AB1", 21) + "C", true},
+		{"demasiado larga", strings.Repeat("AB1", 22), "", false},
+		{"vacía", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NormalizeMPN(tt.input)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("NormalizeMPN(%q) = (%q, %t), se esperaba (%q, %t)", tt.input, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
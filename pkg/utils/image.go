@@ -7,8 +7,11 @@ import (
 	"image/jpeg"
 	"image/png"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/corona10/goimagehash"
 )
@@ -64,9 +67,30 @@ func IsPlaceholderImage(url string) bool {
 	return false
 }
 
-// DownloadImage descarga una imagen desde una URL
-func DownloadImage(url string) (image.Image, error) {
-	resp, err := http.Get(url)
+// imageClient descarga las imágenes de los productos. Sus URLs salen de las páginas scrapeadas,
+// también de las que envían los usuarios, así que nunca se conecta a la propia máquina ni a una
+// red privada, tampoco tras una redirección
+var imageClient = &http.Client{
+	Timeout: 30 * time.Second,
+	Transport: &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         (&net.Dialer{Timeout: 10 * time.Second, Control: PublicDialControl}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+	},
+	CheckRedirect: CheckPublicRedirect,
+}
+
+// DownloadImage descarga una imagen desde una URL pública
+func DownloadImage(imageURL string) (image.Image, error) {
+	parsed, err := url.Parse(imageURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("URL de imagen no válida: %s", imageURL)
+	}
+	if IsLocalHost(parsed.Hostname()) {
+		return nil, fmt.Errorf("error al descargar imagen: %w", ErrPrivateAddress)
+	}
+
+	resp, err := imageClient.Get(imageURL)
 	if err != nil {
 		return nil, fmt.Errorf("error al descargar imagen: %w", err)
 	}
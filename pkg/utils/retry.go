@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ShouldRetry indica si una petición HTTP merece reintentarse: errores de red (salvo cancelación o
+// destino no permitido), 429 Too Many Requests y errores 5xx del servidor
+func ShouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) &&
+			!errors.Is(err, ErrPrivateAddress)
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// RetryBackoff calcula la espera antes del siguiente intento: base * 2^intento, o la indicada por
+// la cabecera Retry-After (en segundos) si la respuesta la trae
+func RetryBackoff(base time.Duration, attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return base * time.Duration(1<<attempt)
+}
+
+// SleepContext espera el tiempo indicado o hasta que se cancele el contexto
+func SleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestShouldRetry(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		err    error
+		want   bool
+	}{
+		{name: "error de red", err: errors.New("connection reset"), want: true},
+		{name: "contexto cancelado", err: fmt.Errorf("envío: %w", context.Canceled), want: false},
+		{name: "tiempo agotado", err: context.DeadlineExceeded, want: false},
+		{name: "dirección privada", err: fmt.Errorf("dial: %w", ErrPrivateAddress), want: false},
+		{name: "200", status: http.StatusOK, want: false},
+		{name: "404", status: http.StatusNotFound, want: false},
+		{name: "429", status: http.StatusTooManyRequests, want: true},
+		{name: "503", status: http.StatusServiceUnavailable, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp *http.Response
+			if tt.err == nil {
+				resp = &http.Response{StatusCode: tt.status}
+			}
+			if got := ShouldRetry(resp, tt.err); got != tt.want {
+				t.Errorf("ShouldRetry() = %t, se esperaba %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	retryAfter := func(value string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": []string{value}}}
+	}
+
+	tests := []struct {
+		name    string
+		attempt int
+		resp    *http.Response
+		want    time.Duration
+	}{
+		{name: "primer intento", attempt: 0, want: time.Second},
+		{name: "tercer intento", attempt: 2, want: 4 * time.Second},
+		{name: "Retry-After", attempt: 2, resp: retryAfter("30"), want: 30 * time.Second},
+		{name: "Retry-After no numérico", attempt: 1, resp: retryAfter("Wed, 21 Oct 2026 07:28:00 GMT"), want: 2 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RetryBackoff(time.Second, tt.attempt, tt.resp); got != tt.want {
+				t.Errorf("RetryBackoff() = %s, se esperaba %s", got, tt.want)
+			}
+		})
+	}
+}
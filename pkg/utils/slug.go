@@ -70,3 +70,32 @@ func GenerateUniqueSlug(text string, checkExists func(string) bool) string {
 
 	return slug
 }
+
+// NormalizeSearchText prepara un texto para buscar palabras en él: minúsculas, sin acentos y con
+// los caracteres no alfanuméricos sustituidos por un único espacio.
+// Convierte "SSD NVMe 2TB (M.2)" en "ssd nvme 2tb m 2"
+func NormalizeSearchText(text string) string {
+	normalized := strings.ToLower(text)
+
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	normalized, _, _ = transform.String(t, normalized)
+
+	normalized = regExpNonAlphanumeric.ReplaceAllString(normalized, " ")
+	normalized = regExpMultipleSpaces.ReplaceAllString(normalized, " ")
+
+	return strings.TrimSpace(normalized)
+}
+
+// ContainsAllTerms indica si el texto contiene todas las palabras de la búsqueda, sin distinguir
+// mayúsculas ni acentos. Cada palabra puede aparecer dentro de otra ("nvme" en "nvme2") o con los
+// espacios quitados del texto, para que "2tb" encuentre "2 TB"
+func ContainsAllTerms(text string, terms string) bool {
+	normalized := NormalizeSearchText(text)
+	compact := strings.ReplaceAll(normalized, " ", "")
+	for _, term := range strings.Fields(NormalizeSearchText(terms)) {
+		if !strings.Contains(normalized, term) && !strings.Contains(compact, term) {
+			return false
+		}
+	}
+	return true
+}
@@ -1,9 +1,92 @@
 package utils
 
 import (
+	"errors"
+	"net"
+	"net/http"
 	"strings"
+	"syscall"
 )
 
+// ErrPrivateAddress indica que una petición apunta a la propia máquina o a una red privada
+var ErrPrivateAddress = errors.New("la URL apunta a una dirección local o privada")
+
+// maxRedirects es el número máximo de redirecciones que se siguen (el mismo que net/http)
+const maxRedirects = 10
+
+var (
+	// carrierGradeNAT es el rango compartido de los operadores (RFC 6598), que no es público
+	carrierGradeNAT = mustParseCIDR("100.64.0.0/10")
+	// nat64Prefixes son los prefijos NAT64 (RFC 6052 y RFC 8215) que llevan una IPv4 en sus últimos
+	// 4 bytes, por los que se puede llegar a una IPv4 interna con una dirección IPv6
+	nat64Prefixes = []*net.IPNet{mustParseCIDR("64:ff9b::/96"), mustParseCIDR("64:ff9b:1::/48")}
+)
+
+// IsPrivateIP indica si la IP es de la propia máquina o de una red privada. Las IPv6 que llevan una
+// IPv4 (mapeadas o NAT64) se comprueban con la IPv4 que contienen
+func IsPrivateIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	} else if len(ip) == net.IPv6len {
+		for _, prefix := range nat64Prefixes {
+			if prefix.Contains(ip) {
+				return IsPrivateIP(net.IP(ip[net.IPv6len-net.IPv4len:]))
+			}
+		}
+	}
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || carrierGradeNAT.Contains(ip)
+}
+
+// mustParseCIDR interpreta un rango escrito en el código
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// IsLocalHost indica si el nombre o la IP de una URL apuntan a la propia máquina o a una red
+// privada. Solo mira el texto: un dominio que resuelve a una IP privada se detecta al conectar
+func IsLocalHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".local") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && IsPrivateIP(ip)
+}
+
+// PublicDialControl es la función Control de un net.Dialer que rechaza las conexiones a la propia
+// máquina o a una red privada. Comprueba la IP a la que se conecta realmente, no solo el nombre de
+// la URL, para que un dominio que resuelve a una IP privada no sirva para saltarse la restricción
+func PublicDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip != nil && IsPrivateIP(ip) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// CheckPublicRedirect es un CheckRedirect de http.Client que vuelve a validar cada redirección:
+// solo http(s), como mucho 10 saltos y nunca hacia la propia máquina o una red privada
+func CheckPublicRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return errors.New("demasiadas redirecciones")
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return errors.New("redirección a un esquema no permitido: " + req.URL.Scheme)
+	}
+	if IsLocalHost(req.URL.Hostname()) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
 // IsEbayURL verifica si una URL pertenece al dominio de eBay
 func IsEbayURL(url string) bool {
 	return strings.Contains(url, "ebay.com")
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIsPrivateIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"8.8.8.8", false},
+		{"2606:4700:4700::1111", false},
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.10", true},
+		{"169.254.169.254", true},
+		{"0.0.0.0", true},
+		{"::1", true},
+		{"fd00::1", true},
+		{"fe80::1", true},
+		{"100.64.0.1", true},      // CGNAT
+		{"100.127.255.254", true}, // CGNAT, final del rango
+		{"100.63.255.255", false}, // Justo antes del CGNAT
+		{"100.128.0.1", false},    // Justo después del CGNAT
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:169.254.169.254", true},
+		{"::ffff:8.8.8.8", false},
+		{"64:ff9b::a9fe:a9fe", true}, // NAT64 de 169.254.169.254
+		{"64:ff9b::7f00:1", true},    // NAT64 de 127.0.0.1
+		{"64:ff9b::808:808", false},  // NAT64 de 8.8.8.8
+		{"64:ff9b:1::a00:1", true},   // NAT64 local de 10.0.0.1
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			ip := net.ParseIP(tt.ip)
+			if ip == nil {
+				t.Fatalf("IP de prueba no válida: %s", tt.ip)
+			}
+			if got := IsPrivateIP(ip); got != tt.want {
+				t.Errorf("IsPrivateIP(%s) = %t, se esperaba %t", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsLocalHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"www.coolmod.com", false},
+		{"localhost", true},
+		{"api.localhost", true},
+		{"nas.local", true},
+		{"100.64.10.1", true},
+		{"::ffff:10.0.0.1", true},
+	}
+
+	for _, tt := range tests {
+		if got := IsLocalHost(tt.host); got != tt.want {
+			t.Errorf("IsLocalHost(%q) = %t, se esperaba %t", tt.host, got, tt.want)
+		}
+	}
+}
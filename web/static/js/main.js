@@ -14,6 +14,9 @@ document.addEventListener('DOMContentLoaded', function() {
     
     // Configurar manejo de visibilidad de página
     setupPageVisibilityHandling();
+
+    // Recibir las notificaciones nuevas sin recargar la página
+    initNotificationStream();
 });
 
 /**
@@ -468,6 +471,29 @@ function initProductDetailPage() {
         });
     }
     
+    // Mostrar solo los campos que usa el tipo de regla elegido
+    const ruleType = document.getElementById('alertRuleType');
+    if (ruleType) {
+        const updateRuleFields = function() {
+            alertForm.querySelectorAll('[data-alert-rule]').forEach(function(field) {
+                const rules = field.dataset.alertRule.split(' ');
+                const visible = rules.includes(ruleType.value);
+                if (field.tagName === 'INPUT') {
+                    field.disabled = !visible;
+                    field.placeholder = visible ? 'Precio objetivo' : 'No se usa con esta condición';
+                } else {
+                    field.classList.toggle('d-none', !visible);
+                }
+            });
+            const unit = document.getElementById('alertRuleValueUnit');
+            if (unit) {
+                unit.textContent = ruleType.value === 'below_average' ? 'días' : '%';
+            }
+        };
+        ruleType.addEventListener('change', updateRuleFields);
+        updateRuleFields();
+    }
+
     // Elementos para el feedback visual
     const alertFeedback = document.getElementById('alertFeedback');
     const alertFeedbackText = document.getElementById('alertFeedbackText');
@@ -1613,4 +1639,59 @@ function setupPageVisibilityHandling() {
             }
         }
     }, 30000); // Verificar cada 30 segundos
-} 
\ No newline at end of file
+}
+
+/**
+ * Abre la conexión de notificaciones en tiempo real (Server-Sent Events) del usuario logueado:
+ * actualiza el contador del menú y muestra un aviso por cada notificación nueva. El navegador
+ * reabre la conexión solo si se corta; si el servidor responde con un error (sin sesión o con
+ * demasiadas pestañas abiertas) no se reintenta
+ */
+function initNotificationStream() {
+    const badge = document.querySelector('.notification-badge');
+    if (!badge || typeof EventSource === 'undefined') return;
+
+    const source = new EventSource('/api/notificaciones/stream');
+
+    source.addEventListener('unread', function(event) {
+        const data = JSON.parse(event.data);
+        updateNotificationBadge(badge, data.count);
+    });
+
+    source.addEventListener('notification', function(event) {
+        const data = JSON.parse(event.data);
+        updateNotificationBadge(badge, data.unread_count);
+
+        if (typeof Swal === 'undefined') return;
+        // titleText y text no interpretan HTML: el título lleva el nombre del producto de la tienda
+        Swal.fire({
+            toast: true,
+            position: 'top-end',
+            icon: 'info',
+            titleText: data.title,
+            text: data.message,
+            showConfirmButton: true,
+            confirmButtonText: 'Ver producto',
+            showCloseButton: true,
+            timer: 8000,
+            timerProgressBar: true
+        }).then(function(result) {
+            if (result.isConfirmed) {
+                window.location.href = data.url;
+            }
+        });
+    });
+}
+
+/**
+ * Actualiza el contador de notificaciones sin leer del menú. Un valor negativo indica que el
+ * servidor no ha podido contarlas y se mantiene el contador actual
+ */
+function updateNotificationBadge(badge, count) {
+    if (typeof count !== 'number' || count < 0) return;
+    if (count > 0) {
+        badge.setAttribute('data-count', count > 99 ? '99+' : count);
+    } else {
+        badge.removeAttribute('data-count');
+    }
+}